@@ -3,57 +3,119 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
-	"time"
 
-	_ "github.com/example/go-template/docs" // Import docs for swagger
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
 	"github.com/example/go-template/internal/server"
 )
 
-// @title Go Template API
-// @version 1.0
-// @description A simple Go API template with calculator and user management endpoints
-// @host localhost:8080
-// @BasePath /
-// @schemes http
 func main() {
+	// An optional leading subcommand (routes, config) precedes the flags
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	switch command {
+	case "", "routes":
+	case "config":
+		// Print the effective configuration with secrets redacted and exit
+		if err := cfg.Dump(os.Stdout); err != nil {
+			log.Fatalf("Failed to dump configuration: %v", err)
+		}
+		cfg.SelfCheck().Print(os.Stderr)
+		return
+	default:
+		log.Fatalf("Unknown command %q, expected routes or config", command)
+	}
+
+	// Initialize DI providers. Resources such as the history database and
+	// the span exporter are only opened by the lifecycle start hooks.
+	providers := di.NewProviders(cfg)
+	logger := providers.Logger
+	slog.SetDefault(logger)
+
 	// Create a new server instance
-	srv := server.New()
+	srv := server.New(providers)
+
+	if command == "routes" {
+		// List the registered routes and exit
+		if err := srv.Routes().Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to list routes: %v", err)
+		}
+		return
+	}
+
+	// Report every insecure setting; prod already refused to load them
+	cfg.SelfCheck().Log(logger)
 
-	// Create HTTP server
+	// Create HTTP server, started last and stopped first by the lifecycle.
+	// Shutdown leaves WebSocket sessions open; the calculator-sessions hook
+	// closes them once the server stopped accepting connections.
+	addr := ":" + strconv.Itoa(cfg.Server.Port)
 	httpServer := &http.Server{
-		Addr:    ":8080",
+		Addr:    addr,
 		Handler: srv.Router(),
 	}
+	serveErr := make(chan error, 1)
+	providers.Lifecycle.Append(server.ListenHook(httpServer, providers.Health, cfg.Server.DrainDelay, serveErr))
 
-	// Channel to listen for interrupt signal to terminate server
-	quit := make(chan os.Signal, 1)
+	// Listen for the signals requesting shutdown; a second one forces exit
+	quit := make(chan os.Signal, 2)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start server in a goroutine
-	go func() {
-		log.Println("Starting server on :8080")
-		log.Println("Swagger UI available at: http://localhost:8080/swagger/")
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
+	if err := providers.Lifecycle.Start(context.Background()); err != nil {
+		logger.Error("server failed to start", "error", err)
+		os.Exit(1)
+	}
+	providers.Health.MarkStarted()
+	logger.Info("server started", "addr", addr, "env", cfg.Env, "docs", "/docs/")
 
-	// Wait for interrupt signal
-	<-quit
-	log.Println("Shutting down server...")
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		logger.Info("shutting down server", "signal", sig.String(), "grace_period", cfg.Server.ShutdownTimeout.String())
+	case err := <-serveErr:
+		logger.Error("server failed", "error", err)
+		exitCode = 1
+	}
 
-	// Create a deadline for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Drain requests and stop every component within the grace period
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Attempt graceful shutdown
-	if err := httpServer.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	stopped := make(chan error, 1)
+	go func() { stopped <- providers.Lifecycle.Stop(ctx) }()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			logger.Error("shutdown incomplete", "error", err)
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		logger.Error("grace period elapsed, forcing exit")
+		os.Exit(1)
+	case sig := <-quit:
+		logger.Error("received second signal, forcing exit", "signal", sig.String())
+		os.Exit(1)
 	}
 
-	log.Println("Server exited")
+	logger.Info("server exited")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
@@ -1,90 +1,335 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/example/go-template/internal/common"
 	"github.com/example/go-template/internal/di"
 	"github.com/example/go-template/internal/domain"
-	"github.com/example/go-template/internal/middleware"
-	"github.com/labstack/echo/v4"
-	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/example/go-template/internal/patch"
+	"github.com/example/go-template/internal/repositories"
+	"github.com/example/go-template/internal/services"
+	"github.com/gorilla/mux"
 )
 
-// RegisterRoutes registers all API routes
-func RegisterRoutes(e *echo.Echo, providers *di.Providers) {
-	// Swagger documentation
-	e.GET("/docs", echoSwagger.WrapHandler)
-	e.GET("/docs/*", echoSwagger.WrapHandler)
+// Handler handles the authentication and customer API requests
+type Handler struct {
+	common.BaseHandler
+	providers *di.Providers
+}
 
-	// Public routes
-	e.GET("/v1/public", handlePublic)
-	e.GET("/v1/customer", handleGetCustomer(providers))
+// NewHandler creates a new API handler
+func NewHandler(providers *di.Providers) *Handler {
+	return &Handler{
+		providers: providers,
+	}
+}
 
-	// Auth routes
-	e.GET("/v1/auth/login", handleLogin(providers))
+// RegisterRoutes registers the API routes. Protected routes need a Router
+// with JWT authentication configured and are skipped on a plain mux router.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	for _, group := range h.Routes() {
+		public := common.RouteGroup{Prefix: group.Prefix}
+		for _, route := range group.Routes {
+			if route.Auth == "" || route.Auth == common.AuthNone {
+				public.Routes = append(public.Routes, route)
+			}
+		}
+		common.RegisterGroup(router, public)
+	}
+}
 
-	// Protected routes (with JWT middleware)
-	protected := e.Group("")
-	protected.Use(middleware.JWTMiddleware(providers.AuthService))
-	protected.GET("/v1/private", handlePrivate)
+// Routes returns the API route groups
+func (h *Handler) Routes() []common.RouteGroup {
+	return []common.RouteGroup{{
+		Prefix: "/v1",
+		Routes: []common.Route{
+			common.NamedRoute("/public", "GET", "public.get", h.handlePublic).WithDoc(common.RouteDoc{
+				Summary:  "Public endpoint",
+				Tags:     []string{"public"},
+				Response: domain.PublicResponse{},
+				Raw:      true,
+			}),
+			common.NamedRoute("/customer", "GET", "customers.list", h.handleGetCustomer).WithDoc(common.RouteDoc{
+				Summary:  "List customers",
+				Tags:     []string{"customers"},
+				Response: []domain.CustomerResponse{},
+				Errors:   []int{http.StatusInternalServerError},
+				Raw:      true,
+			}),
+			common.NamedRoute("/customer", "POST", "customers.create", h.handleCreateCustomer).WithDoc(common.RouteDoc{
+				Summary:  "Create a customer",
+				Tags:     []string{"customers"},
+				Request:  domain.CreateCustomerRequest{},
+				Response: domain.CustomerResponse{},
+				Status:   http.StatusCreated,
+				Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+				Raw:      true,
+			}),
+			common.NamedRoute("/customer/{id}", "GET", "customers.get", h.handleGetCustomerByID).WithDoc(common.RouteDoc{
+				Summary:     "Get a customer",
+				Description: "The ETag header holds the customer version; a matching If-None-Match answers 304",
+				Tags:        []string{"customers"},
+				Response:    domain.CustomerResponse{},
+				Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+				Raw:         true,
+			}),
+			common.NamedRoute("/customer/{id}", "PUT", "customers.update", h.handleUpdateCustomer).WithDoc(common.RouteDoc{
+				Summary:     "Update a customer",
+				Description: "Replaces the name and email of a customer. With If-Match, the update only applies to that version.",
+				Tags:        []string{"customers"},
+				Request:     domain.CreateCustomerRequest{},
+				Response:    domain.CustomerResponse{},
+				Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusInternalServerError},
+				Raw:         true,
+			}),
+			common.NamedRoute("/customer/{id}", "PATCH", "customers.patch", h.handlePatchCustomer).WithDoc(common.RouteDoc{
+				Summary:     "Partially update a customer",
+				Description: "Applies a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) to the customer, validating the patched result. With If-Match, the patch only applies to that version.",
+				Tags:        []string{"customers"},
+				Request:     map[string]interface{}{},
+				Response:    domain.CustomerResponse{},
+				Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+					http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+				Raw: true,
+			}),
+			common.NamedRoute("/customer/{id}", "DELETE", "customers.delete", h.handleDeleteCustomer).WithDoc(common.RouteDoc{
+				Summary:     "Delete a customer",
+				Description: "With If-Match, only that version is deleted",
+				Tags:        []string{"customers"},
+				Status:      http.StatusNoContent,
+				Errors:      []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusInternalServerError},
+				Raw:         true,
+			}),
+			common.NamedRoute("/auth/login", "GET", "auth.login", h.handleLogin).WithDoc(common.RouteDoc{
+				Summary:     "Issue a JWT token",
+				Description: "Returns the token in the body and in the Authorization and X-JWT-Token headers",
+				Tags:        []string{"auth"},
+				Response:    domain.LoginResponse{},
+				Errors:      []int{http.StatusInternalServerError},
+				Raw:         true,
+			}),
+			common.NamedRoute("/private", "GET", "private.get", h.handlePrivate).
+				WithAuth(common.AuthJWT).
+				WithDoc(common.RouteDoc{
+					Summary:  "Protected endpoint",
+					Tags:     []string{"private"},
+					Response: domain.PrivateResponse{},
+					Raw:      true,
+				}),
+		},
+	}}
 }
 
 // handlePublic handles public endpoint
-func handlePublic(c echo.Context) error {
-	return c.JSON(http.StatusOK, domain.PublicResponse{
+func (h *Handler) handlePublic(w http.ResponseWriter, r *http.Request) {
+	common.WriteJSON(w, http.StatusOK, domain.PublicResponse{
 		Message: "This is a public endpoint",
 	})
 }
 
 // handleGetCustomer handles getting a customer
-func handleGetCustomer(providers *di.Providers) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		customers, err := providers.CustomerService.ListCustomers()
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": err.Error(),
-			})
-		}
+func (h *Handler) handleGetCustomer(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.providers.CustomerService.ListCustomers(r.Context())
+	if err != nil {
+		h.WriteInternalError(w, err.Error())
+		return
+	}
 
-		result := make([]domain.CustomerResponse, len(customers))
-		for i, customer := range customers {
-			result[i] = domain.CustomerResponse{
-				ID:    customer.ID,
-				Name:  customer.Name,
-				Email: customer.Email,
-			}
-		}
+	result := make([]domain.CustomerResponse, len(customers))
+	for i, customer := range customers {
+		result[i] = customerResponse(customer)
+	}
+
+	common.WriteJSON(w, http.StatusOK, result)
+}
+
+// handleCreateCustomer handles creating a customer
+func (h *Handler) handleCreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.WriteBadRequest(w, "invalid request body")
+		return
+	}
 
-		return c.JSON(http.StatusOK, result)
+	customer, err := h.providers.CustomerService.CreateCustomer(r.Context(), req.Name, req.Email)
+	if err != nil {
+		h.writeCustomerError(w, err, "failed to create customer")
+		return
 	}
+
+	common.SetETag(w, customer.Version)
+	common.WriteJSON(w, http.StatusCreated, customerResponse(customer))
 }
 
-// handleLogin handles user login and JWT token generation
-func handleLogin(providers *di.Providers) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		// Generate JWT token
-		token, err := providers.AuthService.IssueToken("user@example.com")
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "failed to generate token",
-			})
-		}
+// handleGetCustomerByID handles getting a single customer
+func (h *Handler) handleGetCustomerByID(w http.ResponseWriter, r *http.Request) {
+	id, _ := h.GetURLParams(r).String("id")
+
+	customer, err := h.providers.CustomerService.GetCustomer(r.Context(), id)
+	if err != nil {
+		h.WriteInternalError(w, "failed to get customer")
+		return
+	}
+	if customer == nil {
+		h.WriteNotFound(w, repositories.ErrCustomerNotFound.Error())
+		return
+	}
+
+	if common.NotModified(w, r, customer.Version) {
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, customerResponse(customer))
+}
+
+// handleUpdateCustomer handles replacing a customer
+func (h *Handler) handleUpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	id, _ := h.GetURLParams(r).String("id")
+
+	version, err := common.IfMatch(r)
+	if err != nil {
+		h.WritePreconditionFailed(w, repositories.ErrVersionMismatch.Error())
+		return
+	}
+
+	var req domain.CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.WriteBadRequest(w, "invalid request body")
+		return
+	}
+
+	customer, err := h.providers.CustomerService.UpdateCustomer(r.Context(), id, req.Name, req.Email, version)
+	if err != nil {
+		h.writeCustomerError(w, err, "failed to update customer")
+		return
+	}
 
-		// Set response headers
-		c.Response().Header().Set("Authorization", "Bearer "+token)
-		c.Response().Header().Set("X-JWT-Token", token)
+	common.SetETag(w, customer.Version)
+	common.WriteJSON(w, http.StatusOK, customerResponse(customer))
+}
+
+// handlePatchCustomer handles partially updating a customer
+func (h *Handler) handlePatchCustomer(w http.ResponseWriter, r *http.Request) {
+	id, _ := h.GetURLParams(r).String("id")
+
+	version, err := common.IfMatch(r)
+	if err != nil {
+		h.WritePreconditionFailed(w, repositories.ErrVersionMismatch.Error())
+		return
+	}
+
+	current, err := h.providers.CustomerService.GetCustomer(r.Context(), id)
+	if err != nil {
+		h.WriteInternalError(w, "failed to get customer")
+		return
+	}
+	if current == nil {
+		h.WriteNotFound(w, repositories.ErrCustomerNotFound.Error())
+		return
+	}
+	if version != common.AnyVersion && version != current.Version {
+		h.WritePreconditionFailed(w, repositories.ErrVersionMismatch.Error())
+		return
+	}
+
+	original := customerResponse(current)
+	var patched domain.CustomerResponse
+	if err := patch.Request(w, r, original, &patched); err != nil {
+		patch.WriteError(w, err)
+		return
+	}
+	if patched.ID != original.ID || patched.Version != original.Version {
+		h.WriteError(w, http.StatusUnprocessableEntity, "id and version are read-only")
+		return
+	}
+
+	// The patch was computed from the version just read, so the update must
+	// not apply on top of a newer one
+	customer, err := h.providers.CustomerService.UpdateCustomer(r.Context(), id, patched.Name, patched.Email, current.Version)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidCustomer):
+		h.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	case errors.Is(err, repositories.ErrVersionMismatch) && version == common.AnyVersion:
+		h.WriteError(w, http.StatusConflict, "customer was modified concurrently, retry the request")
+		return
+	default:
+		h.writeCustomerError(w, err, "failed to update customer")
+		return
+	}
+
+	common.SetETag(w, customer.Version)
+	common.WriteJSON(w, http.StatusOK, customerResponse(customer))
+}
+
+// handleDeleteCustomer handles deleting a customer
+func (h *Handler) handleDeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id, _ := h.GetURLParams(r).String("id")
 
-		return c.JSON(http.StatusOK, domain.LoginResponse{
-			Token: token,
-		})
+	version, err := common.IfMatch(r)
+	if err != nil {
+		h.WritePreconditionFailed(w, repositories.ErrVersionMismatch.Error())
+		return
 	}
+
+	if err := h.providers.CustomerService.DeleteCustomer(r.Context(), id, version); err != nil {
+		h.writeCustomerError(w, err, "failed to delete customer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeCustomerError maps a customer service error to its status code
+func (h *Handler) writeCustomerError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCustomer):
+		h.WriteBadRequest(w, err.Error())
+	case errors.Is(err, repositories.ErrCustomerNotFound):
+		h.WriteNotFound(w, err.Error())
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		h.WritePreconditionFailed(w, err.Error())
+	default:
+		h.WriteInternalError(w, fallback)
+	}
+}
+
+// customerResponse converts a customer to its API representation
+func customerResponse(customer *domain.Customer) domain.CustomerResponse {
+	return domain.CustomerResponse{
+		ID:      customer.ID,
+		Name:    customer.Name,
+		Email:   customer.Email,
+		Version: customer.Version,
+	}
+}
+
+// handleLogin handles user login and JWT token generation
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	// Generate JWT token
+	token, err := h.providers.AuthService.IssueToken(r.Context(), "user@example.com")
+	if err != nil {
+		h.WriteInternalError(w, "failed to generate token")
+		return
+	}
+
+	// Set response headers
+	w.Header().Set("Authorization", "Bearer "+token)
+	w.Header().Set("X-JWT-Token", token)
+
+	common.WriteJSON(w, http.StatusOK, domain.LoginResponse{
+		Token: token,
+	})
 }
 
 // handlePrivate handles protected endpoint that requires JWT
-func handlePrivate(c echo.Context) error {
-	user := c.Get("user").(string)
+func (h *Handler) handlePrivate(w http.ResponseWriter, r *http.Request) {
+	user, _ := common.Principal(r.Context())
 
-	return c.JSON(http.StatusOK, domain.PrivateResponse{
+	common.WriteJSON(w, http.StatusOK, domain.PrivateResponse{
 		Message: "This is a private endpoint",
 		User:    user,
 	})
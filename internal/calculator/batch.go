@@ -0,0 +1,227 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// parallelBatchSize is the smallest batch spread over several goroutines.
+// Smaller ones finish faster than the goroutines start.
+const parallelBatchSize = 64
+
+// maxBatchOperands bounds the operands of a batch item, which min and max
+// would otherwise accept without limit
+const maxBatchOperands = 100
+
+// maxBatchItemBytes bounds the JSON text of a batch item when sizing the
+// body of batches: its operation and maxBatchOperands numbers
+const maxBatchItemBytes = maxBatchOperands*(maxNumberBytes+1) + 64
+
+// ErrBatchTooLarge reports a batch above the configured size
+var ErrBatchTooLarge = errors.New("batch too large")
+
+// BatchItem is one calculation of a batch: an operation such as add, divide
+// or sqrt, and its operands
+type BatchItem struct {
+	Op       string        `json:"op"`
+	Operands []json.Number `json:"operands"`
+}
+
+// BatchResult is the outcome of a batch item: a result, which is a decimal
+// string in decimal precision, or an error
+type BatchResult struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// arithmetic are the operations of batches besides the expression
+// functions
+var arithmetic = map[string]function{
+	"add": {2, 2, func(args []float64) (float64, error) {
+		return std.Add(args[0], args[1]), nil
+	}, func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return std.AddDecimal(args[0], args[1]), nil
+	}},
+	"subtract": {2, 2, func(args []float64) (float64, error) {
+		return std.Subtract(args[0], args[1]), nil
+	}, func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return std.SubtractDecimal(args[0], args[1]), nil
+	}},
+	"multiply": {2, 2, func(args []float64) (float64, error) {
+		return std.Multiply(args[0], args[1]), nil
+	}, func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return std.MultiplyDecimal(args[0], args[1]), nil
+	}},
+	"divide": {2, 2, func(args []float64) (float64, error) {
+		return std.Divide(args[0], args[1])
+	}, func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return std.DivideDecimal(args[0], args[1])
+	}},
+}
+
+// operation finds op among the arithmetic operations and the expression
+// functions and checks its number of operands, which is at most
+// maxBatchOperands
+func operation(op string, operands int) (function, error) {
+	fn, ok := arithmetic[op]
+	if !ok {
+		if fn, ok = functions[op]; !ok {
+			return function{}, fmt.Errorf("unknown operation %q", op)
+		}
+	}
+	if operands < fn.minArgs || (fn.maxArgs >= 0 && operands > fn.maxArgs) {
+		return function{}, fmt.Errorf("%s expects %s, got %d", op, arity(fn), operands)
+	}
+	if operands > maxBatchOperands {
+		return function{}, fmt.Errorf("%s accepts at most %d operands, got %d", op, maxBatchOperands, operands)
+	}
+	return fn, nil
+}
+
+// Calculate applies the operation named op to operands
+func (c *Calculator) Calculate(op string, operands []float64) (float64, error) {
+	fn, err := operation(op, len(operands))
+	if err != nil {
+		return 0, err
+	}
+	for _, x := range operands {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return 0, ErrNonFinite
+		}
+	}
+	result, err := fn.eval(operands)
+	if err != nil {
+		return 0, err
+	}
+	return checkFinite(op, result)
+}
+
+// CalculateDecimal applies the operation named op to exact operands,
+// computing irrational results to the precision of p
+func (c *Calculator) CalculateDecimal(op string, operands []*big.Rat, p Precision) (*big.Rat, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	fn, err := operation(op, len(operands))
+	if err != nil {
+		return nil, err
+	}
+	result, err := fn.exact(operands, precisionBits(p.Scale))
+	if err != nil {
+		return nil, err
+	}
+	if !fits(result) {
+		return nil, precisionError(op)
+	}
+	return p.Round(result), nil
+}
+
+// Batch computes every item, in float64 or, when p is not nil, in decimal
+// precision. Items fail independently and results keep the order of items.
+// Batches of at least parallelBatchSize items are shared by workers
+// goroutines.
+func (c *Calculator) Batch(items []BatchItem, p *Precision, workers int) []BatchResult {
+	results := make([]BatchResult, len(items))
+	if len(items) < parallelBatchSize || workers < 2 {
+		for i, item := range items {
+			results[i] = c.batchItem(item, p)
+		}
+		return results
+	}
+
+	// Each worker writes a contiguous chunk of the results
+	chunk := (len(items) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(items); start += chunk {
+		end := min(start+chunk, len(items))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				results[i] = c.batchItem(items[i], p)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return results
+}
+
+// batchItem computes a single batch item
+func (c *Calculator) batchItem(item BatchItem, p *Precision) BatchResult {
+	if p != nil {
+		operands := make([]*big.Rat, len(item.Operands))
+		for i, operand := range item.Operands {
+			x, err := ParseDecimal(string(operand))
+			if err != nil {
+				return BatchResult{Error: fmt.Sprintf("operand %d: %v", i+1, err)}
+			}
+			operands[i] = x
+		}
+		result, err := c.CalculateDecimal(item.Op, operands, *p)
+		if err != nil {
+			return BatchResult{Error: err.Error()}
+		}
+		return BatchResult{Result: p.Format(result)}
+	}
+
+	operands := make([]float64, len(item.Operands))
+	for i, operand := range item.Operands {
+		x, err := strconv.ParseFloat(string(operand), 64)
+		if err != nil {
+			return BatchResult{Error: fmt.Sprintf("operand %d is not a finite number", i+1)}
+		}
+		operands[i] = x
+	}
+	result, err := c.Calculate(item.Op, operands)
+	if err != nil {
+		return BatchResult{Error: err.Error()}
+	}
+	return BatchResult{Result: result}
+}
+
+// ReadBatch decodes a JSON array of batch items one item at a time,
+// failing with ErrBatchTooLarge as soon as it holds more than limit items
+// or r is an http.MaxBytesReader past its limit
+func ReadBatch(r io.Reader, limit int) ([]BatchItem, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, batchError(err, "expected a JSON array of batch items")
+	}
+
+	var items []BatchItem
+	for dec.More() {
+		if len(items) == limit {
+			return nil, fmt.Errorf("%w: at most %d items are accepted", ErrBatchTooLarge, limit)
+		}
+		var item BatchItem
+		if err := dec.Decode(&item); err != nil {
+			return nil, batchError(err, fmt.Sprintf("item %d: invalid batch item", len(items)+1))
+		}
+		items = append(items, item)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, batchError(err, "expected a JSON array of batch items")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, batchError(err, "unexpected data after the JSON array")
+	}
+	return items, nil
+}
+
+// batchError reports an invalid batch with msg, or ErrBatchTooLarge when
+// err comes from a body cut short by http.MaxBytesReader
+func batchError(err error, msg string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w: the body is over %d bytes", ErrBatchTooLarge, tooLarge.Limit)
+	}
+	return errors.New(msg)
+}
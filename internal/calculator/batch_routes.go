@@ -0,0 +1,54 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/example/go-template/internal/history"
+)
+
+// handleBatch handles batches of calculations
+func (h *Handler) handleBatch(w http.ResponseWriter, r *http.Request) {
+	p, decimal, err := precisionQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, int64(h.cfg.MaxBatchSize)*maxBatchItemBytes+1024)
+	items, err := ReadBatch(body, h.cfg.MaxBatchSize)
+	if errors.Is(err, ErrBatchTooLarge) {
+		h.WriteError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	var precision *Precision
+	if decimal {
+		precision = &p
+	}
+	results := h.calc.Batch(items, precision, h.cfg.BatchWorkers)
+
+	var entries []history.Entry
+	for i, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		entry := history.Entry{Operation: items[i].Op, Inputs: make([]string, len(items[i].Operands))}
+		for j, operand := range items[i].Operands {
+			entry.Inputs[j] = operand.String()
+		}
+		if s, ok := result.Result.(string); ok {
+			entry.Result = json.Number(s)
+		} else {
+			entry.Result = floatNumber(result.Result.(float64))
+		}
+		entries = append(entries, entry)
+	}
+	h.record(r, entries...)
+	h.WriteSuccess(w, results)
+}
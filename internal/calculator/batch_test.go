@@ -0,0 +1,119 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func item(op string, operands ...string) BatchItem {
+	numbers := make([]json.Number, len(operands))
+	for i, operand := range operands {
+		numbers[i] = json.Number(operand)
+	}
+	return BatchItem{Op: op, Operands: numbers}
+}
+
+func TestCalculator_Batch(t *testing.T) {
+	items := []BatchItem{
+		item("add", "1", "2"),
+		item("divide", "1", "0"),
+		item("sqrt", "16"),
+		item("max", "3", "9", "4"),
+		item("sqrt", "-1"),
+		item("cube", "2"),
+		item("pow", "2"),
+		item("multiply", "1e308", "10"),
+		item("subtract", "1e999", "1"),
+		item("factorial", "0"),
+		item("max", strings.Split(strings.Repeat("1,", maxBatchOperands)+"1", ",")...),
+	}
+	want := []BatchResult{
+		{Result: 3.0},
+		{Error: "cannot divide by zero"},
+		{Result: 4.0},
+		{Result: 9.0},
+		{Error: "sqrt of a negative number"},
+		{Error: `unknown operation "cube"`},
+		{Error: "pow expects 2 arguments, got 1"},
+		{Error: "result of multiply overflows"},
+		{Error: "operand 1 is not a finite number"},
+		{Result: 1.0},
+		{Error: "max accepts at most 100 operands, got 101"},
+	}
+
+	if got := New().Batch(items, nil, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Batch = %v; want %v", got, want)
+	}
+}
+
+func TestCalculator_BatchInParallel(t *testing.T) {
+	items := make([]BatchItem, 1000)
+	for i := range items {
+		items[i] = item("divide", strconv.Itoa(i), strconv.Itoa(i%3))
+	}
+
+	calc := New()
+	serial := calc.Batch(items, nil, 1)
+	parallel := calc.Batch(items, nil, 8)
+	if !reflect.DeepEqual(parallel, serial) {
+		t.Fatal("parallel batch results differ from serial ones")
+	}
+	if parallel[4].Result != 4.0 || parallel[999].Error != "cannot divide by zero" {
+		t.Errorf("results 4 and 999 = %v, %v; want 4 and a division by zero", parallel[4], parallel[999])
+	}
+}
+
+func TestCalculator_BatchDecimal(t *testing.T) {
+	p := Precision{Scale: 5, Rounding: RoundHalfEven}
+	items := []BatchItem{
+		item("add", "0.1", "0.2"),
+		item("divide", "2", "3"),
+		item("divide", "2", "0"),
+		item("sqrt", "2"),
+		item("add", "1e99999", "1"),
+	}
+	want := []BatchResult{
+		{Result: "0.3"},
+		{Result: "0.66667"},
+		{Error: "cannot divide by zero"},
+		{Result: "1.41421"},
+	}
+
+	got := New().Batch(items, &p, 1)
+	if !reflect.DeepEqual(got[:4], want) {
+		t.Errorf("Batch = %v; want %v", got[:4], want)
+	}
+	if !strings.HasPrefix(got[4].Error, "operand 1: ") {
+		t.Errorf("Batch of an out of range operand = %v; want an error on operand 1", got[4])
+	}
+}
+
+func TestReadBatch(t *testing.T) {
+	items, err := ReadBatch(strings.NewReader(`[{"op":"add","operands":[1,2.5]}, {"op":"sqrt","operands":[4]}]`), 2)
+	if err != nil {
+		t.Fatalf("ReadBatch returned error: %v", err)
+	}
+	if want := []BatchItem{item("add", "1", "2.5"), item("sqrt", "4")}; !reflect.DeepEqual(items, want) {
+		t.Errorf("ReadBatch = %v; want %v", items, want)
+	}
+
+	if _, err := ReadBatch(strings.NewReader(`[{"op":"add"},{"op":"add"},{"op":"add"}]`), 2); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("ReadBatch of 3 items with a limit of 2 error = %v; want ErrBatchTooLarge", err)
+	}
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`[{"op":"add","operands":[1,2]},{"op":"add"}]`)), 40)
+	if _, err := ReadBatch(body, 10); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("ReadBatch of a body over its limit error = %v; want ErrBatchTooLarge", err)
+	}
+	for _, input := range []string{`{"op":"add"}`, `[{"op":1}]`, `[{"op":"add"}`, `[] []`} {
+		if _, err := ReadBatch(strings.NewReader(input), 10); err == nil || errors.Is(err, ErrBatchTooLarge) {
+			t.Errorf("ReadBatch(%s) error = %v; want an invalid batch error", input, err)
+		}
+	}
+}
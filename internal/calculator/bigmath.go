@@ -0,0 +1,274 @@
+package calculator
+
+import (
+	"math/big"
+	"sync"
+)
+
+// The functions below compute irrational results with big.Float. prec is
+// the number of correct bits required after the binary point; each function
+// adds the working precision its own arithmetic loses.
+
+// newFloat returns a zero with the given precision
+func newFloat(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec)
+}
+
+// negligible reports whether |x| < 2^-prec
+func negligible(x *big.Float, prec uint) bool {
+	return x.Sign() == 0 || x.MantExp(nil) < -int(prec)
+}
+
+// exponent returns e such that 2^(e-1) <= |x| < 2^e, or 0 for zero
+func exponent(x *big.Float) int {
+	return x.MantExp(nil)
+}
+
+// pi caches the most precise π computed so far
+var pi struct {
+	sync.Mutex
+	value *big.Float
+}
+
+// bigPi returns π, rounded from the cached value when it is precise enough
+func bigPi(prec uint) *big.Float {
+	wp := prec + 16
+	pi.Lock()
+	defer pi.Unlock()
+	if pi.value == nil || pi.value.Prec() < wp {
+		pi.value = machinPi(wp)
+	}
+	return newFloat(wp).Set(pi.value)
+}
+
+// machinPi computes π with Machin's formula π = 16·atan(1/5) − 4·atan(1/239)
+func machinPi(wp uint) *big.Float {
+	a := atanSeries(newFloat(wp).Quo(newFloat(wp).SetInt64(1), newFloat(wp).SetInt64(5)), wp)
+	b := atanSeries(newFloat(wp).Quo(newFloat(wp).SetInt64(1), newFloat(wp).SetInt64(239)), wp)
+	a.Mul(a, newFloat(wp).SetInt64(16))
+	b.Mul(b, newFloat(wp).SetInt64(4))
+	return a.Sub(a, b)
+}
+
+// atanSeries sums atan(x) = x − x³/3 + x⁵/5 − … for small |x|
+func atanSeries(x *big.Float, prec uint) *big.Float {
+	sum := newFloat(prec).Set(x)
+	power := newFloat(prec).Set(x)
+	x2 := newFloat(prec).Mul(x, x)
+	term := newFloat(prec)
+	for k := int64(3); ; k += 2 {
+		power.Mul(power, x2).Neg(power)
+		term.Quo(power, newFloat(prec).SetInt64(k))
+		if negligible(term, prec) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// atanhSeries sums atanh(x) = x + x³/3 + x⁵/5 + … for small |x|
+func atanhSeries(x *big.Float, prec uint) *big.Float {
+	sum := newFloat(prec).Set(x)
+	power := newFloat(prec).Set(x)
+	x2 := newFloat(prec).Mul(x, x)
+	term := newFloat(prec)
+	for k := int64(3); ; k += 2 {
+		power.Mul(power, x2)
+		term.Quo(power, newFloat(prec).SetInt64(k))
+		if negligible(term, prec) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// bigAtan computes atan(x). |x| > 1 uses atan(x) = ±π/2 − atan(1/x), then
+// atan(x) = 2·atan(x / (1 + √(1 + x²))) shrinks x until the series
+// converges quickly.
+func bigAtan(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return newFloat(prec)
+	}
+	wp := prec + 32
+	one := newFloat(wp).SetInt64(1)
+	y := newFloat(wp).Set(x)
+
+	inverted := newFloat(wp).Abs(y).Cmp(one) > 0
+	if inverted {
+		y.Quo(one, y)
+	}
+
+	halvings := 0
+	for exponent(y) > -3 {
+		root := newFloat(wp).Mul(y, y)
+		root.Add(root, one).Sqrt(root)
+		y.Quo(y, root.Add(root, one))
+		halvings++
+	}
+
+	result := atanSeries(y, wp)
+	result.SetMantExp(result, halvings)
+	if inverted {
+		halfPi := bigPi(wp)
+		halfPi.SetMantExp(halfPi, -1)
+		if x.Sign() < 0 {
+			halfPi.Neg(halfPi)
+		}
+		result.Sub(halfPi, result)
+	}
+	return result
+}
+
+// bigExp computes e^x to prec bits relative to the result, from the series
+// of e^(x/2^k) squared k times
+func bigExp(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return newFloat(prec).SetInt64(1)
+	}
+	k := 0
+	if e := exponent(x); e > -8 {
+		k = e + 8
+	}
+	wp := prec + uint(k) + 16
+
+	y := newFloat(wp).SetMantExp(x, -k)
+	sum := newFloat(wp).SetInt64(1)
+	term := newFloat(wp).SetInt64(1)
+	for n := int64(1); ; n++ {
+		term.Mul(term, y)
+		term.Quo(term, newFloat(wp).SetInt64(n))
+		if negligible(term, wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	for i := 0; i < k; i++ {
+		sum.Mul(sum, sum)
+	}
+	return sum
+}
+
+// bigLn computes ln(x) for x > 0 as ln(m) + e·ln(2) with x = m·2^e and
+// ln(m) = 2·atanh((m − 1) / (m + 1))
+func bigLn(x *big.Float, prec uint) *big.Float {
+	m := new(big.Float)
+	e := x.MantExp(m)
+	wp := prec + 16 + uint(big.NewInt(int64(e)).BitLen())
+
+	m.SetPrec(wp)
+	one := newFloat(wp).SetInt64(1)
+	z := newFloat(wp).Sub(m, one)
+	z.Quo(z, newFloat(wp).Add(m, one))
+	result := atanhSeries(z, wp)
+	result.SetMantExp(result, 1)
+
+	if e != 0 {
+		// ln(2) = 2·atanh(1/3)
+		ln2 := atanhSeries(newFloat(wp).Quo(one, newFloat(wp).SetInt64(3)), wp)
+		ln2.SetMantExp(ln2, 1)
+		result.Add(result, ln2.Mul(ln2, newFloat(wp).SetInt64(int64(e))))
+	}
+	return result
+}
+
+// reduceAngle returns x − 2πk for the integer k truncating x / 2π, so the
+// result lies in (−2π, 2π). It needs exponent(x) extra bits of π, which is
+// why the decimal trig functions reject |x| >= 2^maxTrigExponent.
+func reduceAngle(x *big.Float, prec uint) *big.Float {
+	wp := prec + 16
+	if e := exponent(x); e > 0 {
+		wp += uint(e)
+	}
+	twoPi := bigPi(wp)
+	twoPi.SetMantExp(twoPi, 1)
+
+	k, _ := newFloat(wp).Quo(x, twoPi).Int(nil)
+	if k.Sign() == 0 {
+		return newFloat(wp).Set(x)
+	}
+	twoPi.Mul(twoPi, newFloat(wp).SetInt(k))
+	return twoPi.Sub(newFloat(wp).Set(x), twoPi)
+}
+
+// bigSin computes sin(x) from its Taylor series
+func bigSin(x *big.Float, prec uint) *big.Float {
+	wp := prec + 16
+	y := reduceAngle(x, wp).SetPrec(wp)
+	y2 := newFloat(wp).Mul(y, y)
+	sum := newFloat(wp).Set(y)
+	term := newFloat(wp).Set(y)
+	for n := int64(1); ; n++ {
+		term.Mul(term, y2).Neg(term)
+		term.Quo(term, newFloat(wp).SetInt64(2*n*(2*n+1)))
+		if negligible(term, wp) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// bigCos computes cos(x) from its Taylor series
+func bigCos(x *big.Float, prec uint) *big.Float {
+	wp := prec + 16
+	y := reduceAngle(x, wp).SetPrec(wp)
+	y2 := newFloat(wp).Mul(y, y)
+	sum := newFloat(wp).SetInt64(1)
+	term := newFloat(wp).SetInt64(1)
+	for n := int64(1); ; n++ {
+		term.Mul(term, y2).Neg(term)
+		term.Quo(term, newFloat(wp).SetInt64((2*n-1)*(2*n)))
+		if negligible(term, wp) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// bigTan computes sin(x) / cos(x), adding precision while cos(x) is small
+// because the quotient magnifies its error
+func bigTan(x *big.Float, prec uint) *big.Float {
+	wp := prec + 16
+	for {
+		cos := bigCos(x, wp)
+		if e := exponent(cos); e < 0 && wp < prec+16+uint(-4*e) {
+			wp = prec + 16 + uint(-4*e)
+			continue
+		}
+		return newFloat(wp).Quo(bigSin(x, wp), cos)
+	}
+}
+
+// intRoot returns the integer k-th root of n >= 0, rounded down
+func intRoot(n *big.Int, k uint) *big.Int {
+	if n.Sign() == 0 {
+		return new(big.Int)
+	}
+	if k == 2 {
+		return new(big.Int).Sqrt(n)
+	}
+
+	// Newton's method descends monotonically from any start above the root
+	x := new(big.Int).Lsh(big.NewInt(1), uint(n.BitLen())/k+1)
+	km1 := big.NewInt(int64(k - 1))
+	bk := big.NewInt(int64(k))
+	for {
+		t := new(big.Int).Exp(x, km1, nil)
+		t.Quo(n, t)
+		y := new(big.Int).Mul(km1, x)
+		y.Add(y, t).Quo(y, bk)
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+		x = y
+	}
+}
+
+// exactRoot returns the k-th root of x >= 0 when it is rational
+func exactRoot(x *big.Rat, k uint) (*big.Rat, bool) {
+	num, den := intRoot(x.Num(), k), intRoot(x.Denom(), k)
+	kk := big.NewInt(int64(k))
+	if new(big.Int).Exp(num, kk, nil).Cmp(x.Num()) != 0 || new(big.Int).Exp(den, kk, nil).Cmp(x.Denom()) != 0 {
+		return nil, false
+	}
+	return new(big.Rat).SetFrac(num, den), true
+}
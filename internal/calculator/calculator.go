@@ -1,7 +1,83 @@
 // Package calculator provides simple mathematical operations.
 package calculator
 
-import "errors"
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Kinds of MathError
+var (
+	ErrDivisionByZero = errors.New("cannot divide by zero")
+	ErrDomain         = errors.New("argument outside the domain of the operation")
+	ErrOverflow       = errors.New("result overflows")
+)
+
+// MathError reports an operation that has no finite result for its
+// arguments. Err is ErrDivisionByZero, ErrDomain or ErrOverflow, or
+// ErrDimension or ErrSingular for matrix operations.
+type MathError struct {
+	Op  string
+	Msg string
+	Err error
+}
+
+// Error implements the error interface
+func (e *MathError) Error() string {
+	return e.Msg
+}
+
+// Unwrap returns the kind of error
+func (e *MathError) Unwrap() error {
+	return e.Err
+}
+
+// domainError reports arguments outside the domain of op
+func domainError(op, msg string) *MathError {
+	return &MathError{Op: op, Msg: msg, Err: ErrDomain}
+}
+
+// overflowError reports a result of op too large to represent
+func overflowError(op string) *MathError {
+	return &MathError{Op: op, Msg: "result of " + op + " overflows", Err: ErrOverflow}
+}
+
+// factorialOverflow reports a factorial of n, formatted as the caller wrote
+// it, too large to represent
+func factorialOverflow(n string) *MathError {
+	return &MathError{Op: "factorial", Msg: "factorial of " + n + " overflows", Err: ErrOverflow}
+}
+
+// Domain errors shared by the float64 and decimal evaluations
+var (
+	errSqrtDomain      = domainError("sqrt", "sqrt of a negative number")
+	errNthRootDegree   = domainError("nthroot", "nthroot degree must be a whole number of at least 1")
+	errNthRootDomain   = domainError("nthroot", "even root of a negative number")
+	errLnDomain        = domainError("ln", "ln of a number that is not positive")
+	errLogDomain       = domainError("log", "log of a number that is not positive")
+	errAsinDomain      = domainError("asin", "asin of a number outside [-1, 1]")
+	errAcosDomain      = domainError("acos", "acos of a number outside [-1, 1]")
+	errFactorialDomain = domainError("factorial", "factorial of a number that is not a whole number of at least 0")
+)
+
+// Division errors of the expression operators
+var (
+	errDivisionByZero = &MathError{Op: "/", Msg: "division by zero", Err: ErrDivisionByZero}
+	errModuloByZero   = &MathError{Op: "%", Msg: "modulo by zero", Err: ErrDivisionByZero}
+)
+
+// checkFinite reports NaN results of op as domain errors and infinite ones
+// as overflows
+func checkFinite(op string, result float64) (float64, error) {
+	switch {
+	case math.IsNaN(result):
+		return 0, domainError(op, op+" is undefined for these operands")
+	case math.IsInf(result, 0):
+		return 0, overflowError(op)
+	}
+	return result, nil
+}
 
 // Calculator provides basic math operations
 type Calculator struct{}
@@ -11,6 +87,9 @@ func New() *Calculator {
 	return &Calculator{}
 }
 
+// std evaluates the functions of expressions
+var std = New()
+
 // Add returns the sum of a and b
 func (c *Calculator) Add(a, b float64) float64 {
 	return a + b
@@ -29,7 +108,134 @@ func (c *Calculator) Multiply(a, b float64) float64 {
 // Divide returns the quotient of a and b
 func (c *Calculator) Divide(a, b float64) (float64, error) {
 	if b == 0 {
-		return 0, errors.New("cannot divide by zero")
+		return 0, ErrDivisionByZero
 	}
 	return a / b, nil
 }
+
+// Pow returns a raised to the power b
+func (c *Calculator) Pow(a, b float64) (float64, error) {
+	if a == 0 && b < 0 {
+		return 0, &MathError{Op: "pow", Msg: "pow of zero to a negative power", Err: ErrDivisionByZero}
+	}
+	return checkFinite("pow", math.Pow(a, b))
+}
+
+// Sqrt returns the square root of x
+func (c *Calculator) Sqrt(x float64) (float64, error) {
+	if x < 0 {
+		return 0, errSqrtDomain
+	}
+	return math.Sqrt(x), nil
+}
+
+// NthRoot returns the real n-th root of x. Negative x only have odd roots.
+func (c *Calculator) NthRoot(x float64, n int) (float64, error) {
+	switch {
+	case n < 1:
+		return 0, errNthRootDegree
+	case x < 0 && n%2 == 0:
+		return 0, errNthRootDomain
+	case n == 1:
+		return x, nil
+	case n == 2:
+		return math.Sqrt(x), nil
+	case n == 3:
+		return math.Cbrt(x), nil
+	}
+
+	root := math.Pow(math.Abs(x), 1/float64(n))
+	// Prefer the exact root of perfect powers, which Pow misses by an ulp
+	if whole := math.Round(root); math.Pow(whole, float64(n)) == math.Abs(x) {
+		root = whole
+	}
+	return math.Copysign(root, x), nil
+}
+
+// Mod returns the remainder of a / b, with the sign of a
+func (c *Calculator) Mod(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, &MathError{Op: "mod", Msg: "modulo by zero", Err: ErrDivisionByZero}
+	}
+	return math.Mod(a, b), nil
+}
+
+// Abs returns the absolute value of x
+func (c *Calculator) Abs(x float64) float64 {
+	return math.Abs(x)
+}
+
+// Log returns the base 10 logarithm of x
+func (c *Calculator) Log(x float64) (float64, error) {
+	if x <= 0 {
+		return 0, errLogDomain
+	}
+	return math.Log10(x), nil
+}
+
+// Ln returns the natural logarithm of x
+func (c *Calculator) Ln(x float64) (float64, error) {
+	if x <= 0 {
+		return 0, errLnDomain
+	}
+	return math.Log(x), nil
+}
+
+// Exp returns e raised to the power x
+func (c *Calculator) Exp(x float64) (float64, error) {
+	return checkFinite("exp", math.Exp(x))
+}
+
+// maxFactorial is the largest n whose factorial is a finite float64
+const maxFactorial = 170
+
+// Factorial returns n!
+func (c *Calculator) Factorial(n int) (float64, error) {
+	switch {
+	case n < 0:
+		return 0, errFactorialDomain
+	case n > maxFactorial:
+		return 0, factorialOverflow(strconv.Itoa(n))
+	}
+	result := 1.0
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+	return result, nil
+}
+
+// Sin returns the sine of x radians
+func (c *Calculator) Sin(x float64) float64 {
+	return math.Sin(x)
+}
+
+// Cos returns the cosine of x radians
+func (c *Calculator) Cos(x float64) float64 {
+	return math.Cos(x)
+}
+
+// Tan returns the tangent of x radians
+func (c *Calculator) Tan(x float64) float64 {
+	return math.Tan(x)
+}
+
+// Asin returns the arcsine of x in radians
+func (c *Calculator) Asin(x float64) (float64, error) {
+	if x < -1 || x > 1 {
+		return 0, errAsinDomain
+	}
+	return math.Asin(x), nil
+}
+
+// Acos returns the arccosine of x in radians
+func (c *Calculator) Acos(x float64) (float64, error) {
+	if x < -1 || x > 1 {
+		return 0, errAcosDomain
+	}
+	return math.Acos(x), nil
+}
+
+// Atan returns the arctangent of x in radians
+func (c *Calculator) Atan(x float64) float64 {
+	return math.Atan(x)
+}
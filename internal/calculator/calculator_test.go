@@ -1,6 +1,8 @@
 package calculator
 
 import (
+	"errors"
+	"math"
 	"testing"
 )
 
@@ -50,3 +52,77 @@ func TestCalculator_Divide(t *testing.T) {
 		t.Error("Divide(10, 0) should return error")
 	}
 }
+
+func TestCalculator_Functions(t *testing.T) {
+	calc := New()
+	tests := []struct {
+		name string
+		got  func() (float64, error)
+		want float64
+	}{
+		{"Pow(2, 10)", func() (float64, error) { return calc.Pow(2, 10) }, 1024},
+		{"Pow(-8, 2)", func() (float64, error) { return calc.Pow(-8, 2) }, 64},
+		{"Sqrt(16)", func() (float64, error) { return calc.Sqrt(16) }, 4},
+		{"NthRoot(27, 3)", func() (float64, error) { return calc.NthRoot(27, 3) }, 3},
+		{"NthRoot(-32, 5)", func() (float64, error) { return calc.NthRoot(-32, 5) }, -2},
+		{"NthRoot(1e10, 10)", func() (float64, error) { return calc.NthRoot(1e10, 10) }, 10},
+		{"Mod(-7, 3)", func() (float64, error) { return calc.Mod(-7, 3) }, -1},
+		{"Log(1000)", func() (float64, error) { return calc.Log(1000) }, 3},
+		{"Ln(1)", func() (float64, error) { return calc.Ln(1) }, 0},
+		{"Exp(0)", func() (float64, error) { return calc.Exp(0) }, 1},
+		{"Factorial(0)", func() (float64, error) { return calc.Factorial(0) }, 1},
+		{"Factorial(10)", func() (float64, error) { return calc.Factorial(10) }, 3628800},
+		{"Asin(1)", func() (float64, error) { return calc.Asin(1) }, math.Pi / 2},
+		{"Acos(1)", func() (float64, error) { return calc.Acos(1) }, 0},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.got()
+		if err != nil {
+			t.Errorf("%s returned error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := calc.Abs(-2.5); got != 2.5 {
+		t.Errorf("Abs(-2.5) = %v; want 2.5", got)
+	}
+	if got := calc.Sin(0) + calc.Cos(0) + calc.Tan(0) + calc.Atan(0); got != 1 {
+		t.Errorf("Sin(0) + Cos(0) + Tan(0) + Atan(0) = %v; want 1", got)
+	}
+}
+
+func TestCalculator_FunctionErrors(t *testing.T) {
+	calc := New()
+	tests := []struct {
+		name string
+		err  func() error
+		kind error
+	}{
+		{"Sqrt(-1)", func() error { _, err := calc.Sqrt(-1); return err }, ErrDomain},
+		{"NthRoot(-16, 4)", func() error { _, err := calc.NthRoot(-16, 4); return err }, ErrDomain},
+		{"NthRoot(8, 0)", func() error { _, err := calc.NthRoot(8, 0); return err }, ErrDomain},
+		{"Log(0)", func() error { _, err := calc.Log(0); return err }, ErrDomain},
+		{"Ln(-1)", func() error { _, err := calc.Ln(-1); return err }, ErrDomain},
+		{"Asin(2)", func() error { _, err := calc.Asin(2); return err }, ErrDomain},
+		{"Acos(-2)", func() error { _, err := calc.Acos(-2); return err }, ErrDomain},
+		{"Pow(-8, 0.5)", func() error { _, err := calc.Pow(-8, 0.5); return err }, ErrDomain},
+		{"Factorial(-1)", func() error { _, err := calc.Factorial(-1); return err }, ErrDomain},
+		{"Factorial(171)", func() error { _, err := calc.Factorial(171); return err }, ErrOverflow},
+		{"Exp(1000)", func() error { _, err := calc.Exp(1000); return err }, ErrOverflow},
+		{"Pow(10, 400)", func() error { _, err := calc.Pow(10, 400); return err }, ErrOverflow},
+		{"Pow(0, -1)", func() error { _, err := calc.Pow(0, -1); return err }, ErrDivisionByZero},
+		{"Mod(1, 0)", func() error { _, err := calc.Mod(1, 0); return err }, ErrDivisionByZero},
+	}
+
+	for _, tt := range tests {
+		err := tt.err()
+		var mathErr *MathError
+		if !errors.As(err, &mathErr) || !errors.Is(err, tt.kind) {
+			t.Errorf("%s error = %v; want a *MathError wrapping %v", tt.name, err, tt.kind)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package calculator
+
+import (
+	"fmt"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// Formats of complex results
+const (
+	// ComplexObject writes {"re": 3, "im": 4}
+	ComplexObject = "object"
+	// ComplexString writes "3+4i"
+	ComplexString = "string"
+)
+
+// Complex is a complex number in rectangular form
+type Complex struct {
+	Re float64 `json:"re"`
+	Im float64 `json:"im"`
+}
+
+// NewComplex splits z into its real and imaginary parts
+func NewComplex(z complex128) Complex {
+	z = positiveZero(z)
+	return Complex{Re: real(z), Im: imag(z)}
+}
+
+// positiveZero replaces negative zero parts of z, such as the imaginary
+// part of the conjugate of 3, by zero
+func positiveZero(z complex128) complex128 {
+	return complex(real(z)+0, imag(z)+0)
+}
+
+// ParseComplex reads a complex number written as a+bi, such as 3+4i, -2.5i
+// or 7. Parentheses around it are accepted.
+func ParseComplex(s string) (complex128, error) {
+	z, err := strconv.ParseComplex(strings.TrimSpace(s), 128)
+	if err != nil {
+		return 0, fmt.Errorf("invalid complex number %q, write it as a+bi", s)
+	}
+	if cmplx.IsNaN(z) || cmplx.IsInf(z) {
+		return 0, fmt.Errorf("complex number %q must be finite", s)
+	}
+	return z, nil
+}
+
+// FormatComplex writes z as a+bi, the form read by ParseComplex
+func FormatComplex(z complex128) string {
+	s := strconv.FormatComplex(positiveZero(z), 'g', -1, 128)
+	return s[1 : len(s)-1]
+}
+
+// checkComplex reports a result of op with an infinite or NaN part
+func checkComplex(op string, z complex128) (complex128, error) {
+	if _, err := checkFinite(op, real(z)); err != nil {
+		return 0, err
+	}
+	if _, err := checkFinite(op, imag(z)); err != nil {
+		return 0, err
+	}
+	return z, nil
+}
+
+// ComplexAdd returns the sum of a and b
+func (c *Calculator) ComplexAdd(a, b complex128) (complex128, error) {
+	return checkComplex("add", a+b)
+}
+
+// ComplexSubtract returns the difference between a and b
+func (c *Calculator) ComplexSubtract(a, b complex128) (complex128, error) {
+	return checkComplex("subtract", a-b)
+}
+
+// ComplexMultiply returns the product of a and b
+func (c *Calculator) ComplexMultiply(a, b complex128) (complex128, error) {
+	return checkComplex("multiply", a*b)
+}
+
+// ComplexDivide returns the quotient of a and b
+func (c *Calculator) ComplexDivide(a, b complex128) (complex128, error) {
+	if b == 0 {
+		return 0, &MathError{Op: "divide", Msg: "division by zero", Err: ErrDivisionByZero}
+	}
+	return checkComplex("divide", a/b)
+}
+
+// Modulus returns the absolute value |z|
+func (c *Calculator) Modulus(z complex128) (float64, error) {
+	return checkFinite("modulus", cmplx.Abs(z))
+}
+
+// Argument returns the angle of z in radians, in [-pi, pi]. The argument
+// of 0 is 0.
+func (c *Calculator) Argument(z complex128) float64 {
+	return cmplx.Phase(z)
+}
+
+// Conjugate returns the complex conjugate of z
+func (c *Calculator) Conjugate(z complex128) complex128 {
+	return cmplx.Conj(z)
+}
+
+// Polar returns the modulus and the argument of z
+func (c *Calculator) Polar(z complex128) (r, theta float64, err error) {
+	if r, err = c.Modulus(z); err != nil {
+		return 0, 0, err
+	}
+	return r, c.Argument(z), nil
+}
+
+// Rect returns the complex number of modulus r and argument theta
+func (c *Calculator) Rect(r, theta float64) (complex128, error) {
+	if r < 0 {
+		return 0, domainError("rect", "rect of a negative modulus")
+	}
+	return checkComplex("rect", cmplx.Rect(r, theta))
+}
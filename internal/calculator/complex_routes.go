@@ -0,0 +1,215 @@
+package calculator
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// ComplexResponse represents a complex result, written as
+// ComplexStringResponse with ?format=string
+type ComplexResponse struct {
+	Result Complex `json:"result"`
+}
+
+// ComplexStringResponse represents a complex result written as a+bi
+type ComplexStringResponse struct {
+	Result string `json:"result"`
+}
+
+// PolarResponse represents a complex number in polar form: its modulus r
+// and argument theta in radians
+type PolarResponse struct {
+	R     float64 `json:"r"`
+	Theta float64 `json:"theta"`
+}
+
+// complexParams are the query parameters of routes with complex results
+var complexParams = map[string]string{"format": "string"}
+
+// complexResultDoc describes the result of routes answering a complex number
+const complexResultDoc = "The result is {re, im}, or a+bi with format=string."
+
+// complexRoute declares a GET route taking complex path parameters and its
+// documentation
+func complexRoute(path, name string, handler http.HandlerFunc, summary string, response interface{}, params ...string) common.Route {
+	types := make(map[string]string, len(params))
+	for _, param := range params {
+		types[param] = "string"
+	}
+	doc := common.RouteDoc{
+		Summary:     summary,
+		Description: "Complex numbers are written as a+bi, such as 3+4i, -2i or 7.",
+		Tags:        []string{"calculator"},
+		Params:      types,
+		Response:    response,
+		Errors:      []int{http.StatusBadRequest},
+	}
+	if _, ok := response.(ComplexResponse); ok {
+		doc.Description += " " + complexResultDoc
+		doc.Query = complexParams
+	}
+	return common.NamedRoute(path, "GET", name, handler).WithDoc(doc)
+}
+
+// handleComplexAdd handles complex addition
+func (h *Handler) handleComplexAdd(w http.ResponseWriter, r *http.Request) {
+	h.complexOperation(w, r, h.calc.ComplexAdd)
+}
+
+// handleComplexSubtract handles complex subtraction
+func (h *Handler) handleComplexSubtract(w http.ResponseWriter, r *http.Request) {
+	h.complexOperation(w, r, h.calc.ComplexSubtract)
+}
+
+// handleComplexMultiply handles complex multiplication
+func (h *Handler) handleComplexMultiply(w http.ResponseWriter, r *http.Request) {
+	h.complexOperation(w, r, h.calc.ComplexMultiply)
+}
+
+// handleComplexDivide handles complex division
+func (h *Handler) handleComplexDivide(w http.ResponseWriter, r *http.Request) {
+	h.complexOperation(w, r, h.calc.ComplexDivide)
+}
+
+// handleComplexModulus handles the modulus
+func (h *Handler) handleComplexModulus(w http.ResponseWriter, r *http.Request) {
+	args, err := h.getComplex(r, "z")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	result, err := h.calc.Modulus(args[0])
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.WriteSuccess(w, Response{Result: result})
+}
+
+// handleComplexArgument handles the argument
+func (h *Handler) handleComplexArgument(w http.ResponseWriter, r *http.Request) {
+	args, err := h.getComplex(r, "z")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.WriteSuccess(w, Response{Result: h.calc.Argument(args[0])})
+}
+
+// handleComplexConjugate handles the conjugate
+func (h *Handler) handleComplexConjugate(w http.ResponseWriter, r *http.Request) {
+	format, err := complexFormatQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	args, err := h.getComplex(r, "z")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.writeComplex(w, format, h.calc.Conjugate(args[0]))
+}
+
+// handleComplexPolar handles conversion to polar form
+func (h *Handler) handleComplexPolar(w http.ResponseWriter, r *http.Request) {
+	args, err := h.getComplex(r, "z")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	radius, theta, err := h.calc.Polar(args[0])
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.WriteSuccess(w, PolarResponse{R: radius, Theta: theta})
+}
+
+// handleComplexRect handles conversion from polar form
+func (h *Handler) handleComplexRect(w http.ResponseWriter, r *http.Request) {
+	format, err := complexFormatQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	values := h.GetURLParams(r)
+	radius, err := finiteParam(values, "r")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	theta, err := finiteParam(values, "theta")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	result, err := h.calc.Rect(radius, theta)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.writeComplex(w, format, result)
+}
+
+// complexOperation answers a route applying op to the complex path
+// parameters a and b
+func (h *Handler) complexOperation(w http.ResponseWriter, r *http.Request, op func(a, b complex128) (complex128, error)) {
+	format, err := complexFormatQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	args, err := h.getComplex(r, "a", "b")
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	result, err := op(args[0], args[1])
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.writeComplex(w, format, result)
+}
+
+// complexFormatQuery reads the format query parameter of complex results
+func complexFormatQuery(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", ComplexObject:
+		return ComplexObject, nil
+	case ComplexString:
+		return ComplexString, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, use object or string", format)
+	}
+}
+
+// writeComplex writes a complex result in format
+func (h *Handler) writeComplex(w http.ResponseWriter, format string, z complex128) {
+	if format == ComplexString {
+		h.WriteSuccess(w, ComplexStringResponse{Result: FormatComplex(z)})
+		return
+	}
+	h.WriteSuccess(w, ComplexResponse{Result: NewComplex(z)})
+}
+
+// getComplex extracts complex numbers from URL parameters
+func (h *Handler) getComplex(r *http.Request, params ...string) ([]complex128, error) {
+	values := h.GetURLParams(r)
+	args := make([]complex128, len(params))
+	for i, param := range params {
+		value, ok := values.String(param)
+		if !ok {
+			return nil, common.ErrParameterNotFound
+		}
+		z, err := ParseComplex(value)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = z
+	}
+	return args, nil
+}
@@ -0,0 +1,115 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestParseComplex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  complex128
+	}{
+		{"3+4i", 3 + 4i},
+		{"-2.5i", -2.5i},
+		{"7", 7},
+		{"(1-1i)", 1 - 1i},
+		{" 1e3+2e-1i ", 1000 + 0.2i},
+	}
+	for _, tt := range tests {
+		if got, err := ParseComplex(tt.input); err != nil || got != tt.want {
+			t.Errorf("ParseComplex(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "3+4j", "i4", "NaN", "Inf+1i", "3+4i+1"} {
+		if _, err := ParseComplex(input); err == nil {
+			t.Errorf("ParseComplex(%q) succeeded; want an error", input)
+		}
+	}
+}
+
+func TestFormatComplex(t *testing.T) {
+	tests := []struct {
+		z    complex128
+		want string
+	}{
+		{3 + 4i, "3+4i"},
+		{-0.5 - 2i, "-0.5-2i"},
+		{cmplx.Conj(3), "3+0i"},
+		{1e21i, "0+1e+21i"},
+	}
+	for _, tt := range tests {
+		if got := FormatComplex(tt.z); got != tt.want {
+			t.Errorf("FormatComplex(%v) = %q; want %q", tt.z, got, tt.want)
+		}
+		if z, err := ParseComplex(FormatComplex(tt.z)); err != nil || z != tt.z {
+			t.Errorf("ParseComplex(FormatComplex(%v)) = %v, %v", tt.z, z, err)
+		}
+	}
+	if got := NewComplex(cmplx.Conj(3)); math.Signbit(got.Im) {
+		t.Errorf("NewComplex(conj(3)).Im = %v; want 0", got.Im)
+	}
+}
+
+func TestCalculator_ComplexArithmetic(t *testing.T) {
+	calc := New()
+	a, b := 3+4i, 1-2i
+
+	tests := []struct {
+		name string
+		op   func(a, b complex128) (complex128, error)
+		want complex128
+	}{
+		{"ComplexAdd", calc.ComplexAdd, 4 + 2i},
+		{"ComplexSubtract", calc.ComplexSubtract, 2 + 6i},
+		{"ComplexMultiply", calc.ComplexMultiply, 11 - 2i},
+		{"ComplexDivide", calc.ComplexDivide, -1 + 2i},
+	}
+	for _, tt := range tests {
+		if got, err := tt.op(a, b); err != nil || got != tt.want {
+			t.Errorf("%s(%v, %v) = %v, %v; want %v", tt.name, a, b, got, err, tt.want)
+		}
+	}
+
+	if _, err := calc.ComplexDivide(a, 0); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("ComplexDivide by 0 error = %v; want ErrDivisionByZero", err)
+	}
+	if _, err := calc.ComplexMultiply(1e200+1e200i, 1e200); !errors.Is(err, ErrOverflow) {
+		t.Errorf("ComplexMultiply overflowing error = %v; want ErrOverflow", err)
+	}
+}
+
+func TestCalculator_ComplexForms(t *testing.T) {
+	calc := New()
+
+	if got, err := calc.Modulus(3 + 4i); err != nil || got != 5 {
+		t.Errorf("Modulus(3+4i) = %v, %v; want 5", got, err)
+	}
+	if got := calc.Argument(-1); got != math.Pi {
+		t.Errorf("Argument(-1) = %v; want pi", got)
+	}
+	if got := calc.Argument(0); got != 0 {
+		t.Errorf("Argument(0) = %v; want 0", got)
+	}
+	if got := calc.Conjugate(3 + 4i); got != 3-4i {
+		t.Errorf("Conjugate(3+4i) = %v; want 3-4i", got)
+	}
+
+	r, theta, err := calc.Polar(1 + 1i)
+	if err != nil || math.Abs(r-math.Sqrt2) > 1e-15 || math.Abs(theta-math.Pi/4) > 1e-15 {
+		t.Errorf("Polar(1+1i) = %v, %v, %v; want sqrt(2), pi/4", r, theta, err)
+	}
+	z, err := calc.Rect(r, theta)
+	if err != nil || cmplx.Abs(z-(1+1i)) > 1e-15 {
+		t.Errorf("Rect(sqrt(2), pi/4) = %v, %v; want 1+1i", z, err)
+	}
+	if _, err := calc.Rect(-1, 0); !errors.Is(err, ErrDomain) {
+		t.Errorf("Rect of a negative modulus error = %v; want ErrDomain", err)
+	}
+	if _, err := calc.Modulus(1.5e308 + 1.5e308i); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Modulus(1.5e308+1.5e308i) error = %v; want ErrOverflow", err)
+	}
+}
@@ -0,0 +1,233 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal precision limits
+const (
+	DefaultScale = 28
+	MaxScale     = 1000
+)
+
+// maxDecimalExponent bounds the exponent of decimal literals, so parsing
+// cannot allocate unbounded powers of ten
+const maxDecimalExponent = 9000
+
+// maxRatBits bounds the numerator and denominator of every decimal value
+const maxRatBits = 1 << 15
+
+// guardBits are the extra bits irrational results are computed with
+const guardBits = 64
+
+// ErrInvalidDecimal is returned for strings that are not decimal numbers
+var ErrInvalidDecimal = errors.New("invalid decimal number")
+
+// RoundingMode selects how decimal results are rounded to their scale
+type RoundingMode int
+
+// Rounding modes
+const (
+	RoundHalfEven RoundingMode = iota // To nearest, ties to the even digit
+	RoundHalfUp                       // To nearest, ties away from zero
+	RoundHalfDown                     // To nearest, ties toward zero
+	RoundUp                           // Away from zero
+	RoundDown                         // Toward zero
+	RoundCeiling                      // Toward positive infinity
+	RoundFloor                        // Toward negative infinity
+)
+
+var roundingModeNames = []string{"half_even", "half_up", "half_down", "up", "down", "ceiling", "floor"}
+
+// String returns the name of the rounding mode
+func (m RoundingMode) String() string {
+	if m < 0 || int(m) >= len(roundingModeNames) {
+		return "RoundingMode(" + strconv.Itoa(int(m)) + ")"
+	}
+	return roundingModeNames[m]
+}
+
+// ParseRoundingMode parses a rounding mode name such as half_even
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for i, n := range roundingModeNames {
+		if n == name {
+			return RoundingMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q, use one of %s", name, strings.Join(roundingModeNames, ", "))
+}
+
+// Precision selects exact decimal arithmetic: results are rounded to Scale
+// digits after the decimal point with the Rounding mode
+type Precision struct {
+	Scale    int
+	Rounding RoundingMode
+}
+
+// DefaultPrecision rounds to DefaultScale digits, ties to even
+var DefaultPrecision = Precision{Scale: DefaultScale, Rounding: RoundHalfEven}
+
+// Validate checks the scale and rounding mode
+func (p Precision) Validate() error {
+	if p.Scale < 0 || p.Scale > MaxScale {
+		return fmt.Errorf("scale must be between 0 and %d", MaxScale)
+	}
+	if p.Rounding < 0 || int(p.Rounding) >= len(roundingModeNames) {
+		return fmt.Errorf("unknown rounding mode %d", int(p.Rounding))
+	}
+	return nil
+}
+
+// Round rounds x to the scale of p
+func (p Precision) Round(x *big.Rat) *big.Rat {
+	return new(big.Rat).SetFrac(p.scaled(x), pow10(p.Scale))
+}
+
+// Format rounds x to the scale of p and formats it as a decimal string
+// without trailing zeros, such as "0.3" or "-12"
+func (p Precision) Format(x *big.Rat) string {
+	q := p.scaled(x)
+	digits := new(big.Int).Abs(q).String()
+	if len(digits) <= p.Scale {
+		digits = strings.Repeat("0", p.Scale-len(digits)+1) + digits
+	}
+
+	point := len(digits) - p.Scale
+	s := digits[:point]
+	if frac := strings.TrimRight(digits[point:], "0"); frac != "" {
+		s += "." + frac
+	}
+	if q.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// scaled returns x·10^Scale rounded to an integer with the rounding mode
+func (p Precision) scaled(x *big.Rat) *big.Int {
+	num := new(big.Int).Mul(x.Num(), pow10(p.Scale))
+	q, rem := new(big.Int).QuoRem(num, x.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+
+	// half compares the discarded fraction with one half
+	twice := new(big.Int).Abs(rem)
+	half := twice.Lsh(twice, 1).Cmp(x.Denom())
+
+	var away bool
+	switch p.Rounding {
+	case RoundHalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	case RoundHalfUp:
+		away = half >= 0
+	case RoundHalfDown:
+		away = half > 0
+	case RoundUp:
+		away = true
+	case RoundDown:
+		away = false
+	case RoundCeiling:
+		away = x.Sign() > 0
+	case RoundFloor:
+		away = x.Sign() < 0
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+	return q
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// ParseDecimal parses a decimal number such as "-12.5" or "1.5e-3" exactly
+func ParseDecimal(s string) (*big.Rat, error) {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && isDigit(s[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return nil, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+		}
+		i = len(s)
+	}
+	if i != len(s) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	x, ok := new(big.Rat).SetString(s)
+	if !ok || !fits(x) {
+		return nil, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+	}
+	return x, nil
+}
+
+// fits reports whether x is within the supported size
+func fits(x *big.Rat) bool {
+	return x.Num().BitLen() <= maxRatBits && x.Denom().BitLen() <= maxRatBits
+}
+
+// precisionBits returns the bits after the binary point irrational results
+// need to be correct to scale decimal digits
+func precisionBits(scale int) uint {
+	return uint(math.Ceil(float64(scale)*math.Log2(10))) + guardBits
+}
+
+// AddDecimal returns the exact sum of a and b
+func (c *Calculator) AddDecimal(a, b *big.Rat) *big.Rat {
+	return new(big.Rat).Add(a, b)
+}
+
+// SubtractDecimal returns the exact difference between a and b
+func (c *Calculator) SubtractDecimal(a, b *big.Rat) *big.Rat {
+	return new(big.Rat).Sub(a, b)
+}
+
+// MultiplyDecimal returns the exact product of a and b
+func (c *Calculator) MultiplyDecimal(a, b *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(a, b)
+}
+
+// DivideDecimal returns the exact quotient of a and b
+func (c *Calculator) DivideDecimal(a, b *big.Rat) (*big.Rat, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return new(big.Rat).Quo(a, b), nil
+}
+
+// EvaluateDecimal evaluates an expression like Evaluate, with exact rational
+// arithmetic. Irrational results such as sqrt(2) or pi are computed beyond
+// the scale of p, and the result is rounded to it.
+func (c *Calculator) EvaluateDecimal(expr string, p Precision) (*big.Rat, error) {
+	node, err := Parse(expr, Limits{})
+	if err != nil {
+		return nil, err
+	}
+	return EvalDecimal(node, nil, p)
+}
@@ -0,0 +1,244 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrecision_Format(t *testing.T) {
+	tests := []struct {
+		value    string
+		scale    int
+		rounding RoundingMode
+		want     string
+	}{
+		{"0.125", 2, RoundHalfEven, "0.12"},
+		{"0.135", 2, RoundHalfEven, "0.14"},
+		{"0.125", 2, RoundHalfUp, "0.13"},
+		{"0.125", 2, RoundHalfDown, "0.12"},
+		{"0.1251", 2, RoundHalfDown, "0.13"},
+		{"0.121", 2, RoundUp, "0.13"},
+		{"0.129", 2, RoundDown, "0.12"},
+		{"-0.125", 2, RoundHalfEven, "-0.12"},
+		{"-0.125", 2, RoundHalfUp, "-0.13"},
+		{"-0.121", 2, RoundUp, "-0.13"},
+		{"-0.121", 2, RoundCeiling, "-0.12"},
+		{"-0.121", 2, RoundFloor, "-0.13"},
+		{"0.121", 2, RoundCeiling, "0.13"},
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"-0.001", 2, RoundHalfEven, "0"},
+		{"1.50", 5, RoundHalfEven, "1.5"},
+		{"1200", 3, RoundHalfEven, "1200"},
+		{"0.00042", 28, RoundHalfEven, "0.00042"},
+	}
+
+	for _, tt := range tests {
+		x, err := ParseDecimal(tt.value)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q) returned error: %v", tt.value, err)
+		}
+		p := Precision{Scale: tt.scale, Rounding: tt.rounding}
+		if got := p.Format(x); got != tt.want {
+			t.Errorf("Format(%s) with scale %d, %s = %s; want %s", tt.value, tt.scale, tt.rounding, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	valid := map[string]string{
+		"0.1":    "1/10",
+		"-12.5":  "-25/2",
+		"+3":     "3/1",
+		".5":     "1/2",
+		"5.":     "5/1",
+		"1.5e3":  "1500/1",
+		"25E-2":  "1/4",
+		"007.70": "77/10",
+	}
+	for s, want := range valid {
+		x, err := ParseDecimal(s)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) returned error: %v", s, err)
+			continue
+		}
+		if x.String() != want {
+			t.Errorf("ParseDecimal(%q) = %s; want %s", s, x, want)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".", "1/3", "0x10", "1e", "1e+", "1.2.3", "abc", "Inf", "NaN", "1e99999", "1_000"} {
+		if _, err := ParseDecimal(s); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("ParseDecimal(%q) error = %v; want ErrInvalidDecimal", s, err)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, mode := range []RoundingMode{RoundHalfEven, RoundHalfUp, RoundHalfDown, RoundUp, RoundDown, RoundCeiling, RoundFloor} {
+		got, err := ParseRoundingMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseRoundingMode(%q) = %v, %v; want %v", mode.String(), got, err, mode)
+		}
+	}
+	if _, err := ParseRoundingMode("nearest"); err == nil {
+		t.Error("ParseRoundingMode(\"nearest\") succeeded; want an error")
+	}
+}
+
+func TestCalculator_DecimalOperations(t *testing.T) {
+	calc := New()
+	a, b := big.NewRat(1, 10), big.NewRat(2, 10)
+
+	if got := DefaultPrecision.Format(calc.AddDecimal(a, b)); got != "0.3" {
+		t.Errorf("AddDecimal(0.1, 0.2) = %s; want 0.3", got)
+	}
+	if got := DefaultPrecision.Format(calc.SubtractDecimal(a, b)); got != "-0.1" {
+		t.Errorf("SubtractDecimal(0.1, 0.2) = %s; want -0.1", got)
+	}
+	if got := DefaultPrecision.Format(calc.MultiplyDecimal(a, b)); got != "0.02" {
+		t.Errorf("MultiplyDecimal(0.1, 0.2) = %s; want 0.02", got)
+	}
+
+	quotient, err := calc.DivideDecimal(big.NewRat(2, 1), big.NewRat(3, 1))
+	if err != nil {
+		t.Fatalf("DivideDecimal(2, 3) returned error: %v", err)
+	}
+	if got := DefaultPrecision.Format(quotient); got != "0.6666666666666666666666666667" {
+		t.Errorf("DivideDecimal(2, 3) = %s; want 0.6666666666666666666666666667", got)
+	}
+	if _, err := calc.DivideDecimal(a, new(big.Rat)); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("DivideDecimal(0.1, 0) error = %v; want ErrDivisionByZero", err)
+	}
+}
+
+func TestCalculator_EvaluateDecimal(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0.1 + 0.2", "0.3"},
+		{"(2 + 3) * sqrt(16) / 4 ^ 2", "1.25"},
+		{"1 / 3", "0.3333333333333333333333333333"},
+		{"2 ^ -3", "0.125"},
+		{"2 ^ 100", "1267650600228229401496703205376"},
+		{"-7 % 3", "-1"},
+		{"7.5 % 2", "1.5"},
+		{"sqrt(0.01)", "0.1"},
+		{"sqrt(2)", "1.4142135623730950488016887242"},
+		{"16 ^ 0.5", "4"},
+		{"8 ^ (1 / 3)", "2"},
+		{"3 ^ 0.2", "1.2457309396155173259666803366"},
+		{"pi", "3.1415926535897932384626433833"},
+		{"e", "2.7182818284590452353602874714"},
+		{"tau - 2 * pi", "0"},
+		{"phi", "1.6180339887498948482045868344"},
+		{"exp(1) - e", "0"},
+		{"ln(2)", "0.6931471805599453094172321215"},
+		{"ln(1)", "0"},
+		{"log(1000) + log(0.01)", "1"},
+		{"sin(pi / 6)", "0.5"},
+		{"cos(pi)", "-1"},
+		{"tan(pi / 4)", "1"},
+		{"4 * atan(1)", "3.1415926535897932384626433833"},
+		{"asin(0.5) * 6", "3.1415926535897932384626433833"},
+		{"acos(1)", "0"},
+		{"round(-2.5) + floor(-2.5) + ceil(-2.5)", "-8"},
+		{"max(0.1, 0.3, 0.2) - min(0.5, 0.4)", "-0.1"},
+		{"abs(-0.5)", "0.5"},
+		{"factorial(25)", "15511210043330985984000000"},
+		{"nthroot(-8, 3)", "-2"},
+		{"nthroot(2, 2) - sqrt(2)", "0"},
+		{"pow(0.5, 2) + mod(7.5, 2)", "1.75"},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		result, err := calc.EvaluateDecimal(tt.expr, DefaultPrecision)
+		if err != nil {
+			t.Errorf("EvaluateDecimal(%q) returned error: %v", tt.expr, err)
+			continue
+		}
+		if got := DefaultPrecision.Format(result); got != tt.want {
+			t.Errorf("EvaluateDecimal(%q) = %s; want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCalculator_EvaluateDecimalErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"1 / (2 - 2)", 3, "division by zero"},
+		{"5 % 0", 3, "modulo by zero"},
+		{"0 ^ -1", 3, "division by zero"},
+		{"(-8) ^ 0.5", 6, "^ is undefined"},
+		{"2 ^ 1000000", 3, "exceeds the supported precision"},
+		{"exp(100000)", 1, "exceeds the supported precision"},
+		{"sqrt(-1)", 1, "sqrt of a negative number"},
+		{"1 + ln(0)", 5, "ln of a number that is not positive"},
+		{"asin(1.5)", 1, "asin of a number outside [-1, 1]"},
+		{"1e99999", 1, "out of range"},
+		{"factorial(-1)", 1, "not a whole number"},
+		{"factorial(100000)", 1, "exceeds the supported precision"},
+		{"nthroot(-4, 2)", 1, "even root of a negative number"},
+		{"2 * tan(1e8999)", 5, "tan of a number whose magnitude is 2^64 or more"},
+		{"sin(-2 ^ 64)", 1, "sin of a number whose magnitude is 2^64 or more"},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		_, err := calc.EvaluateDecimal(tt.expr, DefaultPrecision)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("EvaluateDecimal(%q) error = %v; want an *ExprError", tt.expr, err)
+			continue
+		}
+		if exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.msg) {
+			t.Errorf("EvaluateDecimal(%q) error = %v; want %q at position %d", tt.expr, err, tt.msg, tt.pos)
+		}
+	}
+
+	if _, err := calc.EvaluateDecimal("1", Precision{Scale: MaxScale + 1}); err == nil {
+		t.Error("EvaluateDecimal with a scale above MaxScale succeeded; want an error")
+	}
+}
+
+func TestCalculator_EvaluateDecimalLargeTrigArguments(t *testing.T) {
+	calc := New()
+	p := Precision{Scale: MaxScale}
+	start := time.Now()
+	for _, expr := range []string{"tan(1e8999)", "sin(1e8999)", "cos(1e18)", "tan(1e18)"} {
+		_, _ = calc.EvaluateDecimal(expr, p)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("large trig arguments at scale %d took %v; want under 2s", MaxScale, elapsed)
+	}
+
+	got, err := calc.EvaluateDecimal("sin(2 ^ 63)", DefaultPrecision)
+	if err != nil {
+		t.Fatalf("EvaluateDecimal(sin(2 ^ 63)) error = %v", err)
+	}
+	if f, _ := got.Float64(); math.Abs(f-math.Sin(1<<63)) > 1e-12 {
+		t.Errorf("EvaluateDecimal(sin(2 ^ 63)) = %v; want %v", f, math.Sin(1<<63))
+	}
+}
+
+func TestDecimalEvaluationCoversFloatEvaluation(t *testing.T) {
+	for name := range Constants {
+		if _, ok := decimalConstants[name]; !ok {
+			t.Errorf("constant %s has no decimal value", name)
+		}
+	}
+	for name, fn := range functions {
+		if fn.exact == nil {
+			t.Errorf("function %s has no decimal evaluation", name)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package calculator
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Constants are the names every expression can reference
+var Constants = map[string]float64{
+	"pi":  math.Pi,
+	"e":   math.E,
+	"tau": 2 * math.Pi,
+	"phi": math.Phi,
+}
+
+// function is a function callable from expressions, evaluated with
+// float64 by eval and with exact decimals by exact
+type function struct {
+	minArgs, maxArgs int // maxArgs < 0 allows any number
+	eval             func(args []float64) (float64, error)
+	exact            func(args []*big.Rat, prec uint) (*big.Rat, error)
+}
+
+// unary wraps one-argument functions without domain restrictions
+func unary(fn func(float64) float64, exact func(x *big.Rat, prec uint) *big.Rat) function {
+	return function{
+		minArgs: 1,
+		maxArgs: 1,
+		eval: func(args []float64) (float64, error) {
+			return fn(args[0]), nil
+		},
+		exact: func(args []*big.Rat, prec uint) (*big.Rat, error) {
+			return exact(args[0], prec), nil
+		},
+	}
+}
+
+// checked wraps one-argument functions that can fail
+func checked(fn func(float64) (float64, error), exact func(args []*big.Rat, prec uint) (*big.Rat, error)) function {
+	return function{
+		minArgs: 1,
+		maxArgs: 1,
+		eval: func(args []float64) (float64, error) {
+			return fn(args[0])
+		},
+		exact: exact,
+	}
+}
+
+// periodic wraps the trig functions, whose float64 evaluations accept every
+// argument while the decimal ones bound it
+func periodic(fn func(float64) float64, exact func(args []*big.Rat, prec uint) (*big.Rat, error)) function {
+	return function{
+		minArgs: 1,
+		maxArgs: 1,
+		eval: func(args []float64) (float64, error) {
+			return fn(args[0]), nil
+		},
+		exact: exact,
+	}
+}
+
+// binary wraps two-argument functions
+func binary(fn func(a, b float64) (float64, error), exact func(a, b *big.Rat, prec uint) (*big.Rat, error)) function {
+	return function{
+		minArgs: 2,
+		maxArgs: 2,
+		eval: func(args []float64) (float64, error) {
+			return fn(args[0], args[1])
+		},
+		exact: func(args []*big.Rat, prec uint) (*big.Rat, error) {
+			return exact(args[0], args[1], prec)
+		},
+	}
+}
+
+// functions are the functions every expression can call. The float64
+// evaluations are the Calculator methods.
+var functions = map[string]function{
+	"abs":       unary(std.Abs, decimalAbs),
+	"floor":     unary(math.Floor, roundingTo(RoundFloor)),
+	"ceil":      unary(math.Ceil, roundingTo(RoundCeiling)),
+	"round":     unary(math.Round, roundingTo(RoundHalfUp)),
+	"sin":       periodic(std.Sin, decimalSin),
+	"cos":       periodic(std.Cos, decimalCos),
+	"tan":       periodic(std.Tan, decimalTan),
+	"atan":      unary(std.Atan, decimalAtan),
+	"exp":       checked(std.Exp, decimalExp),
+	"sqrt":      checked(std.Sqrt, decimalSqrt),
+	"ln":        checked(std.Ln, decimalLn),
+	"log":       checked(std.Log, decimalLog),
+	"asin":      checked(std.Asin, decimalAsin),
+	"acos":      checked(std.Acos, decimalAcos),
+	"factorial": checked(factorial, decimalFactorial),
+	"pow":       binary(std.Pow, decimalPow),
+	"mod":       binary(std.Mod, decimalMod),
+	"nthroot":   binary(nthRoot, decimalNthRoot),
+	"min": {minArgs: 1, maxArgs: -1, eval: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	}, exact: func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return extreme(args, -1), nil
+	}},
+	"max": {minArgs: 1, maxArgs: -1, eval: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	}, exact: func(args []*big.Rat, _ uint) (*big.Rat, error) {
+		return extreme(args, 1), nil
+	}},
+}
+
+// factorial is Factorial for whole float64 arguments
+func factorial(n float64) (float64, error) {
+	if n != math.Trunc(n) || n < 0 {
+		return 0, errFactorialDomain
+	}
+	// Checked before the int conversion, which would wrap huge arguments
+	if n > maxFactorial {
+		return 0, factorialOverflow(strconv.FormatFloat(n, 'g', -1, 64))
+	}
+	return std.Factorial(int(n))
+}
+
+// nthRoot is NthRoot for whole float64 degrees
+func nthRoot(x, n float64) (float64, error) {
+	if n != math.Trunc(n) || n < 1 {
+		return 0, errNthRootDegree
+	}
+	// Degrees beyond the int range leave every root of |x| > 1 at 1
+	return std.NthRoot(x, int(math.Min(n, math.MaxInt32)))
+}
+
+// Eval evaluates a parsed expression. Names resolve to vars first, then to
+// Constants. Every intermediate result is finite.
+func Eval(node Node, vars map[string]float64) (float64, error) {
+	switch n := node.(type) {
+	case *NumberNode:
+		value, err := strconv.ParseFloat(n.Literal, 64)
+		if err != nil {
+			return 0, errorAt(n.Offset, "number %s is out of range", n.Literal)
+		}
+		return value, nil
+	case *IdentNode:
+		if value, ok := vars[n.Name]; ok {
+			return value, nil
+		}
+		if value, ok := Constants[n.Name]; ok {
+			return value, nil
+		}
+		return 0, errorAt(n.Offset, "unknown name %q", n.Name)
+	case *UnaryNode:
+		operand, err := Eval(n.Operand, vars)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == '-' {
+			return -operand, nil
+		}
+		return operand, nil
+	case *BinaryNode:
+		return evalBinary(n, vars)
+	case *CallNode:
+		return evalCall(n, vars)
+	default:
+		return 0, errorAt(node.Pos(), "unsupported expression")
+	}
+}
+
+func evalBinary(n *BinaryNode, vars map[string]float64) (float64, error) {
+	left, err := Eval(n.Left, vars)
+	if err != nil {
+		return 0, err
+	}
+	right, err := Eval(n.Right, vars)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch n.Op {
+	case '+':
+		result = left + right
+	case '-':
+		result = left - right
+	case '*':
+		result = left * right
+	case '/':
+		if right == 0 {
+			return 0, wrapAt(n.Offset, errDivisionByZero)
+		}
+		result = left / right
+	case '%':
+		if right == 0 {
+			return 0, wrapAt(n.Offset, errModuloByZero)
+		}
+		result = math.Mod(left, right)
+	case '^':
+		result = math.Pow(left, right)
+	}
+	return finite(result, n.Offset, string(n.Op))
+}
+
+func evalCall(n *CallNode, vars map[string]float64) (float64, error) {
+	fn, err := lookupFunction(n)
+	if err != nil {
+		return 0, err
+	}
+
+	args := make([]float64, len(n.Args))
+	for i, arg := range n.Args {
+		value, err := Eval(arg, vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+
+	result, err := fn.eval(args)
+	if err != nil {
+		return 0, wrapAt(n.Offset, err)
+	}
+	return finite(result, n.Offset, n.Name)
+}
+
+// lookupFunction returns the function a call refers to, checking its
+// number of arguments
+func lookupFunction(n *CallNode) (function, error) {
+	fn, ok := functions[n.Name]
+	if !ok {
+		return function{}, errorAt(n.Offset, "unknown function %q", n.Name)
+	}
+	if len(n.Args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.Args) > fn.maxArgs) {
+		return function{}, errorAt(n.Offset, "%s expects %s, got %d", n.Name, arity(fn), len(n.Args))
+	}
+	return fn, nil
+}
+
+// finite rejects the NaN and infinite results of an operation
+func finite(result float64, offset int, op string) (float64, error) {
+	result, err := checkFinite(op, result)
+	if err != nil {
+		return 0, wrapAt(offset, err)
+	}
+	return result, nil
+}
+
+// arity describes the number of arguments a function takes
+func arity(fn function) string {
+	switch {
+	case fn.maxArgs < 0:
+		return "at least " + plural(fn.minArgs, "argument")
+	case fn.minArgs == fn.maxArgs:
+		return plural(fn.minArgs, "argument")
+	default:
+		return strconv.Itoa(fn.minArgs) + " to " + plural(fn.maxArgs, "argument")
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return strconv.Itoa(n) + " " + word + "s"
+}
+
+// Evaluate parses and evaluates an arithmetic expression such as
+// "(2 + 3) * sqrt(16) / 4 ^ 2" within the default limits
+func (c *Calculator) Evaluate(expr string) (float64, error) {
+	node, err := Parse(expr, Limits{})
+	if err != nil {
+		return 0, err
+	}
+	return Eval(node, nil)
+}
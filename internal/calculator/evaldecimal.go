@@ -0,0 +1,464 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// maxExactRootDegree bounds the denominators of exponents whose roots are
+// tried exactly, such as the 2 of 16 ^ 0.5
+const maxExactRootDegree = 64
+
+// maxTrigExponent bounds the arguments of the decimal sin, cos and tan to
+// |x| < 2^maxTrigExponent. Reducing larger arguments modulo 2π costs one
+// more bit of π per bit of x.
+const maxTrigExponent = 64
+
+// decimalConstants compute the Constants to a number of bits
+var decimalConstants = map[string]func(prec uint) *big.Float{
+	"pi": bigPi,
+	"e": func(prec uint) *big.Float {
+		return bigExp(newFloat(prec).SetInt64(1), prec)
+	},
+	"tau": func(prec uint) *big.Float {
+		tau := bigPi(prec)
+		return tau.SetMantExp(tau, 1)
+	},
+	"phi": func(prec uint) *big.Float {
+		phi := newFloat(prec + 16).SetInt64(5)
+		phi.Sqrt(phi).Add(phi, big.NewFloat(1))
+		return phi.SetMantExp(phi, -1)
+	},
+}
+
+// EvalDecimal evaluates a parsed expression like Eval, with exact rational
+// arithmetic, and rounds the result to p. Irrational intermediate results
+// are computed to 64 bits beyond the scale of p.
+func EvalDecimal(node Node, vars map[string]*big.Rat, p Precision) (*big.Rat, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	result, err := evalDecimal(node, vars, precisionBits(p.Scale))
+	if err != nil {
+		return nil, err
+	}
+	return p.Round(result), nil
+}
+
+func evalDecimal(node Node, vars map[string]*big.Rat, prec uint) (*big.Rat, error) {
+	switch n := node.(type) {
+	case *NumberNode:
+		value, err := ParseDecimal(n.Literal)
+		if err != nil {
+			return nil, errorAt(n.Offset, "number %s is out of range", n.Literal)
+		}
+		return value, nil
+	case *IdentNode:
+		if value, ok := vars[n.Name]; ok {
+			return value, nil
+		}
+		if constant, ok := decimalConstants[n.Name]; ok {
+			return toRat(constant(prec + 16)), nil
+		}
+		return nil, errorAt(n.Offset, "unknown name %q", n.Name)
+	case *UnaryNode:
+		operand, err := evalDecimal(n.Operand, vars, prec)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == '-' {
+			return new(big.Rat).Neg(operand), nil
+		}
+		return operand, nil
+	case *BinaryNode:
+		return evalDecimalBinary(n, vars, prec)
+	case *CallNode:
+		return evalDecimalCall(n, vars, prec)
+	default:
+		return nil, errorAt(node.Pos(), "unsupported expression")
+	}
+}
+
+func evalDecimalBinary(n *BinaryNode, vars map[string]*big.Rat, prec uint) (*big.Rat, error) {
+	left, err := evalDecimal(n.Left, vars, prec)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evalDecimal(n.Right, vars, prec)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Rat)
+	switch n.Op {
+	case '+':
+		result.Add(left, right)
+	case '-':
+		result.Sub(left, right)
+	case '*':
+		result.Mul(left, right)
+	case '/':
+		if right.Sign() == 0 {
+			return nil, wrapAt(n.Offset, errDivisionByZero)
+		}
+		result.Quo(left, right)
+	case '%':
+		if right.Sign() == 0 {
+			return nil, wrapAt(n.Offset, errModuloByZero)
+		}
+		result, _ = decimalMod(left, right, prec)
+	case '^':
+		if result, err = decimalPow(left, right, prec); err != nil {
+			return nil, wrapAt(n.Offset, err)
+		}
+	}
+	return bounded(result, n.Offset, string(n.Op))
+}
+
+func evalDecimalCall(n *CallNode, vars map[string]*big.Rat, prec uint) (*big.Rat, error) {
+	fn, err := lookupFunction(n)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]*big.Rat, len(n.Args))
+	for i, arg := range n.Args {
+		if args[i], err = evalDecimal(arg, vars, prec); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := fn.exact(args, prec)
+	if err != nil {
+		return nil, wrapAt(n.Offset, err)
+	}
+	return bounded(result, n.Offset, n.Name)
+}
+
+// bounded rejects results too large to keep exactly
+func bounded(result *big.Rat, offset int, op string) (*big.Rat, error) {
+	if !fits(result) {
+		return nil, wrapAt(offset, precisionError(op))
+	}
+	return result, nil
+}
+
+// precisionError reports a result of op too large to keep exactly
+func precisionError(op string) *MathError {
+	return &MathError{Op: op, Msg: "result of " + op + " exceeds the supported precision", Err: ErrOverflow}
+}
+
+// toFloat converts x to a big.Float with prec bits after the binary point
+func toFloat(x *big.Rat, prec uint) *big.Float {
+	if whole := x.Num().BitLen() - x.Denom().BitLen(); whole > 0 {
+		prec += uint(whole)
+	}
+	return newFloat(prec + 1).SetRat(x)
+}
+
+// toRat converts a finite big.Float exactly
+func toRat(x *big.Float) *big.Rat {
+	r, _ := x.Rat(nil)
+	return r
+}
+
+func decimalAbs(x *big.Rat, _ uint) *big.Rat {
+	return new(big.Rat).Abs(x)
+}
+
+// roundingTo rounds to an integer with a rounding mode
+func roundingTo(mode RoundingMode) func(x *big.Rat, prec uint) *big.Rat {
+	return func(x *big.Rat, _ uint) *big.Rat {
+		return Precision{Rounding: mode}.Round(x)
+	}
+}
+
+// extreme returns the smallest argument for sign -1 and the largest for 1
+func extreme(args []*big.Rat, sign int) *big.Rat {
+	result := args[0]
+	for _, arg := range args[1:] {
+		if arg.Cmp(result) == sign {
+			result = arg
+		}
+	}
+	return result
+}
+
+func decimalSqrt(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x := args[0]
+	if x.Sign() < 0 {
+		return nil, errSqrtDomain
+	}
+	if root, ok := exactRoot(x, 2); ok {
+		return root, nil
+	}
+	f := toFloat(x, prec+16)
+	return toRat(f.Sqrt(f)), nil
+}
+
+func decimalExp(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x, _ := args[0].Float64()
+	bits := x / math.Ln2
+	if math.Abs(bits) > maxRatBits {
+		return nil, precisionError("exp")
+	}
+	wp := prec + 16
+	if bits > 0 {
+		wp += uint(bits)
+	}
+	return toRat(bigExp(toFloat(args[0], wp), wp)), nil
+}
+
+func decimalLn(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x := args[0]
+	if x.Sign() <= 0 {
+		return nil, errLnDomain
+	}
+	if x.Cmp(big.NewRat(1, 1)) == 0 {
+		return new(big.Rat), nil
+	}
+	return toRat(bigLn(toFloat(x, prec+16), prec+16)), nil
+}
+
+func decimalLog(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x := args[0]
+	if x.Sign() <= 0 {
+		return nil, errLogDomain
+	}
+	// Powers of ten have exact logarithms
+	if x.Denom().Cmp(big.NewInt(1)) == 0 {
+		if n, ok := powerOfTen(x.Num()); ok {
+			return big.NewRat(int64(n), 1), nil
+		}
+	} else if x.Num().Cmp(big.NewInt(1)) == 0 {
+		if n, ok := powerOfTen(x.Denom()); ok {
+			return big.NewRat(-int64(n), 1), nil
+		}
+	}
+
+	wp := prec + 16
+	ln := bigLn(toFloat(x, wp), wp)
+	return toRat(ln.Quo(ln, bigLn(newFloat(wp).SetInt64(10), wp))), nil
+}
+
+// powerOfTen returns n for 10^n
+func powerOfTen(x *big.Int) (int, bool) {
+	s := x.String()
+	if s[0] != '1' || strings.Trim(s[1:], "0") != "" {
+		return 0, false
+	}
+	return len(s) - 1, true
+}
+
+// trigArgument rejects arguments of op too large to reduce modulo 2π
+func trigArgument(op string, x *big.Rat, prec uint) (*big.Float, error) {
+	f := toFloat(x, prec+16)
+	if exponent(f) > maxTrigExponent {
+		return nil, domainError(op, fmt.Sprintf("%s of a number whose magnitude is 2^%d or more", op, maxTrigExponent))
+	}
+	return f, nil
+}
+
+func decimalSin(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x, err := trigArgument("sin", args[0], prec)
+	if err != nil {
+		return nil, err
+	}
+	return toRat(bigSin(x, prec)), nil
+}
+
+func decimalCos(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x, err := trigArgument("cos", args[0], prec)
+	if err != nil {
+		return nil, err
+	}
+	return toRat(bigCos(x, prec)), nil
+}
+
+func decimalTan(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x, err := trigArgument("tan", args[0], prec)
+	if err != nil {
+		return nil, err
+	}
+	return toRat(bigTan(x, prec)), nil
+}
+
+func decimalAtan(x *big.Rat, prec uint) *big.Rat {
+	return toRat(bigAtan(toFloat(x, prec+16), prec))
+}
+
+func decimalAsin(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x := args[0]
+	if x.Cmp(big.NewRat(-1, 1)) < 0 || x.Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, errAsinDomain
+	}
+	return asin(x, prec), nil
+}
+
+func decimalAcos(args []*big.Rat, prec uint) (*big.Rat, error) {
+	x := args[0]
+	if x.Cmp(big.NewRat(-1, 1)) < 0 || x.Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, errAcosDomain
+	}
+	if x.Cmp(big.NewRat(1, 1)) == 0 {
+		return new(big.Rat), nil
+	}
+	// acos(x) = π/2 − asin(x)
+	halfPi := toRat(bigPi(prec + 16))
+	halfPi.Quo(halfPi, big.NewRat(2, 1))
+	return halfPi.Sub(halfPi, asin(x, prec)), nil
+}
+
+// asin computes asin(x) = atan(x / √(1 − x²)) for x in [-1, 1]
+func asin(x *big.Rat, prec uint) *big.Rat {
+	if x.Sign() == 0 {
+		return new(big.Rat)
+	}
+	wp := prec + 32
+	if new(big.Rat).Abs(x).Cmp(big.NewRat(1, 1)) == 0 {
+		halfPi := bigPi(wp)
+		halfPi.SetMantExp(halfPi, -1)
+		if x.Sign() < 0 {
+			halfPi.Neg(halfPi)
+		}
+		return toRat(halfPi)
+	}
+
+	rest := new(big.Rat).Mul(x, x)
+	rest.Sub(big.NewRat(1, 1), rest)
+	root := toFloat(rest, wp)
+	root.Sqrt(root)
+	return toRat(bigAtan(root.Quo(toFloat(x, wp), root), prec))
+}
+
+// decimalPow computes base ^ exp, exactly when the result is rational
+func decimalPow(base, exp *big.Rat, prec uint) (*big.Rat, error) {
+	switch {
+	case exp.IsInt():
+		return intPow(base, exp.Num())
+	case base.Sign() < 0:
+		return nil, domainError("^", "^ is undefined for these operands")
+	case base.Sign() == 0:
+		if exp.Sign() < 0 {
+			return nil, errDivisionByZero
+		}
+		return new(big.Rat), nil
+	case base.Cmp(big.NewRat(1, 1)) == 0:
+		return big.NewRat(1, 1), nil
+	}
+
+	// Rational exponents p/q of perfect q-th powers stay exact
+	if q := exp.Denom(); q.IsInt64() && q.Int64() <= maxExactRootDegree {
+		if root, ok := exactRoot(base, uint(q.Int64())); ok {
+			return intPow(root, exp.Num())
+		}
+	}
+
+	// base ^ exp = e^(exp·ln(base)), computed to as many bits as the result
+	// has before the binary point
+	mant := new(big.Float)
+	e := toFloat(base, 64).MantExp(mant)
+	m, _ := mant.Float64()
+	y, _ := exp.Float64()
+	bits := y * (float64(e) + math.Log2(m))
+	if math.IsInf(bits, 0) || math.IsNaN(bits) || math.Abs(bits) > maxRatBits {
+		return nil, precisionError("^")
+	}
+	wp := prec + 16
+	if bits > 0 {
+		wp += uint(bits)
+	}
+
+	lnPrec := wp + 16
+	if e := exp.Num().BitLen() - exp.Denom().BitLen(); e > 0 {
+		lnPrec += uint(e)
+	}
+	t := bigLn(toFloat(base, lnPrec), lnPrec)
+	t.Mul(t, toFloat(exp, lnPrec))
+	return toRat(bigExp(t, wp)), nil
+}
+
+// intPow computes base ^ n exactly for an integer n
+func intPow(base *big.Rat, n *big.Int) (*big.Rat, error) {
+	switch {
+	case n.Sign() == 0:
+		return big.NewRat(1, 1), nil
+	case base.Sign() == 0:
+		if n.Sign() < 0 {
+			return nil, errDivisionByZero
+		}
+		return new(big.Rat), nil
+	case new(big.Rat).Abs(base).Cmp(big.NewRat(1, 1)) == 0:
+		if base.Sign() < 0 && n.Bit(0) == 1 {
+			return big.NewRat(-1, 1), nil
+		}
+		return big.NewRat(1, 1), nil
+	}
+
+	size := base.Num().BitLen()
+	if d := base.Denom().BitLen(); d > size {
+		size = d
+	}
+	if !n.IsInt64() || n.BitLen() > 31 || (size-1)*int(new(big.Int).Abs(n).Int64()) > maxRatBits {
+		return nil, precisionError("^")
+	}
+
+	abs := new(big.Int).Abs(n)
+	num := new(big.Int).Exp(base.Num(), abs, nil)
+	den := new(big.Int).Exp(base.Denom(), abs, nil)
+	if n.Sign() < 0 {
+		num, den = den, num
+	}
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+// decimalMod returns the remainder of a / b with the sign of a, like
+// math.Mod
+func decimalMod(a, b *big.Rat, _ uint) (*big.Rat, error) {
+	if b.Sign() == 0 {
+		return nil, errModuloByZero
+	}
+	quotient := new(big.Rat).Quo(a, b)
+	whole := new(big.Rat).SetInt(new(big.Int).Quo(quotient.Num(), quotient.Denom()))
+	return whole.Sub(a, whole.Mul(b, whole)), nil
+}
+
+// decimalNthRoot returns the real n-th root of x, exactly for perfect powers
+func decimalNthRoot(x, n *big.Rat, prec uint) (*big.Rat, error) {
+	if !n.IsInt() || n.Sign() < 1 || !n.Num().IsInt64() {
+		return nil, errNthRootDegree
+	}
+	degree := n.Num().Int64()
+	if x.Sign() < 0 && degree%2 == 0 {
+		return nil, errNthRootDomain
+	}
+	if x.Sign() == 0 || degree == 1 {
+		return x, nil
+	}
+
+	abs := new(big.Rat).Abs(x)
+	root, err := decimalPow(abs, new(big.Rat).SetFrac64(1, degree), prec)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() < 0 {
+		root.Neg(root)
+	}
+	return root, nil
+}
+
+// decimalFactorial returns n! exactly
+func decimalFactorial(args []*big.Rat, _ uint) (*big.Rat, error) {
+	n := args[0]
+	if !n.IsInt() || n.Sign() < 0 {
+		return nil, errFactorialDomain
+	}
+	// log2(n!) = lgamma(n + 1) / ln(2)
+	v, _ := n.Float64()
+	if lg, _ := math.Lgamma(v + 1); lg/math.Ln2 > maxRatBits {
+		return nil, precisionError("factorial")
+	}
+	return new(big.Rat).SetInt(new(big.Int).MulRange(1, n.Num().Int64())), nil
+}
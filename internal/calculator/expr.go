@@ -0,0 +1,471 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expression limits applied by Parse
+const (
+	DefaultMaxExpressionLength = 1024
+	DefaultMaxExpressionDepth  = 64
+)
+
+// Limits bounds the expressions accepted by Parse. Zero values select the
+// defaults.
+type Limits struct {
+	// MaxLength is the maximum expression length in bytes
+	MaxLength int
+	// MaxDepth is the maximum nesting of parentheses, calls and operators
+	MaxDepth int
+}
+
+// withDefaults fills in the zero limits
+func (l Limits) withDefaults() Limits {
+	if l.MaxLength <= 0 {
+		l.MaxLength = DefaultMaxExpressionLength
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultMaxExpressionDepth
+	}
+	return l
+}
+
+// ExprError is a syntax or evaluation error at a position of the expression
+type ExprError struct {
+	// Pos is the 1-based column, in bytes, the error refers to
+	Pos int
+	Msg string
+	// Err is the *MathError of a failed evaluation, nil for syntax errors
+	Err error
+}
+
+// Error implements the error interface
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos, e.Msg)
+}
+
+// Unwrap returns the underlying evaluation error
+func (e *ExprError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt creates an ExprError at a 0-based offset
+func errorAt(offset int, format string, args ...interface{}) *ExprError {
+	return &ExprError{Pos: offset + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// wrapAt creates an ExprError for an evaluation error at a 0-based offset
+func wrapAt(offset int, err error) *ExprError {
+	return &ExprError{Pos: offset + 1, Msg: err.Error(), Err: err}
+}
+
+// Node is a node of a parsed expression
+type Node interface {
+	// Pos returns the 0-based offset of the node in the expression
+	Pos() int
+}
+
+// NumberNode is a numeric literal, kept as written so it can be evaluated
+// at any precision
+type NumberNode struct {
+	Offset  int
+	Literal string
+}
+
+// IdentNode is a reference to a constant or variable
+type IdentNode struct {
+	Offset int
+	Name   string
+}
+
+// UnaryNode is a prefix operation
+type UnaryNode struct {
+	Offset  int
+	Op      byte
+	Operand Node
+}
+
+// BinaryNode is an infix operation
+type BinaryNode struct {
+	Offset      int
+	Op          byte
+	Left, Right Node
+}
+
+// CallNode is a function call
+type CallNode struct {
+	Offset int
+	Name   string
+	Args   []Node
+}
+
+// Pos implements Node
+func (n *NumberNode) Pos() int { return n.Offset }
+
+// Pos implements Node
+func (n *IdentNode) Pos() int { return n.Offset }
+
+// Pos implements Node
+func (n *UnaryNode) Pos() int { return n.Offset }
+
+// Pos implements Node
+func (n *BinaryNode) Pos() int { return n.Offset }
+
+// Pos implements Node
+func (n *CallNode) Pos() int { return n.Offset }
+
+// tokenKind classifies tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAssign
+)
+
+// token is a lexical token and its offset
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// describe names a token in error messages
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// tokenize splits an expression into tokens
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || c == '.':
+			start := i
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if src[start:i] == "." {
+				return nil, errorAt(start, "unexpected %q", ".")
+			}
+			// An exponent needs digits, so 2e stays a number followed by e
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				j := i + 1
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				if j < len(src) && isDigit(src[j]) {
+					for j < len(src) && isDigit(src[j]) {
+						j++
+					}
+					i = j
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], offset: start})
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], offset: start})
+		case strings.IndexByte("+-*/%^", c) >= 0:
+			tokens = append(tokens, token{kind: tokenOperator, text: string(c), offset: i})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", offset: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", offset: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", offset: i})
+			i++
+		case c == '=':
+			tokens = append(tokens, token{kind: tokenAssign, text: "=", offset: i})
+			i++
+		default:
+			return nil, errorAt(i, "unexpected character %q", rune(c))
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(src)}), nil
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
+
+// parser is a recursive descent parser over the grammar
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | ident [ "(" [ expr { "," expr } ] ")" ] | "(" expr ")"
+//
+// so ^ binds tighter than unary minus and is right-associative:
+// -2^2 is -4 and 2^3^2 is 512.
+type parser struct {
+	tokens   []token
+	pos      int
+	depth    int
+	maxDepth int
+}
+
+// Parse parses an expression within the given limits
+func Parse(src string, limits Limits) (Node, error) {
+	p, err := newParser(src, limits)
+	if err != nil {
+		return nil, err
+	}
+	return p.parse()
+}
+
+// Ans names the result of the previous calculation in expressions
+const Ans = "ans"
+
+// Statement is an expression, assigned to the variable Name in statements
+// such as "x = 2 + 3"
+type Statement struct {
+	Name string
+	Expr Node
+}
+
+// ParseStatement parses an expression or an assignment within the given
+// limits. Constants, functions and Ans cannot be assigned.
+func ParseStatement(src string, limits Limits) (Statement, error) {
+	p, err := newParser(src, limits)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var stmt Statement
+	// The token list always ends with tokenEOF, so a name has a successor
+	if name := p.peek(); name.kind == tokenIdent && p.tokens[p.pos+1].kind == tokenAssign {
+		if err := assignable(name); err != nil {
+			return Statement{}, err
+		}
+		stmt.Name = name.text
+		p.pos += 2
+		if tok := p.peek(); tok.kind == tokenEOF {
+			return Statement{}, errorAt(tok.offset, "expected an expression after \"=\"")
+		}
+	}
+
+	if stmt.Expr, err = p.parse(); err != nil {
+		return Statement{}, err
+	}
+	return stmt, nil
+}
+
+// assignable rejects assignments to names that already have a meaning
+func assignable(name token) error {
+	if name.text == Ans {
+		return errorAt(name.offset, "cannot assign to %q, it holds the previous result", Ans)
+	}
+	if _, ok := Constants[name.text]; ok {
+		return errorAt(name.offset, "cannot assign to constant %q", name.text)
+	}
+	if _, ok := functions[name.text]; ok {
+		return errorAt(name.offset, "cannot assign to function %q", name.text)
+	}
+	return nil
+}
+
+// newParser tokenizes src within the given limits
+func newParser(src string, limits Limits) (*parser, error) {
+	limits = limits.withDefaults()
+	if len(src) > limits.MaxLength {
+		return nil, errorAt(limits.MaxLength, "expression is longer than %d characters", limits.MaxLength)
+	}
+
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, maxDepth: limits.MaxDepth}
+	if p.peek().kind == tokenEOF {
+		return nil, errorAt(0, "empty expression")
+	}
+	return p, nil
+}
+
+// parse parses the remaining tokens as a single expression
+func (p *parser) parse() (Node, error) {
+	node, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorAt(tok.offset, "unexpected %s", tok.describe())
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// enter guards the nesting depth
+func (p *parser) enter(offset int) error {
+	p.depth++
+	if p.depth > p.maxDepth {
+		return errorAt(offset, "expression is nested deeper than %d levels", p.maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isOperator(ops string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOperator && strings.Contains(ops, tok.text)
+}
+
+func (p *parser) expr() (Node, error) {
+	return p.binary("+-", p.term)
+}
+
+func (p *parser) term() (Node, error) {
+	return p.binary("*/%", p.unary)
+}
+
+// binary parses a left-associative chain of the given operators
+func (p *parser) binary(ops string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(ops) {
+		op := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryNode{Offset: op.offset, Op: op.text[0], Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	if !p.isOperator("+-") {
+		return p.power()
+	}
+	op := p.next()
+	if err := p.enter(op.offset); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	operand, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &UnaryNode{Offset: op.offset, Op: op.text[0], Operand: operand}, nil
+}
+
+func (p *parser) power() (Node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	op := p.next()
+	if err := p.enter(op.offset); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryNode{Offset: op.offset, Op: '^', Left: base, Right: exponent}, nil
+}
+
+func (p *parser) primary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &NumberNode{Offset: tok.offset, Literal: tok.text}, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return &IdentNode{Offset: tok.offset, Name: tok.text}, nil
+		}
+		return p.call(tok)
+	case tokenLParen:
+		if err := p.enter(tok.offset); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		node, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, errorAt(closing.offset, "expected \")\" to close \"(\" at position %d, got %s", tok.offset+1, closing.describe())
+		}
+		return node, nil
+	default:
+		return nil, errorAt(tok.offset, "expected a number, name or \"(\", got %s", tok.describe())
+	}
+}
+
+// call parses the arguments of a function call
+func (p *parser) call(name token) (Node, error) {
+	open := p.next()
+	if err := p.enter(open.offset); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	node := &CallNode{Offset: name.offset, Name: name.text}
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return node, nil
+	}
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		node.Args = append(node.Args, arg)
+
+		switch tok := p.next(); tok.kind {
+		case tokenComma:
+		case tokenRParen:
+			return node, nil
+		default:
+			return nil, errorAt(tok.offset, "expected \",\" or \")\" in call to %s, got %s", name.text, tok.describe())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCalculator_Evaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2", 3},
+		{"(2 + 3) * sqrt(16) / 4 ^ 2", 1.25},
+		{"2 + 3 * 4", 14},
+		{"10 - 4 - 3", 3},
+		{"64 / 4 / 2", 8},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 ^ -1", 0.5},
+		{"--3", 3},
+		{"+3 - -3", 6},
+		{"7 % 3", 1},
+		{"1.5e3 + .5", 1500.5},
+		{"max(1, 5, 3) - min(4, 2)", 3},
+		{"abs(-3) + floor(2.7) + ceil(2.1) + round(2.5)", 11},
+		{"ln(e) + log(1000)", 4},
+		{"cos(pi)", -1},
+		{"tau / pi", 2},
+		{"factorial(5) + nthroot(27, 3)", 123},
+		{"pow(2, 10) - mod(7, 3)", 1023},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		got, err := calc.Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned error: %v", tt.expr, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Evaluate(%q) = %v; want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCalculator_EvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"", 1, "empty expression"},
+		{"1 +", 4, "expected a number"},
+		{"(1 + 2", 7, `to close "(" at position 1`},
+		{"1 + 2)", 6, `unexpected ")"`},
+		{"2 $ 3", 3, "unexpected character"},
+		{"2e", 2, `unexpected "e"`},
+		{"1 / (2 - 2)", 3, "division by zero"},
+		{"5 % 0", 3, "modulo by zero"},
+		{"2 * foo", 5, `unknown name "foo"`},
+		{"1 + bar(2)", 5, `unknown function "bar"`},
+		{"sqrt(1, 2)", 1, "sqrt expects 1 argument, got 2"},
+		{"max()", 1, "max expects at least 1 argument, got 0"},
+		{"3 + sqrt(-1)", 5, "sqrt of a negative number"},
+		{"10 ^ 400", 4, "result of ^ overflows"},
+		{"min(1 2)", 7, `expected "," or ")" in call to min`},
+		{"factorial(2.5)", 1, "not a whole number"},
+		{"factorial(1000)", 1, "factorial of 1000 overflows"},
+		{"1 + nthroot(-4, 2)", 5, "even root of a negative number"},
+		{"x = 1", 3, `unexpected "="`},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		_, err := calc.Evaluate(tt.expr)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Evaluate(%q) error = %v; want an *ExprError", tt.expr, err)
+			continue
+		}
+		if exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.msg) {
+			t.Errorf("Evaluate(%q) error = %v; want %q at position %d", tt.expr, err, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestParse_Limits(t *testing.T) {
+	limits := Limits{MaxLength: 20, MaxDepth: 3}
+
+	if _, err := Parse(strings.Repeat("1+", 10)+"1", limits); err == nil || !strings.Contains(err.Error(), "longer than 20") {
+		t.Errorf("Parse of a long expression error = %v; want a length error", err)
+	}
+	if _, err := Parse("(((1)))", limits); err != nil {
+		t.Errorf("Parse(%q) returned error: %v", "(((1)))", err)
+	}
+	if _, err := Parse("((((1))))", limits); err == nil || !strings.Contains(err.Error(), "deeper than 3") {
+		t.Errorf("Parse(%q) error = %v; want a depth error", "((((1))))", err)
+	}
+
+	// The default depth stops runaway unary operators and exponents too
+	for _, expr := range []string{strings.Repeat("-", 100) + "1", strings.Repeat("2^", 100) + "1"} {
+		if _, err := Parse(expr, Limits{}); err == nil {
+			t.Errorf("Parse(%q) succeeded; want a depth error", expr)
+		}
+	}
+}
+
+func TestEval_Variables(t *testing.T) {
+	node, err := Parse("x * 2 + pi", Limits{})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	got, err := Eval(node, map[string]float64{"x": 3, "pi": 1})
+	if err != nil {
+		t.Fatalf("Eval returned error: %v", err)
+	}
+	if got != 7 {
+		t.Errorf("Eval = %v; want 7", got)
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	stmt, err := ParseStatement("total = ans * 2", Limits{})
+	if err != nil {
+		t.Fatalf("ParseStatement returned error: %v", err)
+	}
+	if stmt.Name != "total" {
+		t.Errorf("Name = %q; want total", stmt.Name)
+	}
+	if got, err := Eval(stmt.Expr, map[string]float64{Ans: 21}); err != nil || got != 42 {
+		t.Errorf("Eval = %v, %v; want 42", got, err)
+	}
+
+	if stmt, err := ParseStatement("x + 1", Limits{}); err != nil || stmt.Name != "" {
+		t.Errorf("ParseStatement(%q) = %+v, %v; want an unnamed expression", "x + 1", stmt, err)
+	}
+
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{"pi = 3", 1, `cannot assign to constant "pi"`},
+		{"ans = 3", 1, `cannot assign to "ans"`},
+		{"sqrt = 3", 1, `cannot assign to function "sqrt"`},
+		{"x =", 4, `expected an expression after "="`},
+		{"x = y = 1", 7, `unexpected "="`},
+		{"2 = x", 3, `unexpected "="`},
+	}
+	for _, tt := range tests {
+		_, err := ParseStatement(tt.src, Limits{})
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) || exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.msg) {
+			t.Errorf("ParseStatement(%q) error = %v; want %q at position %d", tt.src, err, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestCalculator_EvaluateErrorKinds(t *testing.T) {
+	tests := []struct {
+		expr string
+		kind error
+	}{
+		{"sqrt(-1)", ErrDomain},
+		{"factorial(200)", ErrOverflow},
+		{"exp(1000)", ErrOverflow},
+		{"1 / 0", ErrDivisionByZero},
+		{"mod(1, 0)", ErrDivisionByZero},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		if _, err := calc.Evaluate(tt.expr); !errors.Is(err, tt.kind) {
+			t.Errorf("Evaluate(%q) error = %v; want %v", tt.expr, err, tt.kind)
+		}
+	}
+	if _, err := calc.Evaluate("1 +"); errors.Unwrap(err) != nil {
+		t.Errorf("syntax error %v wraps %v; want nothing", err, errors.Unwrap(err))
+	}
+}
@@ -0,0 +1,133 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/history"
+	"github.com/example/go-template/internal/logging"
+)
+
+// History page sizes
+const (
+	defaultHistoryPage = 20
+	maxHistoryPage     = 100
+)
+
+// handleListHistory lists the calculations of the caller
+func (h *Handler) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.Principal(r.Context())
+	limit, offset, err := pageQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	entries, total, err := h.history.List(r.Context(), principal, offset, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("listing calculation history failed", "error", err)
+		h.WriteError(w, http.StatusInternalServerError, "failed to load calculation history")
+		return
+	}
+	h.WriteSuccess(w, history.Page{Entries: entries, Total: total, Offset: offset, Limit: limit})
+}
+
+// handleClearHistory clears the calculations of the caller
+func (h *Handler) handleClearHistory(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.Principal(r.Context())
+	if err := h.history.Clear(r.Context(), principal); err != nil {
+		logging.FromContext(r.Context()).Error("clearing calculation history failed", "error", err)
+		h.WriteError(w, http.StatusInternalServerError, "failed to clear calculation history")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pageQuery reads the limit and offset query parameters
+func pageQuery(r *http.Request) (limit, offset int, err error) {
+	query := r.URL.Query()
+	limit = defaultHistoryPage
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxHistoryPage {
+			return 0, 0, fmt.Errorf("limit must be a whole number between 1 and %d", maxHistoryPage)
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a whole number of at least 0")
+		}
+	}
+	return limit, offset, nil
+}
+
+// record appends calculations to the history of an authenticated caller.
+// A failure is logged and the calculation still answered.
+func (h *Handler) record(r *http.Request, entries ...history.Entry) {
+	principal, ok := common.Principal(r.Context())
+	if !ok || len(entries) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	for i := range entries {
+		entries[i].CreatedAt = now
+	}
+	if err := h.history.Append(r.Context(), principal, entries); err != nil {
+		logging.FromContext(r.Context()).Error("recording calculation history failed", "error", err)
+	}
+}
+
+// variables loads the results an authenticated caller can reference,
+// nothing for anonymous ones
+func (h *Handler) variables(r *http.Request) (history.Variables, error) {
+	principal, ok := common.Principal(r.Context())
+	if !ok {
+		return history.Variables{}, nil
+	}
+	vars, err := h.history.Variables(r.Context(), principal)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("loading calculation history failed", "error", err)
+	}
+	return vars, err
+}
+
+// floatVariables converts the recorded results to float64 variables. Ans
+// holds the last result.
+func floatVariables(vars history.Variables) map[string]float64 {
+	values := make(map[string]float64, len(vars.Named)+1)
+	for name, result := range vars.Named {
+		if x, err := strconv.ParseFloat(result.String(), 64); err == nil {
+			values[name] = x
+		}
+	}
+	if x, err := strconv.ParseFloat(vars.Last.String(), 64); err == nil {
+		values[Ans] = x
+	}
+	return values
+}
+
+// decimalVariables converts the recorded results to exact variables. Ans
+// holds the last result.
+func decimalVariables(vars history.Variables) map[string]*big.Rat {
+	values := make(map[string]*big.Rat, len(vars.Named)+1)
+	for name, result := range vars.Named {
+		if x, err := ParseDecimal(result.String()); err == nil {
+			values[name] = x
+		}
+	}
+	if x, err := ParseDecimal(vars.Last.String()); err == nil {
+		values[Ans] = x
+	}
+	return values
+}
+
+// floatNumber formats a finite result the way it is written in responses
+func floatNumber(x float64) json.Number {
+	b, _ := json.Marshal(x)
+	return json.Number(b)
+}
@@ -0,0 +1,433 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Kinds of MathError raised by matrix and vector operations
+var (
+	ErrDimension = errors.New("operand dimensions do not match the operation")
+	ErrSingular  = errors.New("matrix is singular")
+)
+
+// ErrMatrixTooLarge reports a matrix or vector above the configured size
+var ErrMatrixTooLarge = errors.New("matrix too large")
+
+// Norms accepted by VectorNorm and MatrixNorm
+const (
+	NormL1        = "1"
+	NormL2        = "2"
+	NormInf       = "inf"
+	NormFrobenius = "fro"
+)
+
+// Matrix is a dense matrix stored as rows of equal length
+type Matrix [][]float64
+
+// Vector is a column vector
+type Vector []float64
+
+// Dims returns the number of rows and columns of m
+func (m Matrix) Dims() (rows, cols int) {
+	if len(m) == 0 {
+		return 0, 0
+	}
+	return len(m), len(m[0])
+}
+
+// Validate checks that m, named name in messages, has rows of equal length
+// and at most maxSize rows and columns
+func (m Matrix) Validate(name string, maxSize int) error {
+	if len(m) == 0 || len(m[0]) == 0 {
+		return fmt.Errorf("%s must have at least one row and one column", name)
+	}
+	if len(m) > maxSize || len(m[0]) > maxSize {
+		return fmt.Errorf("%w: %s is %dx%d, at most %dx%d is accepted", ErrMatrixTooLarge, name, len(m), len(m[0]), maxSize, maxSize)
+	}
+	for i, row := range m {
+		if len(row) != len(m[0]) {
+			return fmt.Errorf("%s has %d columns in row 1 but %d in row %d", name, len(m[0]), len(row), i+1)
+		}
+	}
+	return nil
+}
+
+// Validate checks that v, named name in messages, has between 1 and
+// maxSize elements
+func (v Vector) Validate(name string, maxSize int) error {
+	if len(v) == 0 {
+		return fmt.Errorf("%s must have at least one element", name)
+	}
+	if len(v) > maxSize {
+		return fmt.Errorf("%w: %s has %d elements, at most %d are accepted", ErrMatrixTooLarge, name, len(v), maxSize)
+	}
+	return nil
+}
+
+// dimensionError reports operands of op whose shapes do not fit together
+func dimensionError(op, format string, args ...interface{}) *MathError {
+	return &MathError{Op: op, Msg: fmt.Sprintf(format, args...), Err: ErrDimension}
+}
+
+// checkRows reports a matrix operand of op, named name in messages, whose
+// rows differ in length
+func checkRows(op, name string, m Matrix) error {
+	for i, row := range m {
+		if len(row) != len(m[0]) {
+			return dimensionError(op, "%s has %d columns in row 1 but %d in row %d", name, len(m[0]), len(row), i+1)
+		}
+	}
+	return nil
+}
+
+// singularError reports a singular matrix given to op
+func singularError(op string) *MathError {
+	return &MathError{Op: op, Msg: "matrix is singular, " + op + " has no unique result", Err: ErrSingular}
+}
+
+// newMatrix allocates a rows x cols matrix of zeros
+func newMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+// finiteMatrix reports overflowing elements of a result of op
+func finiteMatrix(op string, m Matrix) (Matrix, error) {
+	for _, row := range m {
+		if _, err := finiteVector(op, row); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// finiteVector reports overflowing elements of a result of op
+func finiteVector(op string, v Vector) (Vector, error) {
+	for _, x := range v {
+		if _, err := checkFinite(op, x); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// MatrixAdd returns the element-wise sum of a and b
+func (c *Calculator) MatrixAdd(a, b Matrix) (Matrix, error) {
+	if err := checkRows("add", "a", a); err != nil {
+		return nil, err
+	}
+	if err := checkRows("add", "b", b); err != nil {
+		return nil, err
+	}
+	rows, cols := a.Dims()
+	if r, k := b.Dims(); r != rows || k != cols {
+		return nil, dimensionError("add", "cannot add a %dx%d matrix and a %dx%d matrix", rows, cols, r, k)
+	}
+	sum := newMatrix(rows, cols)
+	for i := range sum {
+		for j := range sum[i] {
+			sum[i][j] = a[i][j] + b[i][j]
+		}
+	}
+	return finiteMatrix("add", sum)
+}
+
+// MatrixMultiply returns the matrix product of a and b
+func (c *Calculator) MatrixMultiply(a, b Matrix) (Matrix, error) {
+	if err := checkRows("multiply", "a", a); err != nil {
+		return nil, err
+	}
+	if err := checkRows("multiply", "b", b); err != nil {
+		return nil, err
+	}
+	rows, inner := a.Dims()
+	r, cols := b.Dims()
+	if r != inner {
+		return nil, dimensionError("multiply", "cannot multiply a %dx%d matrix by a %dx%d matrix, the columns of a must match the rows of b", rows, inner, r, cols)
+	}
+	product := newMatrix(rows, cols)
+	for i := range product {
+		for k := 0; k < inner; k++ {
+			aik := a[i][k]
+			for j := range product[i] {
+				product[i][j] += aik * b[k][j]
+			}
+		}
+	}
+	return finiteMatrix("multiply", product)
+}
+
+// Transpose returns the transpose of a
+func (c *Calculator) Transpose(a Matrix) (Matrix, error) {
+	if err := checkRows("transpose", "a", a); err != nil {
+		return nil, err
+	}
+	rows, cols := a.Dims()
+	t := newMatrix(cols, rows)
+	for i := range a {
+		for j := range a[i] {
+			t[j][i] = a[i][j]
+		}
+	}
+	return t, nil
+}
+
+// Determinant returns the determinant of a square matrix, which is 0 for
+// singular ones
+func (c *Calculator) Determinant(a Matrix) (float64, error) {
+	lu, err := Decompose(a)
+	if err != nil {
+		return 0, err
+	}
+	return lu.Determinant()
+}
+
+// Inverse returns the inverse of a square matrix
+func (c *Calculator) Inverse(a Matrix) (Matrix, error) {
+	lu, err := Decompose(a)
+	if err != nil {
+		return nil, err
+	}
+	return lu.Inverse()
+}
+
+// Solve returns x such that a x = b for a square matrix a
+func (c *Calculator) Solve(a Matrix, b Vector) (Vector, error) {
+	lu, err := Decompose(a)
+	if err != nil {
+		return nil, err
+	}
+	return lu.Solve(b)
+}
+
+// Dot returns the dot product of u and v
+func (c *Calculator) Dot(u, v Vector) (float64, error) {
+	if len(u) != len(v) {
+		return 0, dimensionError("dot", "cannot take the dot product of vectors of %d and %d elements", len(u), len(v))
+	}
+	var sum float64
+	for i := range u {
+		sum += u[i] * v[i]
+	}
+	return checkFinite("dot", sum)
+}
+
+// Cross returns the cross product of two 3-dimensional vectors
+func (c *Calculator) Cross(u, v Vector) (Vector, error) {
+	if len(u) != 3 || len(v) != 3 {
+		return nil, dimensionError("cross", "the cross product needs vectors of 3 elements, got %d and %d", len(u), len(v))
+	}
+	return finiteVector("cross", Vector{
+		u[1]*v[2] - u[2]*v[1],
+		u[2]*v[0] - u[0]*v[2],
+		u[0]*v[1] - u[1]*v[0],
+	})
+}
+
+// VectorNorm returns the 1, 2 (Euclidean, the default) or inf norm of v
+func (c *Calculator) VectorNorm(v Vector, ord string) (float64, error) {
+	switch ord {
+	case NormL1:
+		var sum float64
+		for _, x := range v {
+			sum += math.Abs(x)
+		}
+		return checkFinite("norm", sum)
+	case "", NormL2:
+		return checkFinite("norm", euclidean(v))
+	case NormInf:
+		var largest float64
+		for _, x := range v {
+			largest = math.Max(largest, math.Abs(x))
+		}
+		return largest, nil
+	}
+	return 0, fmt.Errorf("unknown vector norm %q, use 1, 2 or inf", ord)
+}
+
+// MatrixNorm returns the Frobenius (the default), 1 (largest column sum) or
+// inf (largest row sum) norm of a
+func (c *Calculator) MatrixNorm(a Matrix, ord string) (float64, error) {
+	switch ord {
+	case "", NormFrobenius:
+		var elements Vector
+		for _, row := range a {
+			elements = append(elements, row...)
+		}
+		return checkFinite("norm", euclidean(elements))
+	case NormL1:
+		t, err := c.Transpose(a)
+		if err != nil {
+			return 0, err
+		}
+		return c.MatrixNorm(t, NormInf)
+	case NormInf:
+		var largest float64
+		for _, row := range a {
+			sum, err := c.VectorNorm(row, NormL1)
+			if err != nil {
+				return 0, err
+			}
+			largest = math.Max(largest, sum)
+		}
+		return largest, nil
+	}
+	return 0, fmt.Errorf("unknown matrix norm %q, use fro, 1 or inf", ord)
+}
+
+// euclidean returns the 2-norm of v, scaled so that squaring large
+// elements does not overflow
+func euclidean(v Vector) float64 {
+	var scale, sum float64 = 0, 1
+	for _, x := range v {
+		if x == 0 {
+			continue
+		}
+		x = math.Abs(x)
+		if scale < x {
+			sum = 1 + sum*(scale/x)*(scale/x)
+			scale = x
+		} else {
+			sum += (x / scale) * (x / scale)
+		}
+	}
+	return scale * math.Sqrt(sum)
+}
+
+// LU is the decomposition PA = LU, with partial pivoting, of a square
+// matrix A
+type LU struct {
+	// lu holds U on and above the diagonal and L, whose diagonal is 1,
+	// below it
+	lu Matrix
+	// pivot lists the row of A that is row i of PA
+	pivot []int
+	// sign is the determinant of P
+	sign     float64
+	singular bool
+}
+
+// Decompose computes the LU decomposition of a square matrix. Pivots
+// within rounding error of zero mark the matrix singular.
+func Decompose(a Matrix) (*LU, error) {
+	if err := checkRows("lu", "a", a); err != nil {
+		return nil, err
+	}
+	n, cols := a.Dims()
+	if n != cols {
+		return nil, dimensionError("lu", "a square matrix is required, got %dx%d", n, cols)
+	}
+
+	lu := newMatrix(n, n)
+	var scale float64
+	for i := range a {
+		copy(lu[i], a[i])
+		for _, x := range a[i] {
+			scale = math.Max(scale, math.Abs(x))
+		}
+	}
+	tolerance := float64(n) * scale * 0x1p-52
+
+	f := &LU{lu: lu, pivot: make([]int, n), sign: 1}
+	for i := range f.pivot {
+		f.pivot[i] = i
+	}
+	for k := 0; k < n; k++ {
+		// The largest element of the column limits the growth of rounding
+		// errors
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu[i][k]) > math.Abs(lu[p][k]) {
+				p = i
+			}
+		}
+		if p != k {
+			lu[p], lu[k] = lu[k], lu[p]
+			f.pivot[p], f.pivot[k] = f.pivot[k], f.pivot[p]
+			f.sign = -f.sign
+		}
+		if math.Abs(lu[k][k]) <= tolerance {
+			f.singular = true
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			lu[i][k] /= lu[k][k]
+			for j := k + 1; j < n; j++ {
+				lu[i][j] -= lu[i][k] * lu[k][j]
+			}
+		}
+	}
+	return f, nil
+}
+
+// Singular reports whether the matrix has no inverse
+func (f *LU) Singular() bool {
+	return f.singular
+}
+
+// Determinant returns the determinant of the matrix
+func (f *LU) Determinant() (float64, error) {
+	if f.singular {
+		return 0, nil
+	}
+	det := f.sign
+	for i := range f.lu {
+		det *= f.lu[i][i]
+	}
+	return checkFinite("determinant", det)
+}
+
+// Solve returns x such that A x = b
+func (f *LU) Solve(b Vector) (Vector, error) {
+	n := len(f.lu)
+	if len(b) != n {
+		return nil, dimensionError("solve", "b has %d elements but the matrix has %d rows", len(b), n)
+	}
+	if f.singular {
+		return nil, singularError("solve")
+	}
+	return finiteVector("solve", f.solve(b))
+}
+
+// solve substitutes forward through L and back through U
+func (f *LU) solve(b Vector) Vector {
+	x := make(Vector, len(f.lu))
+	for i, row := range f.lu {
+		x[i] = b[f.pivot[i]]
+		for j := 0; j < i; j++ {
+			x[i] -= row[j] * x[j]
+		}
+	}
+	for i := len(f.lu) - 1; i >= 0; i-- {
+		row := f.lu[i]
+		for j := i + 1; j < len(row); j++ {
+			x[i] -= row[j] * x[j]
+		}
+		x[i] /= row[i]
+	}
+	return x
+}
+
+// Inverse returns the inverse of the matrix, solving for each column of
+// the identity
+func (f *LU) Inverse() (Matrix, error) {
+	if f.singular {
+		return nil, singularError("inverse")
+	}
+	n := len(f.lu)
+	inverse := newMatrix(n, n)
+	unit := make(Vector, n)
+	for j := 0; j < n; j++ {
+		unit[j] = 1
+		for i, x := range f.solve(unit) {
+			inverse[i][j] = x
+		}
+		unit[j] = 0
+	}
+	return finiteMatrix("inverse", inverse)
+}
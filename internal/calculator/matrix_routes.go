@@ -0,0 +1,234 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// MatrixRequest is the operand of a single matrix route
+type MatrixRequest struct {
+	A Matrix `json:"a"`
+}
+
+// MatrixPairRequest holds the operands of a matrix operation such as a
+// product
+type MatrixPairRequest struct {
+	A Matrix `json:"a"`
+	B Matrix `json:"b"`
+}
+
+// SystemRequest is the linear system a x = b
+type SystemRequest struct {
+	A Matrix `json:"a"`
+	B Vector `json:"b"`
+}
+
+// VectorPairRequest holds the operands of a vector product
+type VectorPairRequest struct {
+	U Vector `json:"u"`
+	V Vector `json:"v"`
+}
+
+// NormRequest holds either a matrix or a vector
+type NormRequest struct {
+	A Matrix `json:"a,omitempty"`
+	U Vector `json:"u,omitempty"`
+}
+
+// MatrixResponse represents a matrix result
+type MatrixResponse struct {
+	Result Matrix `json:"result"`
+}
+
+// VectorResponse represents a vector result
+type VectorResponse struct {
+	Result Vector `json:"result"`
+}
+
+// matrixRoute declares a POST matrix route and its documentation
+func matrixRoute(path, name string, handler http.HandlerFunc, summary string, request, response interface{}) common.Route {
+	return common.NamedRoute(path, "POST", name, handler).WithDoc(common.RouteDoc{
+		Summary:     summary,
+		Description: "Matrices are JSON arrays of rows. Square matrices are factored by LU decomposition with partial pivoting; singular matrices have a determinant of 0 and answer 400 when inverted or solved.",
+		Tags:        []string{"calculator"},
+		Request:     request,
+		Response:    response,
+		Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	})
+}
+
+// handleMatrixAdd handles matrix addition
+func (h *Handler) handleMatrixAdd(w http.ResponseWriter, r *http.Request) {
+	var req MatrixPairRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.MatrixAdd(req.A, req.B)
+		h.writeMatrixResult(w, MatrixResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixMultiply handles matrix products
+func (h *Handler) handleMatrixMultiply(w http.ResponseWriter, r *http.Request) {
+	var req MatrixPairRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.MatrixMultiply(req.A, req.B)
+		h.writeMatrixResult(w, MatrixResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixTranspose handles transposition
+func (h *Handler) handleMatrixTranspose(w http.ResponseWriter, r *http.Request) {
+	var req MatrixRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Transpose(req.A)
+		h.writeMatrixResult(w, MatrixResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixDeterminant handles determinants
+func (h *Handler) handleMatrixDeterminant(w http.ResponseWriter, r *http.Request) {
+	var req MatrixRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Determinant(req.A)
+		h.writeMatrixResult(w, Response{Result: result}, err)
+	}
+}
+
+// handleMatrixInverse handles inverses
+func (h *Handler) handleMatrixInverse(w http.ResponseWriter, r *http.Request) {
+	var req MatrixRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Inverse(req.A)
+		h.writeMatrixResult(w, MatrixResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixSolve handles linear systems
+func (h *Handler) handleMatrixSolve(w http.ResponseWriter, r *http.Request) {
+	var req SystemRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Solve(req.A, req.B)
+		h.writeMatrixResult(w, VectorResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixDot handles dot products
+func (h *Handler) handleMatrixDot(w http.ResponseWriter, r *http.Request) {
+	var req VectorPairRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Dot(req.U, req.V)
+		h.writeMatrixResult(w, Response{Result: result}, err)
+	}
+}
+
+// handleMatrixCross handles cross products
+func (h *Handler) handleMatrixCross(w http.ResponseWriter, r *http.Request) {
+	var req VectorPairRequest
+	if h.readMatrixRequest(w, r, &req) {
+		result, err := h.calc.Cross(req.U, req.V)
+		h.writeMatrixResult(w, VectorResponse{Result: result}, err)
+	}
+}
+
+// handleMatrixNorm handles norms
+func (h *Handler) handleMatrixNorm(w http.ResponseWriter, r *http.Request) {
+	var req NormRequest
+	if !h.readMatrixRequest(w, r, &req) {
+		return
+	}
+	ord := r.URL.Query().Get("ord")
+	var (
+		result float64
+		err    error
+	)
+	if req.A != nil {
+		result, err = h.calc.MatrixNorm(req.A, ord)
+	} else {
+		result, err = h.calc.VectorNorm(req.U, ord)
+	}
+	h.writeMatrixResult(w, Response{Result: result}, err)
+}
+
+// matrixOperands is implemented by the requests of matrix routes
+type matrixOperands interface {
+	validate(maxSize int) error
+}
+
+func (req *MatrixRequest) validate(maxSize int) error {
+	return req.A.Validate("a", maxSize)
+}
+
+func (req *MatrixPairRequest) validate(maxSize int) error {
+	if err := req.A.Validate("a", maxSize); err != nil {
+		return err
+	}
+	return req.B.Validate("b", maxSize)
+}
+
+func (req *SystemRequest) validate(maxSize int) error {
+	if err := req.A.Validate("a", maxSize); err != nil {
+		return err
+	}
+	return req.B.Validate("b", maxSize)
+}
+
+func (req *VectorPairRequest) validate(maxSize int) error {
+	if err := req.U.Validate("u", maxSize); err != nil {
+		return err
+	}
+	return req.V.Validate("v", maxSize)
+}
+
+func (req *NormRequest) validate(maxSize int) error {
+	switch {
+	case req.A != nil && req.U != nil:
+		return errors.New("send either a matrix a or a vector u, not both")
+	case req.A != nil:
+		return req.A.Validate("a", maxSize)
+	}
+	return req.U.Validate("u", maxSize)
+}
+
+// maxNumberBytes bounds the JSON text of a matrix element when sizing the
+// body of matrix routes
+const maxNumberBytes = 32
+
+// readMatrixRequest decodes and validates the body of a matrix route. The
+// body is capped from calculator.max_matrix_size, so oversized matrices
+// are rejected before they are held in memory.
+func (h *Handler) readMatrixRequest(w http.ResponseWriter, r *http.Request, req matrixOperands) bool {
+	size := int64(h.cfg.MaxMatrixSize)
+	// Two operands of size x size numbers and their separators
+	r.Body = http.MaxBytesReader(w, r.Body, 2*size*size*(maxNumberBytes+1)+1024)
+	if err := h.ParseJSON(r, req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("%v: the body is over %d bytes", ErrMatrixTooLarge, tooLarge.Limit))
+			return false
+		}
+		h.WriteBadRequest(w, "invalid JSON payload")
+		return false
+	}
+
+	if err := req.validate(h.cfg.MaxMatrixSize); err != nil {
+		if errors.Is(err, ErrMatrixTooLarge) {
+			h.WriteError(w, http.StatusRequestEntityTooLarge, err.Error())
+		} else {
+			h.WriteBadRequest(w, err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+// writeMatrixResult answers a matrix route. Dimension mismatches and
+// singular matrices are client errors.
+func (h *Handler) writeMatrixResult(w http.ResponseWriter, resp interface{}, err error) {
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.WriteSuccess(w, resp)
+}
@@ -0,0 +1,225 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func closeMatrix(got, want Matrix) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !closeVector(got[i], want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func closeVector(got, want Vector) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCalculator_MatrixArithmetic(t *testing.T) {
+	calc := New()
+	a := Matrix{{1, 2, 3}, {4, 5, 6}}
+	b := Matrix{{7, 8}, {9, 10}, {11, 12}}
+
+	if got, err := calc.MatrixAdd(a, a); err != nil || !reflect.DeepEqual(got, Matrix{{2, 4, 6}, {8, 10, 12}}) {
+		t.Errorf("MatrixAdd = %v, %v; want [[2 4 6] [8 10 12]]", got, err)
+	}
+	if got, err := calc.MatrixMultiply(a, b); err != nil || !reflect.DeepEqual(got, Matrix{{58, 64}, {139, 154}}) {
+		t.Errorf("MatrixMultiply = %v, %v; want [[58 64] [139 154]]", got, err)
+	}
+	if got, err := calc.Transpose(a); err != nil || !reflect.DeepEqual(got, Matrix{{1, 4}, {2, 5}, {3, 6}}) {
+		t.Errorf("Transpose = %v, %v; want [[1 4] [2 5] [3 6]]", got, err)
+	}
+
+	if _, err := calc.MatrixAdd(a, b); !errors.Is(err, ErrDimension) {
+		t.Errorf("MatrixAdd of 2x3 and 3x2 error = %v; want ErrDimension", err)
+	}
+	if _, err := calc.MatrixMultiply(a, a); !errors.Is(err, ErrDimension) {
+		t.Errorf("MatrixMultiply of 2x3 and 2x3 error = %v; want ErrDimension", err)
+	}
+	if _, err := calc.MatrixMultiply(Matrix{{1e200}}, Matrix{{1e200}}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MatrixMultiply overflowing error = %v; want ErrOverflow", err)
+	}
+}
+
+func TestCalculator_Determinant(t *testing.T) {
+	tests := []struct {
+		a    Matrix
+		want float64
+	}{
+		{Matrix{{5}}, 5},
+		{Matrix{{4, 7}, {2, 6}}, 10},
+		{Matrix{{0, 1}, {1, 0}}, -1},
+		{Matrix{{2, -3, 1}, {2, 0, -1}, {1, 4, 5}}, 49},
+		{Matrix{{1, 2}, {2, 4}}, 0},
+		{Matrix{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, 0},
+	}
+
+	calc := New()
+	for _, tt := range tests {
+		got, err := calc.Determinant(tt.a)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Determinant(%v) = %v, %v; want %v", tt.a, got, err, tt.want)
+		}
+	}
+	if _, err := calc.Determinant(Matrix{{1, 2}}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Determinant of a 1x2 matrix error = %v; want ErrDimension", err)
+	}
+}
+
+func TestCalculator_InverseAndSolve(t *testing.T) {
+	calc := New()
+	a := Matrix{{4, 7}, {2, 6}}
+
+	inverse, err := calc.Inverse(a)
+	if err != nil || !closeMatrix(inverse, Matrix{{0.6, -0.7}, {-0.2, 0.4}}) {
+		t.Errorf("Inverse = %v, %v; want [[0.6 -0.7] [-0.2 0.4]]", inverse, err)
+	}
+
+	// The first pivot is zero, so solving needs a row exchange
+	x, err := calc.Solve(Matrix{{0, 2, 1}, {1, 1, 1}, {2, 1, 3}}, Vector{7, 6, 13})
+	if err != nil || !closeVector(x, Vector{1, 2, 3}) {
+		t.Errorf("Solve = %v, %v; want [1 2 3]", x, err)
+	}
+
+	for _, singular := range []Matrix{{{1, 2}, {2, 4}}, {{0, 0}, {0, 0}}, {{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}} {
+		if _, err := calc.Inverse(singular); !errors.Is(err, ErrSingular) {
+			t.Errorf("Inverse(%v) error = %v; want ErrSingular", singular, err)
+		}
+		if _, err := calc.Solve(singular, make(Vector, len(singular))); !errors.Is(err, ErrSingular) {
+			t.Errorf("Solve(%v) error = %v; want ErrSingular", singular, err)
+		}
+	}
+	if _, err := calc.Solve(a, Vector{1, 2, 3}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Solve with 3 right-hand sides for 2 rows error = %v; want ErrDimension", err)
+	}
+}
+
+func TestCalculator_Vectors(t *testing.T) {
+	calc := New()
+	u, v := Vector{1, 2, 3}, Vector{4, 5, 6}
+
+	if got, err := calc.Dot(u, v); err != nil || got != 32 {
+		t.Errorf("Dot = %v, %v; want 32", got, err)
+	}
+	if got, err := calc.Cross(u, v); err != nil || !reflect.DeepEqual(got, Vector{-3, 6, -3}) {
+		t.Errorf("Cross = %v, %v; want [-3 6 -3]", got, err)
+	}
+	if _, err := calc.Dot(u, Vector{1}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Dot of 3 and 1 elements error = %v; want ErrDimension", err)
+	}
+	if _, err := calc.Cross(Vector{1, 2}, Vector{3, 4}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Cross of 2-dimensional vectors error = %v; want ErrDimension", err)
+	}
+}
+
+func TestCalculator_Norms(t *testing.T) {
+	calc := New()
+	tests := []struct {
+		ord  string
+		want float64
+	}{
+		{"", 5},
+		{NormL2, 5},
+		{NormL1, 7},
+		{NormInf, 4},
+	}
+	for _, tt := range tests {
+		if got, err := calc.VectorNorm(Vector{3, -4}, tt.ord); err != nil || got != tt.want {
+			t.Errorf("VectorNorm([3 -4], %q) = %v, %v; want %v", tt.ord, got, err, tt.want)
+		}
+	}
+	// Squaring the elements would overflow
+	if got, err := calc.VectorNorm(Vector{3e200, 4e200}, NormL2); err != nil || math.Abs(got-5e200) > 1e186 {
+		t.Errorf("VectorNorm([3e200 4e200]) = %v, %v; want 5e200", got, err)
+	}
+
+	a := Matrix{{1, -2}, {-3, 4}}
+	for ord, want := range map[string]float64{"": math.Sqrt(30), NormFrobenius: math.Sqrt(30), NormL1: 6, NormInf: 7} {
+		if got, err := calc.MatrixNorm(a, ord); err != nil || math.Abs(got-want) > 1e-12 {
+			t.Errorf("MatrixNorm(%v, %q) = %v, %v; want %v", a, ord, got, err, want)
+		}
+	}
+
+	if _, err := calc.VectorNorm(Vector{1}, NormFrobenius); err == nil {
+		t.Error("VectorNorm with the Frobenius norm succeeded; want an error")
+	}
+	if _, err := calc.MatrixNorm(a, NormL2); err == nil {
+		t.Error("MatrixNorm with the 2-norm succeeded; want an error")
+	}
+}
+
+func TestCalculator_RaggedMatrices(t *testing.T) {
+	calc := New()
+	ragged := Matrix{{1, 2}, {3}}
+	long := Matrix{{1}, {2, 3}}
+	ops := map[string]func(m Matrix) error{
+		"MatrixAdd": func(m Matrix) error {
+			_, err := calc.MatrixAdd(m, m)
+			return err
+		},
+		"MatrixMultiply": func(m Matrix) error {
+			_, err := calc.MatrixMultiply(Matrix{{1, 2}}, m)
+			return err
+		},
+		"Transpose": func(m Matrix) error {
+			_, err := calc.Transpose(m)
+			return err
+		},
+		"Determinant": func(m Matrix) error {
+			_, err := calc.Determinant(m)
+			return err
+		},
+		"Inverse": func(m Matrix) error {
+			_, err := calc.Inverse(m)
+			return err
+		},
+		"Solve": func(m Matrix) error {
+			_, err := calc.Solve(m, Vector{1, 2})
+			return err
+		},
+		"MatrixNorm": func(m Matrix) error {
+			_, err := calc.MatrixNorm(m, NormL1)
+			return err
+		},
+	}
+	for name, op := range ops {
+		for _, m := range []Matrix{ragged, long} {
+			if err := op(m); !errors.Is(err, ErrDimension) {
+				t.Errorf("%s(%v) error = %v; want ErrDimension", name, m, err)
+			}
+		}
+	}
+}
+
+func TestMatrix_Validate(t *testing.T) {
+	if err := (Matrix{{1, 2}, {3, 4}}).Validate("a", 2); err != nil {
+		t.Errorf("Validate of a 2x2 matrix returned error: %v", err)
+	}
+	if err := (Matrix{{1, 2}, {3, 4}, {5, 6}}).Validate("a", 2); !errors.Is(err, ErrMatrixTooLarge) {
+		t.Errorf("Validate of a 3x2 matrix with a limit of 2 error = %v; want ErrMatrixTooLarge", err)
+	}
+	for _, m := range []Matrix{nil, {{}}, {{1, 2}, {3}}} {
+		if err := m.Validate("a", 10); err == nil || errors.Is(err, ErrMatrixTooLarge) {
+			t.Errorf("Validate(%v) error = %v; want a shape error", m, err)
+		}
+	}
+	if err := (Vector{1, 2, 3}).Validate("u", 2); !errors.Is(err, ErrMatrixTooLarge) {
+		t.Errorf("Validate of 3 elements with a limit of 2 error = %v; want ErrMatrixTooLarge", err)
+	}
+}
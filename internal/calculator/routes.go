@@ -1,10 +1,19 @@
 package calculator
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"net/http"
+	"strconv"
 
 	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/history"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 )
 
 // Response represents a calculation response
@@ -12,134 +21,620 @@ type Response struct {
 	Result float64 `json:"result"`
 }
 
+// DecimalResponse represents a calculation response in decimal precision
+type DecimalResponse struct {
+	Result   string `json:"result"`
+	Scale    int    `json:"scale"`
+	Rounding string `json:"rounding"`
+}
+
+// EvaluateRequest represents an expression evaluation request
+type EvaluateRequest struct {
+	Expression string `json:"expression"`
+}
+
+// ExpressionErrorResponse reports an invalid expression and the 1-based
+// position of the problem
+type ExpressionErrorResponse struct {
+	Error     string `json:"error"`
+	Position  int    `json:"position"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
 // Handler handles calculator HTTP requests
 type Handler struct {
 	common.BaseHandler
-	calc *Calculator
+	calc     *Calculator
+	cfg      config.CalculatorConfig
+	history  history.Store
+	sessions *Sessions
+	upgrader websocket.Upgrader
 }
 
-// NewHandler creates a new calculator handler
-func NewHandler() *Handler {
+// NewHandler creates a new calculator handler recording the calculations
+// of authenticated users in store and tracking its WebSocket sessions in
+// sessions
+func NewHandler(cfg config.CalculatorConfig, store history.Store, sessions *Sessions) *Handler {
 	return &Handler{
-		calc: New(),
+		calc:     New(),
+		cfg:      cfg,
+		history:  store,
+		sessions: sessions,
 	}
 }
 
-// RegisterRoutes registers calculator routes
+// RegisterRoutes registers calculator routes. The history routes need a
+// Router with JWT authentication configured and are skipped on a plain mux
+// router, where every calculation is anonymous.
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	routes := common.RouteGroup{
+	for _, group := range h.Routes() {
+		anonymous := common.RouteGroup{Prefix: group.Prefix}
+		for _, route := range group.Routes {
+			if route.Auth != common.AuthJWT {
+				anonymous.Routes = append(anonymous.Routes, route)
+			}
+		}
+		common.RegisterGroup(router, anonymous)
+	}
+}
+
+// Routes returns the calculator route groups. Calculations accept an
+// optional token, which gets them recorded in the caller's history.
+func (h *Handler) Routes() []common.RouteGroup {
+	groups := []common.RouteGroup{{
 		Prefix: "",
 		Routes: []common.Route{
-			common.NamedRoute("/add/{a}/{b}", "GET", "calculator.add", h.handleAdd),
-			common.NamedRoute("/subtract/{a}/{b}", "GET", "calculator.subtract", h.handleSubtract),
-			common.NamedRoute("/multiply/{a}/{b}", "GET", "calculator.multiply", h.handleMultiply),
-			common.NamedRoute("/divide/{a}/{b}", "GET", "calculator.divide", h.handleDivide),
+			common.NamedRoute("/add/{a}/{b}", "GET", "calculator.add", h.handleAdd).
+				WithDoc(operationDoc("Add two numbers")),
+			common.NamedRoute("/subtract/{a}/{b}", "GET", "calculator.subtract", h.handleSubtract).
+				WithDoc(operationDoc("Subtract two numbers")),
+			common.NamedRoute("/multiply/{a}/{b}", "GET", "calculator.multiply", h.handleMultiply).
+				WithDoc(operationDoc("Multiply two numbers")),
+			common.NamedRoute("/divide/{a}/{b}", "GET", "calculator.divide", h.handleDivide).
+				WithDoc(operationDoc("Divide two numbers")),
+			common.NamedRoute("/pow/{a}/{b}", "GET", "calculator.pow", h.handlePow).
+				WithDoc(operationDoc("Raise a number to a power")),
+			common.NamedRoute("/mod/{a}/{b}", "GET", "calculator.mod", h.handleMod).
+				WithDoc(operationDoc("Remainder of a division")),
+			common.NamedRoute("/nthroot/{x}/{n}", "GET", "calculator.nthroot", h.handleNthRoot).
+				WithDoc(functionDoc("N-th root of a number", "x", "n")),
+			common.NamedRoute("/sqrt/{x}", "GET", "calculator.sqrt", h.handleSqrt).
+				WithDoc(functionDoc("Square root of a number", "x")),
+			common.NamedRoute("/abs/{x}", "GET", "calculator.abs", h.handleAbs).
+				WithDoc(functionDoc("Absolute value of a number", "x")),
+			common.NamedRoute("/log/{x}", "GET", "calculator.log", h.handleLog).
+				WithDoc(functionDoc("Base 10 logarithm of a number", "x")),
+			common.NamedRoute("/ln/{x}", "GET", "calculator.ln", h.handleLn).
+				WithDoc(functionDoc("Natural logarithm of a number", "x")),
+			common.NamedRoute("/exp/{x}", "GET", "calculator.exp", h.handleExp).
+				WithDoc(functionDoc("e raised to a power", "x")),
+			common.NamedRoute("/factorial/{n}", "GET", "calculator.factorial", h.handleFactorial).
+				WithDoc(functionDoc("Factorial of a whole number", "n")),
+			common.NamedRoute("/sin/{x}", "GET", "calculator.sin", h.handleSin).
+				WithDoc(functionDoc("Sine of an angle in radians", "x")),
+			common.NamedRoute("/cos/{x}", "GET", "calculator.cos", h.handleCos).
+				WithDoc(functionDoc("Cosine of an angle in radians", "x")),
+			common.NamedRoute("/tan/{x}", "GET", "calculator.tan", h.handleTan).
+				WithDoc(functionDoc("Tangent of an angle in radians", "x")),
+			common.NamedRoute("/asin/{x}", "GET", "calculator.asin", h.handleAsin).
+				WithDoc(functionDoc("Arcsine in radians", "x")),
+			common.NamedRoute("/acos/{x}", "GET", "calculator.acos", h.handleAcos).
+				WithDoc(functionDoc("Arccosine in radians", "x")),
+			common.NamedRoute("/atan/{x}", "GET", "calculator.atan", h.handleAtan).
+				WithDoc(functionDoc("Arctangent in radians", "x")),
 		},
+	}, {
+		Prefix: "/calculator",
+		Routes: []common.Route{
+			common.NamedRoute("/evaluate", "POST", "calculator.evaluate", h.handleEvaluate).WithDoc(common.RouteDoc{
+				Summary:     "Evaluate an arithmetic expression",
+				Description: "Evaluates an expression with + - * / % ^ (right-associative), parentheses, unary minus, the functions abs, ceil, floor, round, sqrt, nthroot, pow, mod, factorial, exp, ln, log, sin, cos, tan, asin, acos, atan, min and max and the constants pi, e, tau and phi. Authenticated users can assign the result to a variable, as in x = 2 + 3, and reference their variables and the previous result, ans, in later expressions. Syntax and evaluation errors report the 1-based position of the problem. " + precisionDoc,
+				Tags:        []string{"calculator"},
+				Query:       precisionParams,
+				Request:     EvaluateRequest{},
+				Response:    Response{},
+				Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusInternalServerError},
+			}),
+			common.NamedRoute("/stats", "POST", "calculator.stats", h.handleStats).WithDoc(common.RouteDoc{
+				Summary:     "Describe a series of numbers",
+				Description: "Returns count, sum, mean, median, mode, variance, standard deviation, min, max, percentiles and a histogram of a series sent as a JSON array, CSV or NDJSON, either as the body or as the file part of a multipart upload. Series longer than 10000 values are summarized in bounded memory: quantiles, modes and the histogram are then estimates and approximate is true.",
+				Tags:        []string{"calculator"},
+				Query:       map[string]string{"percentiles": "string", "bins": "integer", "column": "string"},
+				Request:     []float64{},
+				Response:    Summary{},
+				Errors:      []int{http.StatusBadRequest, http.StatusUnsupportedMediaType},
+			}),
+			common.NamedRoute("/batch", "POST", "calculator.batch", h.handleBatch).WithDoc(common.RouteDoc{
+				Summary:     "Run many calculations at once",
+				Description: "Runs an array of {op, operands} items, where op is add, subtract, multiply, divide or any expression function such as pow, sqrt or max. Results and errors come back in the order of the items, and a failing item such as a division by zero does not fail the batch. An item takes at most 100 operands. Large batches are computed in parallel. " + precisionDoc,
+				Tags:        []string{"calculator"},
+				Query:       precisionParams,
+				Request:     []BatchItem{},
+				Response:    []BatchResult{},
+				Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+			}),
+			matrixRoute("/matrix/add", "calculator.matrix.add", h.handleMatrixAdd, "Add two matrices",
+				MatrixPairRequest{}, MatrixResponse{}),
+			matrixRoute("/matrix/multiply", "calculator.matrix.multiply", h.handleMatrixMultiply, "Multiply two matrices",
+				MatrixPairRequest{}, MatrixResponse{}),
+			matrixRoute("/matrix/transpose", "calculator.matrix.transpose", h.handleMatrixTranspose, "Transpose a matrix",
+				MatrixRequest{}, MatrixResponse{}),
+			matrixRoute("/matrix/determinant", "calculator.matrix.determinant", h.handleMatrixDeterminant, "Determinant of a square matrix",
+				MatrixRequest{}, Response{}),
+			matrixRoute("/matrix/inverse", "calculator.matrix.inverse", h.handleMatrixInverse, "Inverse of a square matrix",
+				MatrixRequest{}, MatrixResponse{}),
+			matrixRoute("/matrix/solve", "calculator.matrix.solve", h.handleMatrixSolve, "Solve a linear system",
+				SystemRequest{}, VectorResponse{}),
+			matrixRoute("/matrix/dot", "calculator.matrix.dot", h.handleMatrixDot, "Dot product of two vectors",
+				VectorPairRequest{}, Response{}),
+			matrixRoute("/matrix/cross", "calculator.matrix.cross", h.handleMatrixCross, "Cross product of two 3-dimensional vectors",
+				VectorPairRequest{}, VectorResponse{}),
+			common.NamedRoute("/matrix/norm", "POST", "calculator.matrix.norm", h.handleMatrixNorm).WithDoc(common.RouteDoc{
+				Summary:     "Norm of a vector or matrix",
+				Description: "Computes the norm of the vector u or of the matrix a. Vectors have the 1, 2 (Euclidean, default) and inf norms; matrices the fro (Frobenius, default), 1 (largest column sum) and inf (largest row sum) norms.",
+				Tags:        []string{"calculator"},
+				Query:       map[string]string{"ord": "string"},
+				Request:     NormRequest{},
+				Response:    Response{},
+				Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+			}),
+			complexRoute("/complex/add/{a}/{b}", "calculator.complex.add", h.handleComplexAdd, "Add two complex numbers",
+				ComplexResponse{}, "a", "b"),
+			complexRoute("/complex/subtract/{a}/{b}", "calculator.complex.subtract", h.handleComplexSubtract, "Subtract two complex numbers",
+				ComplexResponse{}, "a", "b"),
+			complexRoute("/complex/multiply/{a}/{b}", "calculator.complex.multiply", h.handleComplexMultiply, "Multiply two complex numbers",
+				ComplexResponse{}, "a", "b"),
+			complexRoute("/complex/divide/{a}/{b}", "calculator.complex.divide", h.handleComplexDivide, "Divide two complex numbers",
+				ComplexResponse{}, "a", "b"),
+			complexRoute("/complex/modulus/{z}", "calculator.complex.modulus", h.handleComplexModulus, "Modulus of a complex number",
+				Response{}, "z"),
+			complexRoute("/complex/argument/{z}", "calculator.complex.argument", h.handleComplexArgument, "Argument of a complex number",
+				Response{}, "z"),
+			complexRoute("/complex/conjugate/{z}", "calculator.complex.conjugate", h.handleComplexConjugate, "Conjugate of a complex number",
+				ComplexResponse{}, "z"),
+			complexRoute("/complex/polar/{z}", "calculator.complex.polar", h.handleComplexPolar, "Polar form of a complex number",
+				PolarResponse{}, "z"),
+			common.NamedRoute("/complex/rect/{r}/{theta}", "GET", "calculator.complex.rect", h.handleComplexRect).WithDoc(common.RouteDoc{
+				Summary:     "Complex number from its polar form",
+				Description: "Converts a modulus r and an argument theta in radians to rectangular form. " + complexResultDoc,
+				Tags:        []string{"calculator"},
+				Params:      map[string]string{"r": "number", "theta": "number"},
+				Query:       complexParams,
+				Response:    ComplexResponse{},
+				Errors:      []int{http.StatusBadRequest},
+			}),
+			common.NamedRoute("/ws", "GET", "calculator.ws", h.handleSession).
+				WithAuth(common.AuthNone).
+				WithDoc(common.RouteDoc{
+					Summary:     "Interactive calculator session",
+					Description: "Upgrades to a WebSocket for a REPL-style session. Each text message is an expression, or a {id, expression} object, answered with {id, result, variable} or {id, error, position}. Variables assigned with x = expr, and the previous result as ans, last for the connection. The server pings every calculator.ws_ping_interval and closes connections missing two pongs; messages above calculator.ws_message_rate per second are answered with an error.",
+					Tags:        []string{"calculator"},
+					Status:      http.StatusSwitchingProtocols,
+					Errors:      []int{http.StatusBadRequest},
+					Raw:         true,
+				}),
+			common.NamedRoute("/history", "GET", "calculator.history.list", h.handleListHistory).
+				WithAuth(common.AuthJWT).
+				WithDoc(common.RouteDoc{
+					Summary:     "List your calculations",
+					Description: "Returns the calculations recorded for the authenticated user, newest first: the operation, its inputs, the result, the variable it was assigned to and when it ran. Only the newest calculator.history_limit calculations are kept.",
+					Tags:        []string{"calculator"},
+					Query:       map[string]string{"limit": "integer", "offset": "integer"},
+					Response:    history.Page{},
+					Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+				}),
+			common.NamedRoute("/history", "DELETE", "calculator.history.clear", h.handleClearHistory).
+				WithAuth(common.AuthJWT).
+				WithDoc(common.RouteDoc{
+					Summary:     "Clear your calculations",
+					Description: "Deletes every calculation recorded for the authenticated user, along with ans and the variables they set",
+					Tags:        []string{"calculator"},
+					Status:      http.StatusNoContent,
+					Errors:      []int{http.StatusInternalServerError},
+					Raw:         true,
+				}),
+		},
+	}}
+
+	for _, group := range groups {
+		for i, route := range group.Routes {
+			if route.Auth == "" {
+				group.Routes[i] = route.WithAuth(common.AuthOptionalJWT)
+			}
+		}
+	}
+	return groups
+}
+
+// precisionParams are the query parameters selecting decimal precision,
+// described by precisionDoc
+var precisionParams = map[string]string{"precision": "string", "scale": "integer", "rounding": "string"}
+
+// precisionDoc describes precisionParams in the documentation of the routes
+// taking them
+const precisionDoc = "With precision=decimal the result is an exact decimal string (DecimalResponse) rounded to scale digits, 28 by default, using rounding: half_even (default), half_up, half_down, up, down, ceiling or floor."
+
+// operationDoc documents a two operand calculator route
+func operationDoc(summary string) common.RouteDoc {
+	return functionDoc(summary, "a", "b")
+}
+
+// functionDoc documents a calculator route with numeric path parameters
+func functionDoc(summary string, params ...string) common.RouteDoc {
+	types := make(map[string]string, len(params))
+	for _, param := range params {
+		types[param] = "number"
+	}
+	return common.RouteDoc{
+		Summary:     summary,
+		Description: precisionDoc,
+		Tags:        []string{"calculator"},
+		Params:      types,
+		Query:       precisionParams,
+		Response:    Response{},
+		Errors:      []int{http.StatusBadRequest},
 	}
-	common.RegisterGroup(router, routes)
 }
 
 // handleAdd handles addition
-// @Summary Add two numbers
-// @Description Performs addition of two floating-point numbers
-// @Tags calculator
-// @Accept json
-// @Produce json
-// @Param a path number true "First number"
-// @Param b path number true "Second number"
-// @Success 200 {object} Response
-// @Failure 400 {object} common.ErrorResponse
-// @Router /add/{a}/{b} [get]
 func (h *Handler) handleAdd(w http.ResponseWriter, r *http.Request) {
-	a, b, err := h.getNumbers(r)
+	h.calculate(w, r, "add", func(a, b float64) (float64, error) {
+		return h.calc.Add(a, b), nil
+	}, func(a, b *big.Rat) (*big.Rat, error) {
+		return h.calc.AddDecimal(a, b), nil
+	})
+}
+
+// handleSubtract handles subtraction
+func (h *Handler) handleSubtract(w http.ResponseWriter, r *http.Request) {
+	h.calculate(w, r, "subtract", func(a, b float64) (float64, error) {
+		return h.calc.Subtract(a, b), nil
+	}, func(a, b *big.Rat) (*big.Rat, error) {
+		return h.calc.SubtractDecimal(a, b), nil
+	})
+}
+
+// handleMultiply handles multiplication
+func (h *Handler) handleMultiply(w http.ResponseWriter, r *http.Request) {
+	h.calculate(w, r, "multiply", func(a, b float64) (float64, error) {
+		return h.calc.Multiply(a, b), nil
+	}, func(a, b *big.Rat) (*big.Rat, error) {
+		return h.calc.MultiplyDecimal(a, b), nil
+	})
+}
+
+// handleDivide handles division
+func (h *Handler) handleDivide(w http.ResponseWriter, r *http.Request) {
+	h.calculate(w, r, "divide", h.calc.Divide, h.calc.DivideDecimal)
+}
+
+// handlePow handles pow
+func (h *Handler) handlePow(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "pow", "a", "b")
+}
+
+// handleMod handles mod
+func (h *Handler) handleMod(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "mod", "a", "b")
+}
+
+// handleNthRoot handles nthroot
+func (h *Handler) handleNthRoot(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "nthroot", "x", "n")
+}
+
+// handleSqrt handles sqrt
+func (h *Handler) handleSqrt(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "sqrt", "x")
+}
+
+// handleAbs handles abs
+func (h *Handler) handleAbs(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "abs", "x")
+}
+
+// handleLog handles log
+func (h *Handler) handleLog(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "log", "x")
+}
+
+// handleLn handles ln
+func (h *Handler) handleLn(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "ln", "x")
+}
+
+// handleExp handles exp
+func (h *Handler) handleExp(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "exp", "x")
+}
+
+// handleFactorial handles factorial
+func (h *Handler) handleFactorial(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "factorial", "n")
+}
+
+// handleSin handles sin
+func (h *Handler) handleSin(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "sin", "x")
+}
+
+// handleCos handles cos
+func (h *Handler) handleCos(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "cos", "x")
+}
+
+// handleTan handles tan
+func (h *Handler) handleTan(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "tan", "x")
+}
+
+// handleAsin handles asin
+func (h *Handler) handleAsin(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "asin", "x")
+}
+
+// handleAcos handles acos
+func (h *Handler) handleAcos(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "acos", "x")
+}
+
+// handleAtan handles atan
+func (h *Handler) handleAtan(w http.ResponseWriter, r *http.Request) {
+	h.applyFunction(w, r, "atan", "x")
+}
+
+// maxEvaluateBodyBytes bounds the body of expression evaluation: the
+// longest expression the parser accepts and the JSON around it
+const maxEvaluateBodyBytes = DefaultMaxExpressionLength + 1024
+
+// handleEvaluate handles expression evaluation
+func (h *Handler) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	var req EvaluateRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxEvaluateBodyBytes)
+	if err := h.ParseJSON(r, &req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("expression too long: the body is over %d bytes", tooLarge.Limit))
+			return
+		}
+		h.WriteBadRequest(w, "invalid JSON payload")
+		return
+	}
+
+	p, decimal, err := precisionQuery(r)
 	if err != nil {
 		h.WriteBadRequest(w, err.Error())
 		return
 	}
-	result := h.calc.Add(a, b)
+	stmt, err := ParseStatement(req.Expression, Limits{})
+	if err != nil {
+		writeExpressionError(w, err)
+		return
+	}
+	vars, err := h.variables(r)
+	if err != nil {
+		h.WriteError(w, http.StatusInternalServerError, "failed to load calculation history")
+		return
+	}
+	entry := history.Entry{Operation: "evaluate", Inputs: []string{req.Expression}, Variable: stmt.Name}
+
+	if decimal {
+		result, err := EvalDecimal(stmt.Expr, decimalVariables(vars), p)
+		if err != nil {
+			writeExpressionError(w, err)
+			return
+		}
+		entry.Result = json.Number(p.Format(result))
+		h.record(r, entry)
+		h.WriteSuccess(w, decimalResponse(result, p))
+		return
+	}
+
+	result, err := Eval(stmt.Expr, floatVariables(vars))
+	if err != nil {
+		writeExpressionError(w, err)
+		return
+	}
+	entry.Result = floatNumber(result)
+	h.record(r, entry)
 	h.WriteSuccess(w, Response{Result: result})
 }
 
-// handleSubtract handles subtraction
-// @Summary Subtract two numbers
-// @Description Performs subtraction of two floating-point numbers (a - b)
-// @Tags calculator
-// @Accept json
-// @Produce json
-// @Param a path number true "First number (minuend)"
-// @Param b path number true "Second number (subtrahend)"
-// @Success 200 {object} Response
-// @Failure 400 {object} common.ErrorResponse
-// @Router /subtract/{a}/{b} [get]
-func (h *Handler) handleSubtract(w http.ResponseWriter, r *http.Request) {
-	a, b, err := h.getNumbers(r)
+// writeExpressionError writes an expression error with its position
+func writeExpressionError(w http.ResponseWriter, err error) {
+	var exprErr *ExprError
+	if !errors.As(err, &exprErr) {
+		common.WriteBadRequest(w, err.Error())
+		return
+	}
+	common.WriteJSON(w, http.StatusBadRequest, ExpressionErrorResponse{
+		Error:     exprErr.Msg,
+		Position:  exprErr.Pos,
+		RequestID: w.Header().Get(common.HeaderRequestID),
+	})
+}
+
+// calculate answers a two operand route in float64 or, with
+// ?precision=decimal, with exact decimals
+func (h *Handler) calculate(w http.ResponseWriter, r *http.Request, op string,
+	float func(a, b float64) (float64, error), decimal func(a, b *big.Rat) (*big.Rat, error)) {
+	p, exact, err := precisionQuery(r)
 	if err != nil {
 		h.WriteBadRequest(w, err.Error())
 		return
 	}
-	result := h.calc.Subtract(a, b)
-	h.WriteSuccess(w, Response{Result: result})
-}
 
-// handleMultiply handles multiplication
-// @Summary Multiply two numbers
-// @Description Performs multiplication of two floating-point numbers
-// @Tags calculator
-// @Accept json
-// @Produce json
-// @Param a path number true "First number"
-// @Param b path number true "Second number"
-// @Success 200 {object} Response
-// @Failure 400 {object} common.ErrorResponse
-// @Router /multiply/{a}/{b} [get]
-func (h *Handler) handleMultiply(w http.ResponseWriter, r *http.Request) {
+	if exact {
+		args, err := h.getDecimals(r, "a", "b")
+		if err != nil {
+			h.WriteBadRequest(w, err.Error())
+			return
+		}
+		result, err := decimal(args[0], args[1])
+		if err != nil {
+			h.WriteBadRequest(w, err.Error())
+			return
+		}
+		h.recordOperation(r, op, json.Number(p.Format(result)), "a", "b")
+		h.WriteSuccess(w, decimalResponse(result, p))
+		return
+	}
+
 	a, b, err := h.getNumbers(r)
 	if err != nil {
 		h.WriteBadRequest(w, err.Error())
 		return
 	}
-	result := h.calc.Multiply(a, b)
+	result, err := float(a, b)
+	if err == nil {
+		result, err = checkFinite(op, result)
+	}
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.recordOperation(r, op, floatNumber(result), "a", "b")
 	h.WriteSuccess(w, Response{Result: result})
 }
 
-// handleDivide handles division
-// @Summary Divide two numbers
-// @Description Performs division of two floating-point numbers (a / b)
-// @Tags calculator
-// @Accept json
-// @Produce json
-// @Param a path number true "Dividend"
-// @Param b path number true "Divisor (cannot be zero)"
-// @Success 200 {object} Response
-// @Failure 400 {object} common.ErrorResponse
-// @Router /divide/{a}/{b} [get]
-func (h *Handler) handleDivide(w http.ResponseWriter, r *http.Request) {
-	a, b, err := h.getNumbers(r)
+// applyFunction answers a route computing an expression function of its
+// path parameters in float64 or, with ?precision=decimal, exactly
+func (h *Handler) applyFunction(w http.ResponseWriter, r *http.Request, name string, params ...string) {
+	fn := functions[name]
+	p, exact, err := precisionQuery(r)
 	if err != nil {
 		h.WriteBadRequest(w, err.Error())
 		return
 	}
-	result, err := h.calc.Divide(a, b)
+
+	if exact {
+		args, err := h.getDecimals(r, params...)
+		if err != nil {
+			h.WriteBadRequest(w, err.Error())
+			return
+		}
+		result, err := fn.exact(args, precisionBits(p.Scale))
+		if err == nil && !fits(result) {
+			err = precisionError(name)
+		}
+		if err != nil {
+			h.WriteBadRequest(w, err.Error())
+			return
+		}
+		h.recordOperation(r, name, json.Number(p.Format(result)), params...)
+		h.WriteSuccess(w, decimalResponse(result, p))
+		return
+	}
+
+	args := make([]float64, len(params))
+	values := h.GetURLParams(r)
+	for i, param := range params {
+		if args[i], err = finiteParam(values, param); err != nil {
+			h.WriteBadRequest(w, err.Error())
+			return
+		}
+	}
+	result, err := fn.eval(args)
+	if err == nil {
+		result, err = checkFinite(name, result)
+	}
 	if err != nil {
 		h.WriteBadRequest(w, err.Error())
 		return
 	}
+	h.recordOperation(r, name, floatNumber(result), params...)
 	h.WriteSuccess(w, Response{Result: result})
 }
 
+// recordOperation records a path parameter route, with the parameters as
+// written in the URL as its inputs
+func (h *Handler) recordOperation(r *http.Request, op string, result json.Number, params ...string) {
+	values := h.GetURLParams(r)
+	inputs := make([]string, len(params))
+	for i, param := range params {
+		inputs[i], _ = values.String(param)
+	}
+	h.record(r, history.Entry{Operation: op, Inputs: inputs, Result: result})
+}
+
+// precisionQuery reads the precision, scale and rounding query parameters.
+// decimal is false for the default float64 precision.
+func precisionQuery(r *http.Request) (p Precision, decimal bool, err error) {
+	query := r.URL.Query()
+	switch query.Get("precision") {
+	case "", "float":
+		return Precision{}, false, nil
+	case "decimal":
+	default:
+		return Precision{}, false, fmt.Errorf("unknown precision %q, use float or decimal", query.Get("precision"))
+	}
+
+	p = DefaultPrecision
+	if scale := query.Get("scale"); scale != "" {
+		if p.Scale, err = strconv.Atoi(scale); err != nil {
+			return Precision{}, false, fmt.Errorf("invalid scale %q", scale)
+		}
+	}
+	if rounding := query.Get("rounding"); rounding != "" {
+		if p.Rounding, err = ParseRoundingMode(rounding); err != nil {
+			return Precision{}, false, err
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return Precision{}, false, err
+	}
+	return p, true, nil
+}
+
+// decimalResponse formats a decimal result
+func decimalResponse(result *big.Rat, p Precision) DecimalResponse {
+	return DecimalResponse{Result: p.Format(result), Scale: p.Scale, Rounding: p.Rounding.String()}
+}
+
+// getDecimals extracts exact decimal numbers from URL parameters
+func (h *Handler) getDecimals(r *http.Request, params ...string) ([]*big.Rat, error) {
+	values := h.GetURLParams(r)
+	args := make([]*big.Rat, len(params))
+	for i, param := range params {
+		value, ok := values.String(param)
+		if !ok {
+			return nil, common.ErrParameterNotFound
+		}
+		x, err := ParseDecimal(value)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = x
+	}
+	return args, nil
+}
+
 // getNumbers extracts numbers from URL parameters
 func (h *Handler) getNumbers(r *http.Request) (float64, float64, error) {
 	params := h.GetURLParams(r)
 
-	a, err := params.Float64("a")
+	a, err := finiteParam(params, "a")
 	if err != nil {
 		return 0, 0, err
 	}
 
-	b, err := params.Float64("b")
+	b, err := finiteParam(params, "b")
 	if err != nil {
 		return 0, 0, err
 	}
 
 	return a, b, nil
 }
+
+// finiteParam extracts a finite number from a URL parameter. ParseFloat
+// accepts NaN and Inf, which have no result that can be written as JSON.
+func finiteParam(params *common.URLParams, key string) (float64, error) {
+	value, err := params.Float64(key)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%s must be a finite number", key)
+	}
+	return value, nil
+}
@@ -0,0 +1,209 @@
+package calculator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MaxSessionVariables bounds the variables a session may assign
+const MaxSessionVariables = 1000
+
+// Session evaluates the statements of an interactive session. It keeps the
+// variables assigned during the session and the previous result as ans.
+type Session struct {
+	vars map[string]float64
+}
+
+// NewSession creates a session without variables
+func NewSession() *Session {
+	return &Session{vars: make(map[string]float64)}
+}
+
+// Evaluate runs a statement such as 2 * ans or x = sqrt(2), returning its
+// result and the variable it was assigned to, if any
+func (s *Session) Evaluate(src string, limits Limits) (result float64, name string, err error) {
+	stmt, err := ParseStatement(src, limits)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, ok := s.vars[stmt.Name]; stmt.Name != "" && stmt.Name != Ans && !ok && s.named() >= MaxSessionVariables {
+		return 0, "", fmt.Errorf("a session holds at most %d variables", MaxSessionVariables)
+	}
+	if result, err = Eval(stmt.Expr, s.vars); err != nil {
+		return 0, "", err
+	}
+	s.vars[Ans] = result
+	if stmt.Name != "" {
+		s.vars[stmt.Name] = result
+	}
+	return result, stmt.Name, nil
+}
+
+// named returns the number of variables assigned by name, which leaves out
+// ans
+func (s *Session) named() int {
+	if _, ok := s.vars[Ans]; ok {
+		return len(s.vars) - 1
+	}
+	return len(s.vars)
+}
+
+// SessionRequest is a message sent on /calculator/ws. Text messages that
+// are not JSON objects are taken as the expression itself.
+type SessionRequest struct {
+	// ID is echoed in the response to match it with the request
+	ID         string `json:"id,omitempty"`
+	Expression string `json:"expression"`
+}
+
+// SessionResponse answers a SessionRequest with its result or, on failure,
+// its error and the 1-based position of expression errors
+type SessionResponse struct {
+	ID       string   `json:"id,omitempty"`
+	Result   *float64 `json:"result,omitempty"`
+	Variable string   `json:"variable,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Position int      `json:"position,omitempty"`
+}
+
+// ParseSessionRequest reads a message sent on /calculator/ws
+func ParseSessionRequest(data []byte) (SessionRequest, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return SessionRequest{Expression: string(data)}, nil
+	}
+	var req SessionRequest
+	if err := json.Unmarshal(trimmed, &req); err != nil {
+		return SessionRequest{}, errors.New("invalid JSON message")
+	}
+	return req, nil
+}
+
+// Answer evaluates the expression of req
+func (s *Session) Answer(req SessionRequest, limits Limits) SessionResponse {
+	resp := SessionResponse{ID: req.ID}
+	result, name, err := s.Evaluate(req.Expression, limits)
+	if err != nil {
+		var exprErr *ExprError
+		if errors.As(err, &exprErr) {
+			resp.Error, resp.Position = exprErr.Msg, exprErr.Pos
+		} else {
+			resp.Error = err.Error()
+		}
+		return resp
+	}
+	resp.Result, resp.Variable = &result, name
+	return resp
+}
+
+// Variables returns a copy of the session variables, with ans once a
+// statement succeeded
+func (s *Session) Variables() map[string]float64 {
+	vars := make(map[string]float64, len(s.vars))
+	for name, value := range s.vars {
+		vars[name] = value
+	}
+	return vars
+}
+
+// sessionCloseTimeout bounds the wait for clients to answer the close
+// message sent on shutdown before their connections are dropped
+const sessionCloseTimeout = 5 * time.Second
+
+// Sessions tracks the open /calculator/ws connections. http.Server.Shutdown
+// leaves these hijacked connections open, so Shutdown closes them.
+type Sessions struct {
+	mu      sync.Mutex
+	conns   map[*websocket.Conn]struct{}
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// NewSessions creates an empty set of sessions
+func NewSessions() *Sessions {
+	return &Sessions{conns: make(map[*websocket.Conn]struct{})}
+}
+
+// Len returns the number of open sessions
+func (s *Sessions) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// add tracks conn until remove. It reports false once Shutdown started.
+func (s *Sessions) add(conn *websocket.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+// remove closes conn and stops tracking it
+func (s *Sessions) remove(conn *websocket.Conn) {
+	_ = conn.Close()
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+// Shutdown refuses new sessions and asks the open ones to close with a
+// going away close message. Connections still open after a few seconds, or
+// when ctx expires, are dropped.
+func (s *Sessions) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	conns := make([]*websocket.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		closeSession(conn, websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(sessionCloseTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	// The sessions end as soon as their connection fails
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("websocket sessions did not close: %w", ctx.Err())
+	}
+}
+
+// closeSession sends a close message, which the client answers before the
+// connection ends
+func closeSession(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(sessionWriteTimeout))
+}
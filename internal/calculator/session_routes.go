@@ -0,0 +1,119 @@
+package calculator
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/logging"
+	"github.com/example/go-template/internal/ratelimit"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket session timeouts
+const (
+	// sessionWriteTimeout bounds writing a message to a slow client
+	sessionWriteTimeout = 10 * time.Second
+	// maxSessionMessageBytes bounds a message sent by the client
+	maxSessionMessageBytes = DefaultMaxExpressionLength + 1024
+)
+
+// handleSession handles interactive sessions
+func (h *Handler) handleSession(w http.ResponseWriter, r *http.Request) {
+	// Upgrade answers failed handshakes itself and writes the handshake on
+	// the hijacked connection, with the headers set by the middleware
+	conn, err := h.upgrader.Upgrade(w, r, w.Header())
+	if err != nil {
+		return
+	}
+	if !h.sessions.add(conn) {
+		closeSession(conn, websocket.CloseGoingAway, "server shutting down")
+		_ = conn.Close()
+		return
+	}
+	defer h.sessions.remove(conn)
+
+	// A client missing two pings in a row is gone
+	pongWait := 2 * h.cfg.WSPingInterval
+	conn.SetReadLimit(maxSessionMessageBytes)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	responses := make(chan SessionResponse, 16)
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		h.writeSession(conn, responses)
+	}()
+
+	// Each connection has its own bucket, dropped with the connection
+	limiter := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{
+		Algorithm: config.AlgorithmTokenBucket,
+		Limit:     h.cfg.WSMessageRate,
+		Period:    time.Second,
+		Burst:     h.cfg.WSMessageBurst,
+	}
+	session := NewSession()
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				logging.FromContext(r.Context()).Debug("websocket session ended", "error", err)
+			}
+			break
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		decision, _ := limiter.Allow(r.Context(), "messages", limit, time.Now())
+		switch {
+		case !decision.Allowed:
+			responses <- SessionResponse{Error: fmt.Sprintf("rate limit exceeded, retry in %s", decision.RetryAfter.Round(time.Millisecond))}
+		case kind != websocket.TextMessage:
+			responses <- SessionResponse{Error: "send expressions as text messages"}
+		default:
+			req, err := ParseSessionRequest(data)
+			if err != nil {
+				responses <- SessionResponse{Error: err.Error()}
+				continue
+			}
+			responses <- session.Answer(req, Limits{})
+		}
+	}
+	close(responses)
+	<-written
+}
+
+// writeSession writes the responses of a session and pings the client
+// until responses is closed. Writes only happen here, as a WebSocket
+// connection supports one concurrent writer.
+func (h *Handler) writeSession(conn *websocket.Conn, responses <-chan SessionResponse) {
+	ticker := time.NewTicker(h.cfg.WSPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case resp, ok := <-responses:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(sessionWriteTimeout))
+			if err := conn.WriteJSON(resp); err != nil {
+				// Closing ends the read loop; drain its last responses
+				_ = conn.Close()
+				for range responses {
+				}
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(sessionWriteTimeout)); err != nil {
+				_ = conn.Close()
+				for range responses {
+				}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/history"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+func TestSession_Evaluate(t *testing.T) {
+	s := NewSession()
+	steps := []struct {
+		src  string
+		want float64
+		name string
+	}{
+		{"x = 2 + 3", 5, "x"},
+		{"ans * 2", 10, ""},
+		{"y = ans + x", 15, "y"},
+		{"x * y", 75, ""},
+	}
+	for _, step := range steps {
+		got, name, err := s.Evaluate(step.src, Limits{})
+		if err != nil || got != step.want || name != step.name {
+			t.Errorf("Evaluate(%q) = %v, %q, %v; want %v, %q", step.src, got, name, err, step.want, step.name)
+		}
+	}
+	if vars := s.Variables(); vars["x"] != 5 || vars["y"] != 15 || vars[Ans] != 75 {
+		t.Errorf("Variables() = %v; want x=5 y=15 ans=75", vars)
+	}
+
+	// A failed statement keeps the previous result and variables
+	var exprErr *ExprError
+	if _, _, err := s.Evaluate("x = 1 / 0", Limits{}); !errors.As(err, &exprErr) {
+		t.Errorf("Evaluate(x = 1 / 0) error = %v; want an *ExprError", err)
+	}
+	if vars := s.Variables(); vars["x"] != 5 || vars[Ans] != 75 {
+		t.Errorf("Variables() after a failure = %v; want x=5 ans=75", vars)
+	}
+	if _, _, err := NewSession().Evaluate("ans", Limits{}); err == nil {
+		t.Error("Evaluate(ans) in a new session succeeded; want an error")
+	}
+}
+
+func TestSession_VariableLimit(t *testing.T) {
+	s := NewSession()
+	for i := 0; i < MaxSessionVariables; i++ {
+		if _, _, err := s.Evaluate(fmt.Sprintf("v%d = %d", i, i), Limits{}); err != nil {
+			t.Fatalf("assigning variable %d: %v", i+1, err)
+		}
+	}
+	if _, _, err := s.Evaluate("extra = 1", Limits{}); err == nil {
+		t.Errorf("assigning variable %d succeeded; want an error", MaxSessionVariables+1)
+	}
+	// Existing variables can still be reassigned
+	if _, _, err := s.Evaluate("v0 = 2", Limits{}); err != nil {
+		t.Errorf("reassigning v0 at the limit: %v", err)
+	}
+	if n := len(s.Variables()); n != MaxSessionVariables+1 {
+		t.Errorf("len(Variables()) = %d; want %d variables and ans", n, MaxSessionVariables)
+	}
+}
+
+func TestParseSessionRequest(t *testing.T) {
+	tests := []struct {
+		data string
+		want SessionRequest
+	}{
+		{"1 + 2", SessionRequest{Expression: "1 + 2"}},
+		{`{"id":"7","expression":"x = 3"}`, SessionRequest{ID: "7", Expression: "x = 3"}},
+		{` {"expression":"pi"} `, SessionRequest{Expression: "pi"}},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSessionRequest([]byte(tt.data)); err != nil || got != tt.want {
+			t.Errorf("ParseSessionRequest(%q) = %+v, %v; want %+v", tt.data, got, err, tt.want)
+		}
+	}
+	if _, err := ParseSessionRequest([]byte(`{"expression":`)); err == nil {
+		t.Error("ParseSessionRequest of truncated JSON succeeded; want an error")
+	}
+}
+
+// startSessionServer serves /calculator/ws of a handler configured by cfg
+func startSessionServer(t *testing.T, cfg config.CalculatorConfig) (*Sessions, string) {
+	t.Helper()
+	sessions := NewSessions()
+	router := mux.NewRouter()
+	NewHandler(cfg, history.NewMemoryStore(10), sessions).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return sessions, "ws" + strings.TrimPrefix(server.URL, "http") + "/calculator/ws"
+}
+
+// exchange sends a message and reads its response
+func exchange(t *testing.T, conn *websocket.Conn, message string) SessionResponse {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("WriteMessage(%q) error: %v", message, err)
+	}
+	var resp SessionResponse
+	if err := conn.ReadJSON(&resp); err != nil {
+		t.Fatalf("ReadJSON after %q error: %v", message, err)
+	}
+	return resp
+}
+
+func TestHandler_Session(t *testing.T) {
+	cfg := config.Default().Calculator
+	cfg.WSMessageRate, cfg.WSMessageBurst = 1, 3
+	sessions, url := startSessionServer(t, cfg)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer conn.Close()
+
+	if resp := exchange(t, conn, `{"id":"1","expression":"r = 2"}`); resp.ID != "1" || resp.Result == nil || *resp.Result != 2 || resp.Variable != "r" {
+		t.Errorf("assignment response = %+v; want id 1, result 2, variable r", resp)
+	}
+	if resp := exchange(t, conn, "pi * r ^ 2"); resp.Result == nil || *resp.Result != 4*3.141592653589793 {
+		t.Errorf("expression response = %+v; want 4 pi", resp)
+	}
+	if resp := exchange(t, conn, "1 + (2"); resp.Error == "" || resp.Position != 7 || resp.Result != nil {
+		t.Errorf("invalid expression response = %+v; want an error at position 7", resp)
+	}
+	// The burst of 3 messages is spent
+	if resp := exchange(t, conn, "1"); !strings.HasPrefix(resp.Error, "rate limit exceeded") {
+		t.Errorf("response over the rate limit = %+v; want a rate limit error", resp)
+	}
+	if n := sessions.Len(); n != 1 {
+		t.Errorf("Len() = %d; want 1", n)
+	}
+}
+
+func TestSessions_Shutdown(t *testing.T) {
+	sessions, url := startSessionServer(t, config.Default().Calculator)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer conn.Close()
+	exchange(t, conn, "1")
+
+	// The client answers the close message while reading
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		closed <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sessions.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	if err := <-closed; !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("client read error = %v; want a going away close", err)
+	}
+	if n := sessions.Len(); n != 0 {
+		t.Errorf("Len() after Shutdown = %d; want 0", n)
+	}
+
+	// New sessions are refused once shut down
+	conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial after Shutdown error: %v", err)
+	}
+	defer conn.Close()
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("read after Shutdown error = %v; want a going away close", err)
+	}
+}
+
+func TestHandler_SessionPings(t *testing.T) {
+	cfg := config.Default().Calculator
+	cfg.WSPingInterval = 20 * time.Millisecond
+	_, url := startSessionServer(t, cfg)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer conn.Close()
+
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// Pings are handled while reading; answered pongs keep the session open
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-pings:
+		case <-time.After(time.Second):
+			t.Fatalf("received %d pings; want 3", i)
+		}
+	}
+}
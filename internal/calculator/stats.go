@@ -0,0 +1,526 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Limits of the statistics options
+const (
+	DefaultHistogramBins = 10
+	MaxHistogramBins     = 1000
+	MaxPercentiles       = 20
+)
+
+// exactStatsLimit is the number of values kept for exact quantiles, modes
+// and histograms. Longer series switch to streaming estimates in constant
+// memory.
+const exactStatsLimit = 10000
+
+// modeCounters bounds the Misra-Gries counters estimating the mode of long
+// series
+const modeCounters = 1024
+
+// maxModes bounds the number of tied modes reported
+const maxModes = 10
+
+// DefaultPercentiles are reported when no percentiles are requested
+var DefaultPercentiles = []float64{25, 50, 75, 90, 95, 99}
+
+// Errors of the statistics accumulator
+var (
+	ErrNoValues  = errors.New("at least one value is required")
+	ErrNonFinite = errors.New("values must be finite numbers")
+)
+
+// StatsOptions selects the percentiles and histogram resolution of a Summary
+type StatsOptions struct {
+	Percentiles []float64
+	Bins        int
+}
+
+// Validate checks the percentiles are within [0, 100] and the bin count is
+// within bounds
+func (o StatsOptions) Validate() error {
+	if len(o.Percentiles) > MaxPercentiles {
+		return fmt.Errorf("at most %d percentiles can be requested", MaxPercentiles)
+	}
+	for _, p := range o.Percentiles {
+		if !(p >= 0 && p <= 100) {
+			return fmt.Errorf("percentile %v is outside [0, 100]", p)
+		}
+	}
+	if o.Bins < 1 || o.Bins > MaxHistogramBins {
+		return fmt.Errorf("bins must be between 1 and %d", MaxHistogramBins)
+	}
+	return nil
+}
+
+// Summary describes a series of numbers. Quantiles, modes and the histogram
+// are estimates when Approximate is set.
+type Summary struct {
+	Count          int                `json:"count"`
+	Sum            float64            `json:"sum"`
+	Mean           float64            `json:"mean"`
+	Median         float64            `json:"median"`
+	Mode           []float64          `json:"mode"`
+	Variance       float64            `json:"variance"`
+	StdDev         float64            `json:"stddev"`
+	SampleVariance *float64           `json:"sample_variance,omitempty"`
+	SampleStdDev   *float64           `json:"sample_stddev,omitempty"`
+	Min            float64            `json:"min"`
+	Max            float64            `json:"max"`
+	Percentiles    map[string]float64 `json:"percentiles"`
+	Histogram      []HistogramBin     `json:"histogram"`
+	Approximate    bool               `json:"approximate"`
+}
+
+// HistogramBin counts the values in [Lower, Upper). The last bin also holds
+// values equal to its upper bound.
+type HistogramBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+// Stats accumulates a series of numbers one value at a time. Memory use is
+// bounded regardless of the length of the series.
+type Stats struct {
+	percentiles []float64
+	bins        int
+
+	count    int
+	sum      float64
+	sumError float64
+	mean     float64
+	m2       float64
+	min, max float64
+
+	// values holds the series until it grows past exactStatsLimit, after
+	// which the estimators below take over
+	values    []float64
+	quantiles map[float64]*p2Quantile
+	modes     map[float64]int
+	histogram *histogram
+}
+
+// NewStats creates an empty accumulator
+func NewStats(opts StatsOptions) (*Stats, error) {
+	if opts.Percentiles == nil {
+		opts.Percentiles = DefaultPercentiles
+	}
+	if opts.Bins == 0 {
+		opts.Bins = DefaultHistogramBins
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	percentiles := append([]float64(nil), opts.Percentiles...)
+	sort.Float64s(percentiles)
+	return &Stats{
+		percentiles: percentiles,
+		bins:        opts.Bins,
+		values:      make([]float64, 0, 64),
+	}, nil
+}
+
+// Add adds x to the series
+func (s *Stats) Add(x float64) error {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return ErrNonFinite
+	}
+
+	s.count++
+	if s.count == 1 || x < s.min {
+		s.min = x
+	}
+	if s.count == 1 || x > s.max {
+		s.max = x
+	}
+
+	// Neumaier's compensated summation
+	sum := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.sumError += (s.sum - sum) + x
+	} else {
+		s.sumError += (x - sum) + s.sum
+	}
+	s.sum = sum
+
+	// Welford's online mean and variance
+	delta := x - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (x - s.mean)
+
+	if s.values != nil {
+		s.values = append(s.values, x)
+		if len(s.values) > exactStatsLimit {
+			s.spill()
+		}
+		return nil
+	}
+	for _, q := range s.quantiles {
+		q.add(x)
+	}
+	s.countMode(x)
+	s.histogram.add(x)
+	return nil
+}
+
+// Count returns the number of values added
+func (s *Stats) Count() int {
+	return s.count
+}
+
+// spill replaces the kept values by the streaming estimators
+func (s *Stats) spill() {
+	sort.Float64s(s.values)
+
+	s.quantiles = make(map[float64]*p2Quantile, len(s.percentiles)+1)
+	for _, p := range append([]float64{50}, s.percentiles...) {
+		if p > 0 && p < 100 {
+			s.quantiles[p] = newP2Quantile(p/100, s.values)
+		}
+	}
+	s.modes = make(map[float64]int, modeCounters)
+	for _, x := range s.values {
+		s.countMode(x)
+	}
+	s.histogram = newHistogram(s.bins, s.min, s.max)
+	for _, x := range s.values {
+		s.histogram.add(x)
+	}
+	s.values = nil
+}
+
+// countMode updates the Misra-Gries counters. A counter never exceeds the
+// frequency of its value, and any value more frequent than
+// count/(modeCounters+1) keeps a counter.
+func (s *Stats) countMode(x float64) {
+	if _, ok := s.modes[x]; ok || len(s.modes) < modeCounters {
+		s.modes[x]++
+		return
+	}
+	for value, n := range s.modes {
+		if n == 1 {
+			delete(s.modes, value)
+		} else {
+			s.modes[value] = n - 1
+		}
+	}
+}
+
+// Summary describes the values added so far
+func (s *Stats) Summary() (Summary, error) {
+	if s.count == 0 {
+		return Summary{}, ErrNoValues
+	}
+
+	n := float64(s.count)
+	sum := s.sum
+	if !math.IsInf(sum, 0) {
+		// The compensation is NaN once the sum overflows
+		sum += s.sumError
+	}
+	sum, err := checkFinite("sum", sum)
+	if err != nil {
+		return Summary{}, err
+	}
+	variance, err := checkFinite("variance", s.m2/n)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{
+		Count:       s.count,
+		Sum:         sum,
+		Mean:        s.mean,
+		Variance:    variance,
+		StdDev:      math.Sqrt(variance),
+		Min:         s.min,
+		Max:         s.max,
+		Percentiles: make(map[string]float64, len(s.percentiles)),
+		Mode:        []float64{},
+	}
+	if s.count > 1 {
+		sampleVariance := s.m2 / (n - 1)
+		sampleStdDev := math.Sqrt(sampleVariance)
+		summary.SampleVariance, summary.SampleStdDev = &sampleVariance, &sampleStdDev
+	}
+
+	if s.values != nil {
+		s.exactSummary(&summary)
+	} else {
+		s.estimatedSummary(&summary)
+	}
+	return summary, nil
+}
+
+// exactSummary fills the quantiles, modes and histogram from the kept values
+func (s *Stats) exactSummary(summary *Summary) {
+	sorted := s.values
+	sort.Float64s(sorted)
+
+	summary.Median = percentile(sorted, 0.5)
+	for _, p := range s.percentiles {
+		summary.Percentiles[percentileKey(p)] = percentile(sorted, p/100)
+	}
+
+	best := 1
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j] == sorted[i] {
+			j++
+		}
+		switch n := j - i; {
+		case n > best:
+			best = n
+			summary.Mode = append(summary.Mode[:0], sorted[i])
+		case n == best && best > 1 && len(summary.Mode) < maxModes:
+			summary.Mode = append(summary.Mode, sorted[i])
+		}
+		i = j
+	}
+
+	if s.min == s.max {
+		summary.Histogram = []HistogramBin{{Lower: s.min, Upper: s.max, Count: s.count}}
+		return
+	}
+	width := s.max/float64(s.bins) - s.min/float64(s.bins)
+	counts := make([]int, s.bins)
+	for _, x := range sorted {
+		counts[binIndex(x, s.min, width, s.bins)]++
+	}
+	summary.Histogram = make([]HistogramBin, s.bins)
+	for i, n := range counts {
+		summary.Histogram[i] = HistogramBin{Lower: s.min + float64(i)*width, Upper: s.min + float64(i+1)*width, Count: n}
+	}
+	summary.Histogram[s.bins-1].Upper = s.max
+}
+
+// estimatedSummary fills the quantiles, modes and histogram from the
+// streaming estimators
+func (s *Stats) estimatedSummary(summary *Summary) {
+	summary.Approximate = true
+	summary.Median = s.quantiles[50].value()
+	for _, p := range s.percentiles {
+		switch p {
+		case 0:
+			summary.Percentiles[percentileKey(p)] = s.min
+		case 100:
+			summary.Percentiles[percentileKey(p)] = s.max
+		default:
+			summary.Percentiles[percentileKey(p)] = s.quantiles[p].value()
+		}
+	}
+
+	best := 1
+	for value, n := range s.modes {
+		switch {
+		case n > best:
+			best = n
+			summary.Mode = append(summary.Mode[:0], value)
+		case n == best && best > 1:
+			summary.Mode = append(summary.Mode, value)
+		}
+	}
+	sort.Float64s(summary.Mode)
+	if len(summary.Mode) > maxModes {
+		summary.Mode = summary.Mode[:maxModes]
+	}
+
+	summary.Histogram = s.histogram.bins()
+}
+
+// percentile interpolates quantile p of sorted values between the closest
+// ranks
+func percentile(sorted []float64, p float64) float64 {
+	h := float64(len(sorted)-1) * p
+	i := int(h)
+	if frac := h - float64(i); frac > 0 && i+1 < len(sorted) {
+		return sorted[i] + frac*(sorted[i+1]-sorted[i])
+	}
+	return sorted[i]
+}
+
+// percentileKey names percentile p, as in p50 or p99.9
+func percentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// binIndex returns the bin of x among bins of the given width from lo,
+// clamped to the last bin
+func binIndex(x, lo, width float64, bins int) int {
+	f := x/width - lo/width
+	switch {
+	case f < 0:
+		return 0
+	case !(f < float64(bins)):
+		return bins - 1
+	}
+	return int(f)
+}
+
+// p2Quantile estimates a quantile with the P² algorithm of Jain and
+// Chlamtac, which tracks five markers instead of the values
+type p2Quantile struct {
+	heights [5]float64
+	pos     [5]float64
+	desired [5]float64
+	incr    [5]float64
+}
+
+// newP2Quantile starts estimating quantile p of a series, placing the
+// markers at their exact positions among its first sorted values
+func newP2Quantile(p float64, sorted []float64) *p2Quantile {
+	n := float64(len(sorted))
+	q := &p2Quantile{
+		desired: [5]float64{1, 1 + (n-1)*p/2, 1 + (n-1)*p, 1 + (n-1)*(1+p)/2, n},
+		incr:    [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+	q.pos[0], q.pos[4] = 1, n
+	for i := 1; i < 4; i++ {
+		// Markers must keep distinct positions
+		q.pos[i] = math.Min(math.Max(math.Round(q.desired[i]), q.pos[i-1]+1), n-float64(4-i))
+	}
+	for i, pos := range q.pos {
+		q.heights[i] = sorted[int(pos)-1]
+	}
+	return q
+}
+
+// add moves the markers to account for x
+func (q *p2Quantile) add(x float64) {
+	var k int
+	switch {
+	case x < q.heights[0]:
+		q.heights[0] = x
+	case x >= q.heights[4]:
+		q.heights[4] = x
+		k = 3
+	default:
+		for x >= q.heights[k+1] {
+			k++
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		q.pos[i]++
+	}
+	for i := range q.desired {
+		q.desired[i] += q.incr[i]
+	}
+
+	for i := 1; i < 4; i++ {
+		d := q.desired[i] - q.pos[i]
+		if d >= 1 && q.pos[i+1]-q.pos[i] > 1 || d <= -1 && q.pos[i-1]-q.pos[i] < -1 {
+			d = math.Copysign(1, d)
+			h := q.parabolic(i, d)
+			if !(q.heights[i-1] < h && h < q.heights[i+1]) {
+				h = q.linear(i, d)
+			}
+			q.heights[i] = h
+			q.pos[i] += d
+		}
+	}
+}
+
+// parabolic predicts the height of marker i moved by d
+func (q *p2Quantile) parabolic(i int, d float64) float64 {
+	return q.heights[i] + d/(q.pos[i+1]-q.pos[i-1])*
+		((q.pos[i]-q.pos[i-1]+d)*(q.heights[i+1]-q.heights[i])/(q.pos[i+1]-q.pos[i])+
+			(q.pos[i+1]-q.pos[i]-d)*(q.heights[i]-q.heights[i-1])/(q.pos[i]-q.pos[i-1]))
+}
+
+// linear interpolates the height of marker i moved by d
+func (q *p2Quantile) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return q.heights[i] + d*(q.heights[j]-q.heights[i])/(q.pos[j]-q.pos[i])
+}
+
+// value returns the estimate
+func (q *p2Quantile) value() float64 {
+	return q.heights[2]
+}
+
+// histogram counts values in equal-width bins over a range that doubles
+// when a value falls outside it. It keeps twice the requested bins, so
+// doubling merges adjacent pairs.
+type histogram struct {
+	lo     float64
+	width  float64
+	counts []int
+}
+
+// newHistogram creates a histogram covering [lo, hi]
+func newHistogram(bins int, lo, hi float64) *histogram {
+	n := 2 * bins
+	width := hi/float64(n-1) - lo/float64(n-1)
+	if width == 0 {
+		width = math.Max(math.Abs(lo), 1) / float64(n)
+	}
+	return &histogram{lo: lo, width: width, counts: make([]int, n)}
+}
+
+// add counts x, growing the range to hold it
+func (h *histogram) add(x float64) {
+	n := float64(len(h.counts))
+	for x < h.lo {
+		if !h.grow(h.lo - n*h.width) {
+			break
+		}
+	}
+	for !(x/h.width-h.lo/h.width < n) {
+		if !h.grow(h.lo) {
+			break
+		}
+	}
+	h.counts[binIndex(x, h.lo, h.width, len(h.counts))]++
+}
+
+// grow doubles the bin width and moves the range to start at lo, which is
+// either the current start or one range below it. It reports false when
+// the range can no longer grow.
+func (h *histogram) grow(lo float64) bool {
+	n := len(h.counts)
+	if math.IsInf(lo, 0) || math.IsInf(2*h.width*float64(n), 0) {
+		return false
+	}
+
+	offset := 0
+	if lo != h.lo {
+		offset = n / 2
+	}
+	merged := make([]int, n)
+	for i := 0; i < n/2; i++ {
+		merged[offset+i] = h.counts[2*i] + h.counts[2*i+1]
+	}
+	h.lo, h.width, h.counts = lo, 2*h.width, merged
+	return true
+}
+
+// bins reports the requested number of bins, without the empty bins at
+// either end
+func (h *histogram) bins() []HistogramBin {
+	width := 2 * h.width
+	bins := make([]HistogramBin, 0, len(h.counts)/2)
+	for i := 0; i < len(h.counts); i += 2 {
+		bins = append(bins, HistogramBin{
+			Lower: h.lo + float64(i)*h.width,
+			Upper: h.lo + float64(i)*h.width + width,
+			Count: h.counts[i] + h.counts[i+1],
+		})
+	}
+
+	first, last := 0, len(bins)-1
+	for first < last && bins[first].Count == 0 {
+		first++
+	}
+	for last > first && bins[last].Count == 0 {
+		last--
+	}
+	return bins[first : last+1]
+}
@@ -0,0 +1,113 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// handleStats handles descriptive statistics of a series
+func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
+	opts, err := statsQuery(r)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	stats, err := NewStats(opts)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	body, format, err := seriesBody(r)
+	if errors.Is(err, ErrUnsupportedFormat) {
+		h.WriteError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	if err := ReadSeries(body, format, r.URL.Query().Get("column"), stats.Add); err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	summary, err := stats.Summary()
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	h.WriteSuccess(w, summary)
+}
+
+// statsQuery reads the percentiles and bins query parameters
+func statsQuery(r *http.Request) (StatsOptions, error) {
+	var opts StatsOptions
+	query := r.URL.Query()
+	if list := query.Get("percentiles"); list != "" {
+		for _, item := range strings.Split(list, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+			if err != nil {
+				return StatsOptions{}, fmt.Errorf("invalid percentile %q", item)
+			}
+			opts.Percentiles = append(opts.Percentiles, p)
+		}
+	}
+	if bins := query.Get("bins"); bins != "" {
+		n, err := strconv.Atoi(bins)
+		if err != nil || n < 1 {
+			return StatsOptions{}, fmt.Errorf("invalid bins %q", bins)
+		}
+		opts.Bins = n
+	}
+	return opts, nil
+}
+
+// seriesBody returns the series of a request, which is either the body or
+// the "file" part of a multipart upload, and its format
+func seriesBody(r *http.Request) (io.Reader, SeriesFormat, error) {
+	mediaType := r.Header.Get("Content-Type")
+	if mediaType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(mediaType); err != nil {
+			return nil, 0, fmt.Errorf("%w %q", ErrUnsupportedFormat, r.Header.Get("Content-Type"))
+		}
+	}
+	if mediaType != "multipart/form-data" {
+		format, err := ParseSeriesFormat(mediaType)
+		return r.Body, format, err
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid multipart upload: %w", err)
+	}
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil, 0, errors.New("multipart upload has no \"file\" part")
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid multipart upload: %w", err)
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		kind := part.Header.Get("Content-Type")
+		if kind != "" {
+			kind, _, _ = mime.ParseMediaType(kind)
+		}
+		if kind == "" || kind == "application/octet-stream" || kind == "text/plain" {
+			kind = filepath.Ext(part.FileName())
+		}
+		format, err := ParseSeriesFormat(kind)
+		return part, format, err
+	}
+}
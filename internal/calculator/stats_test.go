@@ -0,0 +1,265 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func summarize(t *testing.T, opts StatsOptions, values ...float64) Summary {
+	t.Helper()
+	stats, err := NewStats(opts)
+	if err != nil {
+		t.Fatalf("NewStats returned error: %v", err)
+	}
+	for _, x := range values {
+		if err := stats.Add(x); err != nil {
+			t.Fatalf("Add(%v) returned error: %v", x, err)
+		}
+	}
+	summary, err := stats.Summary()
+	if err != nil {
+		t.Fatalf("Summary returned error: %v", err)
+	}
+	return summary
+}
+
+func TestStats_Summary(t *testing.T) {
+	s := summarize(t, StatsOptions{Percentiles: []float64{0, 25, 75, 100}, Bins: 7}, 2, 4, 4, 4, 5, 5, 7, 9)
+
+	if s.Count != 8 || s.Sum != 40 || s.Mean != 5 || s.Median != 4.5 || s.Min != 2 || s.Max != 9 {
+		t.Errorf("Summary = %+v; want count 8, sum 40, mean 5, median 4.5, min 2, max 9", s)
+	}
+	if s.Variance != 4 || s.StdDev != 2 {
+		t.Errorf("Variance, StdDev = %v, %v; want 4, 2", s.Variance, s.StdDev)
+	}
+	if s.SampleVariance == nil || math.Abs(*s.SampleVariance-32.0/7) > 1e-12 {
+		t.Errorf("SampleVariance = %v; want 32/7", s.SampleVariance)
+	}
+	if !reflect.DeepEqual(s.Mode, []float64{4}) {
+		t.Errorf("Mode = %v; want [4]", s.Mode)
+	}
+	want := map[string]float64{"p0": 2, "p25": 4, "p75": 5.5, "p100": 9}
+	if !reflect.DeepEqual(s.Percentiles, want) {
+		t.Errorf("Percentiles = %v; want %v", s.Percentiles, want)
+	}
+	if s.Approximate {
+		t.Error("Approximate = true for a short series; want exact results")
+	}
+
+	counts := make([]int, 0, len(s.Histogram))
+	for _, bin := range s.Histogram {
+		counts = append(counts, bin.Count)
+	}
+	if !reflect.DeepEqual(counts, []int{1, 0, 3, 2, 0, 1, 1}) {
+		t.Errorf("Histogram counts = %v; want [1 0 3 2 0 1 1]", counts)
+	}
+	if s.Histogram[0].Lower != 2 || s.Histogram[6].Upper != 9 {
+		t.Errorf("Histogram spans [%v, %v]; want [2, 9]", s.Histogram[0].Lower, s.Histogram[6].Upper)
+	}
+}
+
+func TestStats_SummaryOfOneValue(t *testing.T) {
+	s := summarize(t, StatsOptions{}, 3)
+
+	if s.Median != 3 || s.Variance != 0 || s.SampleVariance != nil || len(s.Mode) != 0 {
+		t.Errorf("Summary = %+v; want median 3, variance 0, no sample variance and no mode", s)
+	}
+	if len(s.Histogram) != 1 || s.Histogram[0] != (HistogramBin{Lower: 3, Upper: 3, Count: 1}) {
+		t.Errorf("Histogram = %v; want a single bin holding 3", s.Histogram)
+	}
+	if len(s.Percentiles) != len(DefaultPercentiles) {
+		t.Errorf("Percentiles = %v; want the default percentiles", s.Percentiles)
+	}
+}
+
+func TestStats_StreamsLongSeries(t *testing.T) {
+	const n = 1000000
+	stats, err := NewStats(StatsOptions{Percentiles: []float64{1, 50, 99}})
+	if err != nil {
+		t.Fatalf("NewStats returned error: %v", err)
+	}
+	// A permutation of 0..n-1 with its multiples of 10 replaced by 42, so
+	// that 42 fills the ranks from 1% to 10%
+	for i := 0; i < n; i++ {
+		x := float64(i * 7919 % n)
+		if i%10 == 0 {
+			x = 42
+		}
+		if err := stats.Add(x); err != nil {
+			t.Fatalf("Add(%v) returned error: %v", x, err)
+		}
+	}
+	if stats.values != nil {
+		t.Fatal("the series is still held in memory")
+	}
+
+	s, err := stats.Summary()
+	if err != nil {
+		t.Fatalf("Summary returned error: %v", err)
+	}
+	if !s.Approximate || s.Count != n || s.Min != 1 || s.Max != n-1 {
+		t.Errorf("Summary = approximate %v, count %d, min %v, max %v; want an approximate summary of %d values in [1, %d]",
+			s.Approximate, s.Count, s.Min, s.Max, n, n-1)
+	}
+	if !reflect.DeepEqual(s.Mode, []float64{42}) {
+		t.Errorf("Mode = %v; want [42]", s.Mode)
+	}
+
+	tolerance := 0.01 * n
+	for key, want := range map[string]float64{"p1": 42, "p50": 0.4 * n / 0.9, "p99": 0.89 * n / 0.9} {
+		if got := s.Percentiles[key]; math.Abs(got-want) > tolerance {
+			t.Errorf("Percentiles[%s] = %v; want %v within %v", key, got, want, tolerance)
+		}
+	}
+	if s.Median != s.Percentiles["p50"] {
+		t.Errorf("Median = %v; want p50 %v", s.Median, s.Percentiles["p50"])
+	}
+
+	total := 0
+	for _, bin := range s.Histogram {
+		total += bin.Count
+	}
+	if total != n || len(s.Histogram) > DefaultHistogramBins {
+		t.Errorf("Histogram holds %d values in %d bins; want %d values in at most %d bins", total, len(s.Histogram), n, DefaultHistogramBins)
+	}
+	if s.Histogram[0].Lower > s.Min || s.Histogram[len(s.Histogram)-1].Upper < s.Max {
+		t.Errorf("Histogram spans [%v, %v]; want it to cover [%v, %v]",
+			s.Histogram[0].Lower, s.Histogram[len(s.Histogram)-1].Upper, s.Min, s.Max)
+	}
+}
+
+func TestStats_HistogramGrowsBelowAndAbove(t *testing.T) {
+	stats, _ := NewStats(StatsOptions{Bins: 4})
+	for i := 0; i <= exactStatsLimit; i++ {
+		_ = stats.Add(float64(i % 100))
+	}
+	_ = stats.Add(-1e6)
+	_ = stats.Add(1e9)
+
+	s, err := stats.Summary()
+	if err != nil {
+		t.Fatalf("Summary returned error: %v", err)
+	}
+	total := 0
+	for _, bin := range s.Histogram {
+		total += bin.Count
+	}
+	if total != exactStatsLimit+3 || len(s.Histogram) > 4 {
+		t.Errorf("Histogram = %v; want %d values in at most 4 bins", s.Histogram, exactStatsLimit+3)
+	}
+	if s.Histogram[0].Lower > -1e6 || s.Histogram[len(s.Histogram)-1].Upper <= 1e9 {
+		t.Errorf("Histogram = %v; want it to cover [-1e6, 1e9]", s.Histogram)
+	}
+}
+
+func TestStats_Errors(t *testing.T) {
+	stats, _ := NewStats(StatsOptions{})
+	if _, err := stats.Summary(); !errors.Is(err, ErrNoValues) {
+		t.Errorf("Summary of no values error = %v; want ErrNoValues", err)
+	}
+	if err := stats.Add(math.NaN()); !errors.Is(err, ErrNonFinite) {
+		t.Errorf("Add(NaN) error = %v; want ErrNonFinite", err)
+	}
+
+	_ = stats.Add(math.MaxFloat64)
+	_ = stats.Add(math.MaxFloat64)
+	if _, err := stats.Summary(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Summary of an overflowing sum error = %v; want ErrOverflow", err)
+	}
+
+	for _, opts := range []StatsOptions{
+		{Percentiles: []float64{101}},
+		{Percentiles: []float64{-1}},
+		{Percentiles: []float64{math.NaN()}},
+		{Percentiles: make([]float64, MaxPercentiles+1)},
+		{Bins: -1},
+		{Bins: MaxHistogramBins + 1},
+	} {
+		if _, err := NewStats(opts); err == nil {
+			t.Errorf("NewStats(%+v) succeeded; want an error", opts)
+		}
+	}
+}
+
+func TestReadSeries(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format SeriesFormat
+		column string
+		want   []float64
+	}{
+		{"json", " [1, 2.5, -3e2] ", FormatJSON, "", []float64{1, 2.5, -300}},
+		{"empty json", "[]", FormatJSON, "", nil},
+		{"csv", "1,2\n3, 4\n\n5,\n", FormatCSV, "", []float64{1, 2, 3, 4, 5}},
+		{"csv header", "a,b\n1,2\n", FormatCSV, "", []float64{1, 2}},
+		{"csv column", "id,price\nx,1.5\ny,2.5\n", FormatCSV, "price", []float64{1.5, 2.5}},
+		{"ndjson", "1\n\n 2.5 \n-3\n", FormatNDJSON, "", []float64{1, 2.5, -3}},
+	}
+
+	for _, tt := range tests {
+		var got []float64
+		err := ReadSeries(strings.NewReader(tt.input), tt.format, tt.column, func(x float64) error {
+			got = append(got, x)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: ReadSeries returned error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadSeries read %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadSeriesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format SeriesFormat
+		column string
+		want   string
+	}{
+		{"json object", `{"values":[1]}`, FormatJSON, "", "expected a JSON array"},
+		{"json string", `[1, "2"]`, FormatJSON, "", "value 2 is not a number"},
+		{"json nested", `[1, [2]]`, FormatJSON, "", "value 2 is not a number"},
+		{"json range", `[1e999]`, FormatJSON, "", "value 1: 1e999 is out of range"},
+		{"json unterminated", `[1, 2`, FormatJSON, "", "value 3: invalid JSON"},
+		{"json trailing", `[1] [2]`, FormatJSON, "", "unexpected data"},
+		{"csv text", "1,2\n3,x\n", FormatCSV, "", `line 2: "x" is not a number`},
+		{"csv nan", "1\nNaN\n", FormatCSV, "", "line 2: values must be finite numbers"},
+		{"csv missing column", "a,b\n1,2\n", FormatCSV, "c", `column "c" not found`},
+		{"csv short row", "a,b\n1,2\n3\n", FormatCSV, "b", `line 3 has no column "b"`},
+		{"ndjson text", "1\nfoo\n", FormatNDJSON, "", "line 2 is not a number"},
+	}
+
+	for _, tt := range tests {
+		stats, _ := NewStats(StatsOptions{})
+		err := ReadSeries(strings.NewReader(tt.input), tt.format, tt.column, stats.Add)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ReadSeries error = %v; want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseSeriesFormat(t *testing.T) {
+	for input, want := range map[string]SeriesFormat{
+		"":                     FormatJSON,
+		"application/json":     FormatJSON,
+		"text/csv":             FormatCSV,
+		".CSV":                 FormatCSV,
+		"application/x-ndjson": FormatNDJSON,
+		".jsonl":               FormatNDJSON,
+	} {
+		if got, err := ParseSeriesFormat(input); err != nil || got != want {
+			t.Errorf("ParseSeriesFormat(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseSeriesFormat("application/xml"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("ParseSeriesFormat(\"application/xml\") error = %v; want ErrUnsupportedFormat", err)
+	}
+}
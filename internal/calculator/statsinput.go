@@ -0,0 +1,192 @@
+package calculator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SeriesFormat is the encoding of a series of numbers
+type SeriesFormat int
+
+// Series formats
+const (
+	FormatJSON SeriesFormat = iota
+	FormatCSV
+	FormatNDJSON
+)
+
+// ErrUnsupportedFormat reports a series in an unknown encoding
+var ErrUnsupportedFormat = errors.New("unsupported series format")
+
+// maxLineBytes bounds a single NDJSON line
+const maxLineBytes = 64 * 1024
+
+// ParseSeriesFormat resolves a media type or a file name extension to a
+// series format
+func ParseSeriesFormat(s string) (SeriesFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "application/json", "text/json", ".json":
+		return FormatJSON, nil
+	case "text/csv", "application/csv", ".csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines", ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	}
+	return 0, fmt.Errorf("%w %q, use JSON, CSV or NDJSON", ErrUnsupportedFormat, s)
+}
+
+// ReadSeries streams the numbers of r to add. CSV values come from every
+// field, or from the named column of a header row when column is set;
+// without a column, a first row holding no numbers is taken as a header.
+func ReadSeries(r io.Reader, format SeriesFormat, column string, add func(float64) error) error {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, column, add)
+	case FormatNDJSON:
+		return readNDJSON(r, add)
+	}
+	return readJSONArray(r, add)
+}
+
+// readJSONArray reads a JSON array of numbers token by token, so the array
+// is never held in memory
+func readJSONArray(r io.Reader, add func(float64) error) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return errors.New("expected a JSON array of numbers")
+	}
+
+	for i := 1; dec.More(); i++ {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("value %d: invalid JSON", i)
+		}
+		number, ok := tok.(json.Number)
+		if !ok {
+			return fmt.Errorf("value %d is not a number", i)
+		}
+		if err := addNumber(string(number), add); err != nil {
+			return fmt.Errorf("value %d: %w", i, err)
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return errors.New("expected a JSON array of numbers")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON array")
+	}
+	return nil
+}
+
+// readNDJSON reads one JSON number per line, skipping blank lines
+func readNDJSON(r io.Reader, add func(float64) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var x float64
+		if err := json.Unmarshal(text, &x); err != nil {
+			return fmt.Errorf("line %d is not a number", line)
+		}
+		if err := add(x); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid NDJSON: %w", err)
+	}
+	return nil
+}
+
+// readCSV reads numbers from CSV fields, skipping empty ones
+func readCSV(r io.Reader, column string, add func(float64) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+
+	index := -1
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			if column != "" {
+				if index = headerIndex(record, column); index < 0 {
+					return fmt.Errorf("column %q not found in the CSV header", column)
+				}
+				continue
+			}
+			if isHeader(record) {
+				continue
+			}
+		}
+
+		fields := record
+		if index >= 0 {
+			if index >= len(record) {
+				return fmt.Errorf("line %d has no column %q", line, column)
+			}
+			fields = record[index : index+1]
+		}
+		for _, field := range fields {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			if err := addNumber(field, add); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	return nil
+}
+
+// headerIndex finds column in a header row
+func headerIndex(header []string, column string) int {
+	for i, name := range header {
+		if strings.TrimSpace(name) == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// isHeader reports whether a row holds no numbers
+func isHeader(record []string) bool {
+	for _, field := range record {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// addNumber parses s and adds it
+func addNumber(s string, add func(float64) error) error {
+	x, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("%s is out of range", s)
+		}
+		return fmt.Errorf("%q is not a number", s)
+	}
+	return add(x)
+}
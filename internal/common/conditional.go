@@ -0,0 +1,72 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Conditional request headers
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// AnyVersion is the expected version of unconditional writes
+const AnyVersion = 0
+
+// Errors of the conditional request checks
+var (
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// ETag formats a resource version as a strong entity tag
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag header of a resource version
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set(HeaderETag, ETag(version))
+}
+
+// NotModified sets the ETag of a resource version and, when If-None-Match
+// already holds it, answers 304 and returns true
+func NotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	SetETag(w, version)
+
+	header := r.Header.Get(HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	etag := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatch returns the version a write requires through If-Match, or
+// AnyVersion without the header or with *. Weak, malformed and multiple
+// entity tags cannot match a single version and return ErrPreconditionFailed.
+func IfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get(HeaderIfMatch))
+	if header == "" || header == "*" {
+		return AnyVersion, nil
+	}
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrPreconditionFailed
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
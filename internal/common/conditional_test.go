@@ -0,0 +1,74 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"no header", "", false},
+		{"matching tag", `"3"`, true},
+		{"weak matching tag", `W/"3"`, true},
+		{"tag in list", `"1", "3"`, true},
+		{"wildcard", "*", true},
+		{"stale tag", `"2"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set(HeaderIfNoneMatch, tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.Equal(t, tt.expected, NotModified(rec, req, 3))
+			assert.Equal(t, `"3"`, rec.Header().Get(HeaderETag))
+			if tt.expected {
+				assert.Equal(t, http.StatusNotModified, rec.Code)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		expected int
+		wantErr  bool
+	}{
+		{"no header", "", AnyVersion, false},
+		{"wildcard", "*", AnyVersion, false},
+		{"strong tag", `"7"`, 7, false},
+		{"weak tag", `W/"7"`, 0, true},
+		{"unquoted", "7", 0, true},
+		{"not a version", `"abc"`, 0, true},
+		{"list", `"6", "7"`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, tt.ifMatch)
+			}
+
+			version, err := IfMatch(req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrPreconditionFailed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
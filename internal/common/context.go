@@ -0,0 +1,63 @@
+package common
+
+import "context"
+
+// principalKey is the context key of the authenticated subject
+type principalKey struct{}
+
+// apiKeyKey is the context key of the validated API key
+type apiKeyKey struct{}
+
+// stateKey is the context key of the RequestState
+type stateKey struct{}
+
+// RequestState collects per-request facts shared between middleware and
+// handlers. It is mutable so that middleware wrapping the router can report
+// facts discovered while routing once the handler returns.
+type RequestState struct {
+	RequestID string // Correlation ID of the request
+	Route     string // Name of the matched route, or its method and path template
+	Principal string // Authenticated subject, empty for anonymous requests
+}
+
+// WithState returns a copy of ctx carrying a new RequestState
+func WithState(ctx context.Context) (context.Context, *RequestState) {
+	if state := State(ctx); state != nil {
+		return ctx, state
+	}
+	state := &RequestState{}
+	return context.WithValue(ctx, stateKey{}, state), state
+}
+
+// State returns the RequestState stored in ctx, or nil
+func State(ctx context.Context) *RequestState {
+	state, _ := ctx.Value(stateKey{}).(*RequestState)
+	return state
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated subject.
+// The subject is also recorded in the request state when there is one.
+func WithPrincipal(ctx context.Context, subject string) context.Context {
+	if state := State(ctx); state != nil {
+		state.Principal = subject
+	}
+	return context.WithValue(ctx, principalKey{}, subject)
+}
+
+// Principal returns the authenticated subject stored in ctx
+func Principal(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(principalKey{}).(string)
+	return subject, ok && subject != ""
+}
+
+// WithAPIKey returns a copy of ctx carrying an API key that an
+// authenticator has validated
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKey returns the validated API key stored in ctx
+func APIKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(string)
+	return key, ok && key != ""
+}
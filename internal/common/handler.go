@@ -43,6 +43,11 @@ func (h *BaseHandler) WriteNotFound(w http.ResponseWriter, message string) {
 	WriteNotFound(w, message)
 }
 
+// WritePreconditionFailed writes a precondition failed error
+func (h *BaseHandler) WritePreconditionFailed(w http.ResponseWriter, message string) {
+	WritePreconditionFailed(w, message)
+}
+
 // WriteInternalError writes an internal server error
 func (h *BaseHandler) WriteInternalError(w http.ResponseWriter, message string) {
 	WriteInternalError(w, message)
@@ -0,0 +1,228 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Middleware wraps an HTTP handler
+type Middleware func(http.Handler) http.Handler
+
+// namedMiddleware keeps a middleware together with the name reported by introspection
+type namedMiddleware struct {
+	name string
+	fn   Middleware
+}
+
+// RouteMiddleware builds a middleware for a single route from its metadata,
+// so it can label or configure itself per route
+type RouteMiddleware func(info RouteInfo) Middleware
+
+// namedRouteMiddleware keeps a route middleware together with its name
+type namedRouteMiddleware struct {
+	name string
+	fn   RouteMiddleware
+}
+
+// Route names reported for requests that match no route
+const (
+	RouteNotFound         = "route.not_found"
+	RouteMethodNotAllowed = "route.method_not_allowed"
+)
+
+// Router is the single router every module is mounted on. It applies the
+// shared middleware and error handling, enforces route authentication and
+// records every route in a RouteTable.
+type Router struct {
+	mux        *mux.Router
+	routes     *RouteTable
+	middleware []namedMiddleware
+	perRoute   []namedRouteMiddleware
+	afterAuth  []namedRouteMiddleware
+	auth       map[string]namedMiddleware
+	handler    http.Handler
+}
+
+// NewRouter creates a router answering unknown routes with JSON errors
+func NewRouter() *Router {
+	r := &Router{
+		mux:    mux.NewRouter(),
+		routes: NewRouteTable(),
+		auth:   make(map[string]namedMiddleware),
+	}
+	r.setFallbackHandlers()
+	r.handler = r.mux
+	return r
+}
+
+// setFallbackHandlers answers unmatched requests with JSON errors, through
+// the route middleware so they are observed like any other route
+func (r *Router) setFallbackHandlers() {
+	r.mux.NotFoundHandler = r.wrapRoute(RouteInfo{Name: RouteNotFound}, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		WriteNotFound(w, "route not found")
+	}))
+	r.mux.MethodNotAllowedHandler = r.wrapRoute(RouteInfo{Name: RouteMethodNotAllowed}, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}))
+}
+
+// Use adds a middleware applied to every request, including unmatched ones.
+// Middleware added first runs outermost.
+func (r *Router) Use(name string, fn Middleware) {
+	r.middleware = append(r.middleware, namedMiddleware{name: name, fn: fn})
+	r.routes.Use(name)
+
+	var handler http.Handler = r.mux
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i].fn(handler)
+	}
+	r.handler = handler
+}
+
+// UseRoute adds a middleware built for every route once it is matched, inside
+// the shared middleware and outside authentication. It only applies to routes
+// registered after the call.
+func (r *Router) UseRoute(name string, fn RouteMiddleware) {
+	r.perRoute = append(r.perRoute, namedRouteMiddleware{name: name, fn: fn})
+	r.setFallbackHandlers()
+}
+
+// UseAfterAuth adds a middleware built for every route and run inside
+// authentication, so it sees the authenticated principal. It only applies to
+// routes registered after the call.
+func (r *Router) UseAfterAuth(name string, fn RouteMiddleware) {
+	r.afterAuth = append(r.afterAuth, namedRouteMiddleware{name: name, fn: fn})
+}
+
+// RequireAuth sets the middleware enforcing routes declared with the given auth kind
+func (r *Router) RequireAuth(kind, name string, fn Middleware) {
+	r.auth[kind] = namedMiddleware{name: name, fn: fn}
+}
+
+// Mount registers every route group of a module
+func (r *Router) Mount(module RouteProvider) {
+	for _, group := range module.Routes() {
+		r.HandleGroup(group)
+	}
+}
+
+// HandleGroup registers a group of routes with a common prefix
+func (r *Router) HandleGroup(group RouteGroup) {
+	for _, route := range group.Routes {
+		path := group.Prefix + route.Path
+		info := RouteInfo{
+			Method: route.Method,
+			Path:   path,
+			Name:   route.Name,
+			Auth:   route.Auth,
+			Doc:    route.Doc,
+		}
+
+		var handler http.Handler = route.Handler
+		for i := len(r.afterAuth) - 1; i >= 0; i-- {
+			handler = r.afterAuth[i].fn(info)(handler)
+		}
+
+		var authName string
+		if route.Auth != "" && route.Auth != AuthNone {
+			auth, ok := r.auth[route.Auth]
+			if !ok {
+				panic(fmt.Sprintf("route %s %s requires unknown auth %q", route.Method, path, route.Auth))
+			}
+			handler = auth.fn(handler)
+			authName = auth.name
+		}
+
+		handler = r.wrapRoute(info, handler)
+		info.Middleware = r.routeMiddlewareNames()
+		if authName != "" {
+			info.Middleware = append(info.Middleware, authName)
+		}
+		for _, m := range r.afterAuth {
+			info.Middleware = append(info.Middleware, m.name)
+		}
+
+		m := r.mux.Handle(path, handler).Methods(route.Method)
+		if route.Name != "" {
+			m.Name(route.Name)
+		}
+		r.routes.Add(info)
+	}
+}
+
+// HandlePrefix registers a handler for every GET request below a path prefix
+func (r *Router) HandlePrefix(prefix, name string, handler http.Handler, doc RouteDoc) {
+	info := RouteInfo{Method: "GET", Path: prefix, Name: name, Doc: doc}
+	r.mux.PathPrefix(prefix).Handler(r.wrapRoute(info, handler)).Methods("GET").Name(name)
+	info.Middleware = r.routeMiddlewareNames()
+	r.routes.Add(info)
+}
+
+// wrapRoute applies the route middleware and records the route in the request state
+func (r *Router) wrapRoute(info RouteInfo, handler http.Handler) http.Handler {
+	for i := len(r.perRoute) - 1; i >= 0; i-- {
+		handler = r.perRoute[i].fn(info)(handler)
+	}
+	return withRouteState(info, handler)
+}
+
+// routeMiddlewareNames returns the names of the route middleware
+func (r *Router) routeMiddlewareNames() []string {
+	var names []string
+	for _, m := range r.perRoute {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// Routes returns the table of registered routes
+func (r *Router) Routes() *RouteTable {
+	return r.routes
+}
+
+// URL builds the path of a named route from key/value parameter pairs
+func (r *Router) URL(name string, pairs ...string) (string, error) {
+	return URLFor(r.mux, name, pairs...)
+}
+
+// ServeHTTP dispatches the request through the shared middleware
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := context.WithValue(req.Context(), routerKey{}, r)
+	ctx, _ = WithState(ctx)
+	r.handler.ServeHTTP(w, req.WithContext(ctx))
+}
+
+// RouteLabel returns the name identifying a route in logs and metrics: its
+// name, or its method and path template when it has none
+func RouteLabel(info RouteInfo) string {
+	if info.Name != "" {
+		return info.Name
+	}
+	return info.Method + " " + info.Path
+}
+
+// withRouteState records the matched route in the request state
+func withRouteState(info RouteInfo, next http.Handler) http.Handler {
+	route := RouteLabel(info)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if state := State(req.Context()); state != nil {
+			state.Route = route
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// routerKey is the context key of the Router serving a request
+type routerKey struct{}
+
+// RequestURL builds the path of a named route on the Router serving the request
+func RequestURL(req *http.Request, name string, pairs ...string) (string, error) {
+	r, ok := req.Context().Value(routerKey{}).(*Router)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+	return r.URL(name, pairs...)
+}
@@ -1,10 +1,15 @@
 package common
 
 import (
+	"bytes"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 )
 
+// HeaderRequestID is the header carrying the request correlation ID
+const HeaderRequestID = "X-Request-ID"
+
 // Response represents a standard API response
 type Response struct {
 	Data    interface{} `json:"data,omitempty"`
@@ -19,18 +24,33 @@ type SuccessResponse struct {
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
-// WriteJSON writes a JSON response with the given status code
+// WriteJSON writes a JSON response with the given status code. The body is
+// encoded before the status line is sent, so data that cannot be encoded,
+// such as NaN or infinite numbers, is answered with a 500 error instead of a
+// truncated body.
 func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		slog.Default().Error("failed to encode JSON response",
+			"error", err,
+			"status", statusCode,
+			"request_id", w.Header().Get(HeaderRequestID),
+		)
+		buf.Reset()
+		statusCode = http.StatusInternalServerError
+		_ = json.NewEncoder(&buf).Encode(ErrorResponse{
+			Error:     "failed to encode response",
+			RequestID: w.Header().Get(HeaderRequestID),
+		})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		// Log the error or handle it appropriately
-		// For now, we'll silently ignore it since the response headers are already sent
-		_ = err
-	}
+	_, _ = w.Write(buf.Bytes())
 }
 
 // WriteSuccess writes a successful JSON response
@@ -43,9 +63,13 @@ func WriteCreated(w http.ResponseWriter, data interface{}) {
 	WriteJSON(w, http.StatusCreated, SuccessResponse{Data: data})
 }
 
-// WriteError writes an error JSON response
+// WriteError writes an error JSON response, echoing the request ID set by
+// the correlation middleware
 func WriteError(w http.ResponseWriter, statusCode int, message string) {
-	WriteJSON(w, statusCode, ErrorResponse{Error: message})
+	WriteJSON(w, statusCode, ErrorResponse{
+		Error:     message,
+		RequestID: w.Header().Get(HeaderRequestID),
+	})
 }
 
 // WriteBadRequest writes a bad request error response
@@ -58,6 +82,11 @@ func WriteNotFound(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusNotFound, message)
 }
 
+// WritePreconditionFailed writes a precondition failed error response
+func WritePreconditionFailed(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusPreconditionFailed, message)
+}
+
 // WriteInternalError writes an internal server error response
 func WriteInternalError(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusInternalServerError, message)
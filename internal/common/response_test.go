@@ -0,0 +1,30 @@
+package common
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteSuccess(rec, map[string]float64{"result": 1.5})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"data":{"result":1.5}}`, rec.Body.String())
+}
+
+func TestWriteJSONRejectsUnencodableData(t *testing.T) {
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		rec := httptest.NewRecorder()
+		rec.Header().Set(HeaderRequestID, "req-1")
+		WriteSuccess(rec, map[string]float64{"result": value})
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"error":"failed to encode response","request_id":"req-1"}`, rec.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -11,12 +12,54 @@ type Handler interface {
 	RegisterRoutes(router *mux.Router)
 }
 
+// RouteProvider is implemented by handlers that expose their route groups
+type RouteProvider interface {
+	Routes() []RouteGroup
+}
+
+// Module is a feature module mounted on the server Router. Modules still
+// register on a plain mux router for standalone use, and expose their route
+// groups so the server can apply shared middleware and record route metadata.
+type Module interface {
+	Handler
+	RouteProvider
+}
+
 // Route represents a single route configuration
 type Route struct {
 	Path    string
 	Method  string
 	Handler http.HandlerFunc
-	Name    string // Optional name for the route
+	Name    string   // Optional name for the route
+	Auth    string   // Authentication required by the route, AuthNone by default
+	Doc     RouteDoc // Optional documentation used to build the OpenAPI spec
+}
+
+// RouteDoc describes a route for the generated OpenAPI document
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Params      map[string]string // Path parameter types (string, integer, number), string by default
+	Query       map[string]string // Optional query parameter types
+	Request     interface{}       // Zero value of the request body type, nil if the route has no body
+	Response    interface{}       // Zero value of the success payload type, nil if the route has no body
+	Status      int               // Success status code, 200 by default
+	Errors      []int             // Error status codes answered with an ErrorResponse
+	Raw         bool              // Response is written as is instead of wrapped in SuccessResponse
+	Hidden      bool              // Route is left out of the OpenAPI document
+}
+
+// WithAuth returns a copy of the route requiring the given authentication
+func (r Route) WithAuth(auth string) Route {
+	r.Auth = auth
+	return r
+}
+
+// WithDoc returns a copy of the route with the given documentation
+func (r Route) WithDoc(doc RouteDoc) Route {
+	r.Doc = doc
+	return r
 }
 
 // RouteGroup represents a group of routes with a common prefix
@@ -36,6 +79,20 @@ func RegisterGroup(router *mux.Router, group RouteGroup) {
 	}
 }
 
+// URLFor builds the path of a named mux route from key/value parameter pairs
+func URLFor(router *mux.Router, name string, pairs ...string) (string, error) {
+	route := router.Get(name)
+	if route == nil {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+
+	u, err := route.URLPath(pairs...)
+	if err != nil {
+		return "", err
+	}
+	return u.Path, nil
+}
+
 // SimpleRoute creates a simple route with just path, method and handler
 func SimpleRoute(path, method string, handler http.HandlerFunc) Route {
 	return Route{
@@ -0,0 +1,169 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Auth requirement values reported by route introspection
+const (
+	AuthNone        = "none"
+	AuthJWT         = "jwt"
+	AuthOptionalJWT = "jwt_optional" // Valid tokens authenticate, anything else passes anonymously
+)
+
+// Errors of the reverse URL helpers
+var (
+	ErrRouteNotFound = errors.New("route not found")
+)
+
+// RouteInfo describes a registered route for introspection
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Name       string   `json:"name,omitempty"`
+	Auth       string   `json:"auth"`
+	Middleware []string `json:"middleware"`
+	Doc        RouteDoc `json:"-"`
+}
+
+// RouteTable keeps the metadata of every route registered on a server
+type RouteTable struct {
+	mu         sync.RWMutex
+	routes     []RouteInfo
+	middleware []string
+}
+
+// NewRouteTable creates an empty route table
+func NewRouteTable() *RouteTable {
+	return &RouteTable{}
+}
+
+// Use records the names of middleware applied to every route
+func (t *RouteTable) Use(names ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.middleware = append(t.middleware, names...)
+}
+
+// Add records a single route
+func (t *RouteTable) Add(info RouteInfo) {
+	if info.Auth == "" {
+		info.Auth = AuthNone
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.routes = append(t.routes, info)
+}
+
+// AddGroup records every route of a route group
+func (t *RouteTable) AddGroup(group RouteGroup) {
+	for _, route := range group.Routes {
+		t.Add(RouteInfo{
+			Method: route.Method,
+			Path:   group.Prefix + route.Path,
+			Name:   route.Name,
+			Auth:   route.Auth,
+			Doc:    route.Doc,
+		})
+	}
+}
+
+// Routes returns the recorded routes sorted by path and method
+func (t *RouteTable) Routes() []RouteInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	routes := make([]RouteInfo, len(t.routes))
+	for i, route := range t.routes {
+		route.Middleware = append(append([]string{}, t.middleware...), route.Middleware...)
+		routes[i] = route
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Lookup returns the route registered under the given name
+func (t *RouteTable) Lookup(name string) (RouteInfo, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, route := range t.routes {
+		if route.Name != "" && route.Name == name {
+			return route, true
+		}
+	}
+	return RouteInfo{}, false
+}
+
+// URL builds the path of a named route from key/value parameter pairs
+func (t *RouteTable) URL(name string, pairs ...string) (string, error) {
+	route, ok := t.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+	return BuildPath(route.Path, pairs...)
+}
+
+// Print writes the route table as aligned text columns
+func (t *RouteTable) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tAUTH\tMIDDLEWARE")
+	for _, route := range t.Routes() {
+		middleware := strings.Join(route.Middleware, ",")
+		if middleware == "" {
+			middleware = "-"
+		}
+		name := route.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", route.Method, route.Path, name, route.Auth, middleware)
+	}
+	return tw.Flush()
+}
+
+// BuildPath fills the parameters of a mux path template ({id} or {id:[0-9]+})
+// from key/value pairs.
+func BuildPath(template string, pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", errors.New("parameters must be key/value pairs")
+	}
+
+	values := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		values[pairs[i]] = pairs[i+1]
+	}
+
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if idx := strings.Index(key, ":"); idx >= 0 {
+			key = key[:idx]
+		}
+
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("missing value for parameter %q", key)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+
+	return strings.Join(segments, "/"), nil
+}
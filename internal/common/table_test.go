@@ -0,0 +1,110 @@
+package common
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		pairs    []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "mux parameter",
+			template: "/users/{id}",
+			pairs:    []string{"id", "42"},
+			expected: "/users/42",
+		},
+		{
+			name:     "mux parameter with pattern",
+			template: "/users/{id:[0-9]+}/posts/{post}",
+			pairs:    []string{"id", "1", "post", "hello world"},
+			expected: "/users/1/posts/hello%20world",
+		},
+		{
+			name:     "missing parameter",
+			template: "/add/{a}/{b}",
+			pairs:    []string{"a", "1"},
+			wantErr:  true,
+		},
+		{
+			name:     "odd pairs",
+			template: "/users/{id}",
+			pairs:    []string{"id"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := BuildPath(tt.template, tt.pairs...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestRouteTable(t *testing.T) {
+	table := NewRouteTable()
+	table.Use("logger")
+	table.AddGroup(RouteGroup{
+		Prefix: "/users",
+		Routes: []Route{
+			NamedRoute("/{id}", "GET", "users.get", nil),
+			NamedRoute("", "POST", "users.create", nil),
+		},
+	})
+	table.Add(RouteInfo{Method: "GET", Path: "/private", Name: "private.get", Auth: AuthJWT, Middleware: []string{"jwt"}})
+
+	routes := table.Routes()
+	assert.Len(t, routes, 3)
+	assert.Equal(t, "/private", routes[0].Path)
+	assert.Equal(t, []string{"logger", "jwt"}, routes[0].Middleware)
+	assert.Equal(t, AuthNone, routes[1].Auth)
+
+	path, err := table.URL("users.get", "id", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/3", path)
+
+	_, err = table.URL("users.unknown")
+	assert.ErrorIs(t, err, ErrRouteNotFound)
+
+	var out bytes.Buffer
+	assert.NoError(t, table.Print(&out))
+	assert.Contains(t, out.String(), "users.create")
+}
+
+func TestURLFor(t *testing.T) {
+	router := mux.NewRouter()
+	RegisterGroup(router, RouteGroup{
+		Prefix: "/users",
+		Routes: []Route{
+			NamedRoute("/{id}", "GET", "users.get", func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	})
+
+	path, err := URLFor(router, "users.get", "id", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/5", path)
+
+	_, err = URLFor(router, "users.missing")
+	assert.ErrorIs(t, err, ErrRouteNotFound)
+
+	// The route still serves requests normally
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/5", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
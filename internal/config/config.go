@@ -0,0 +1,298 @@
+// Package config loads the typed application configuration.
+//
+// Values are layered in the following precedence, lowest first:
+// defaults, config file (YAML or TOML), .env file, environment variables and
+// command line flags. Every value is validated once loading is complete.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// DefaultJWTSecret is the development signing secret used when none is configured
+const DefaultJWTSecret = "your-super-secret-jwt-key-at-least-32-characters-long-for-hs256"
+
+// Span exporters
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+	TracingMemory = "memory"
+)
+
+// Calculation history stores
+const (
+	HistoryMemory = "memory"
+	HistorySQLite = "sqlite"
+)
+
+// Environment profiles
+const (
+	EnvDev  = "dev"
+	EnvTest = "test"
+	EnvProd = "prod"
+)
+
+// Config holds the application configuration
+type Config struct {
+	Env         string            `yaml:"env" toml:"env" env:"APP_ENV" flag:"env" usage:"Environment profile: dev, test or prod"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Calculator  CalculatorConfig  `yaml:"calculator" toml:"calculator"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port            int           `yaml:"port" toml:"port" env:"PORT" flag:"port" usage:"HTTP listen port"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"Grace period for draining requests and stopping components, after which the process exits"`
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"DRAIN_DELAY" flag:"drain-delay" usage:"Time between failing readiness and closing the listener on shutdown"`
+}
+
+// AuthConfig configures JWT authentication
+type AuthConfig struct {
+	JWTSecret     string        `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	JWTAlgorithm  string        `yaml:"jwt_algorithm" toml:"jwt_algorithm" env:"JWT_ALGORITHM" flag:"jwt-algorithm" usage:"JWT signing algorithm"`
+	JWTExpiration time.Duration `yaml:"jwt_expiration" toml:"jwt_expiration" env:"JWT_EXPIRATION" flag:"jwt-expiration" usage:"JWT lifetime, in seconds or as a duration such as 1h"`
+}
+
+// CORSConfig configures cross-origin requests
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins" toml:"allow_origins" env:"CORS_ALLOW_ORIGINS" flag:"cors-allow-origins" usage:"Comma-separated list of allowed origins"`
+}
+
+// LogConfig configures structured logging
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"Log level: debug, info, warn or error"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"Log format: json or text"`
+}
+
+// TracingConfig configures distributed tracing
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"Span exporter: none, stdout, otlp or memory"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"OTLP_ENDPOINT" flag:"otlp-endpoint" usage:"OTLP/HTTP traces endpoint"`
+	ServiceName  string `yaml:"service_name" toml:"service_name" env:"SERVICE_NAME" flag:"service-name" usage:"Service name reported on spans"`
+}
+
+// HealthConfig configures the health checks
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" flag:"health-check-timeout" usage:"Default timeout of a single health check"`
+	CacheTTL     time.Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"HEALTH_CACHE_TTL" flag:"health-cache-ttl" usage:"How long health check results are reused"`
+}
+
+// IdempotencyConfig configures Idempotency-Key handling
+type IdempotencyConfig struct {
+	TTL          time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"How long responses are kept for replay to retries with the same Idempotency-Key"`
+	Routes       []string      `yaml:"routes" toml:"routes" env:"IDEMPOTENCY_ROUTES" flag:"idempotency-routes" usage:"Comma-separated names of the routes honoring Idempotency-Key"`
+	MaxBodyBytes int           `yaml:"max_body_bytes" toml:"max_body_bytes" env:"IDEMPOTENCY_MAX_BODY_BYTES" flag:"idempotency-max-body-bytes" usage:"Largest request body read to fingerprint a request with an Idempotency-Key"`
+}
+
+// CalculatorConfig configures the calculator endpoints
+type CalculatorConfig struct {
+	MaxBatchSize   int           `yaml:"max_batch_size" toml:"max_batch_size" env:"CALCULATOR_MAX_BATCH_SIZE" flag:"calculator-max-batch-size" usage:"Most items accepted in one POST /calculator/batch"`
+	BatchWorkers   int           `yaml:"batch_workers" toml:"batch_workers" env:"CALCULATOR_BATCH_WORKERS" flag:"calculator-batch-workers" usage:"Goroutines sharing the items of a large batch"`
+	HistoryStore   string        `yaml:"history_store" toml:"history_store" env:"CALCULATOR_HISTORY_STORE" flag:"calculator-history-store" usage:"Calculation history storage: memory or sqlite"`
+	HistoryDSN     string        `yaml:"history_dsn" toml:"history_dsn" env:"CALCULATOR_HISTORY_DSN" flag:"calculator-history-dsn" usage:"SQLite database of the calculation history"`
+	HistoryLimit   int           `yaml:"history_limit" toml:"history_limit" env:"CALCULATOR_HISTORY_LIMIT" flag:"calculator-history-limit" usage:"Calculations kept per user, the oldest are dropped first"`
+	MaxMatrixSize  int           `yaml:"max_matrix_size" toml:"max_matrix_size" env:"CALCULATOR_MAX_MATRIX_SIZE" flag:"calculator-max-matrix-size" usage:"Most rows, columns or vector elements accepted by /calculator/matrix"`
+	WSMessageRate  int           `yaml:"ws_message_rate" toml:"ws_message_rate" env:"CALCULATOR_WS_MESSAGE_RATE" flag:"calculator-ws-message-rate" usage:"Messages per second accepted on one /calculator/ws connection"`
+	WSMessageBurst int           `yaml:"ws_message_burst" toml:"ws_message_burst" env:"CALCULATOR_WS_MESSAGE_BURST" flag:"calculator-ws-message-burst" usage:"Messages a /calculator/ws connection may send at once before ws_message_rate applies"`
+	WSPingInterval time.Duration `yaml:"ws_ping_interval" toml:"ws_ping_interval" env:"CALCULATOR_WS_PING_INTERVAL" flag:"calculator-ws-ping-interval" usage:"Interval of the pings keeping /calculator/ws connections alive; connections missing two pongs are closed"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
+		Env: EnvDev,
+		Server: ServerConfig{
+			Port:            8000,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			JWTSecret:     DefaultJWTSecret,
+			JWTAlgorithm:  "HS256",
+			JWTExpiration: time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:     TracingNone,
+			OTLPEndpoint: "http://localhost:4318/v1/traces",
+			ServiceName:  "go-template",
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+			CacheTTL:     time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Rules: []string{
+				"auth.login=10/1m key=ip algorithm=sliding_window",
+				"users.create=30/1m burst=10 key=ip",
+				"customers.create=30/1m burst=10 key=ip",
+			},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:          24 * time.Hour,
+			Routes:       []string{"users.create", "customers.create"},
+			MaxBodyBytes: 1 << 20,
+		},
+		Calculator: CalculatorConfig{
+			MaxBatchSize:   1000,
+			BatchWorkers:   4,
+			HistoryStore:   HistoryMemory,
+			HistoryDSN:     "file:history.db",
+			HistoryLimit:   1000,
+			MaxMatrixSize:  100,
+			WSMessageRate:  10,
+			WSMessageBurst: 20,
+			WSPingInterval: 30 * time.Second,
+		},
+	}
+}
+
+// Validate checks every value and reports all problems at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Env {
+	case EnvDev, EnvTest, EnvProd:
+	default:
+		errs = append(errs, fmt.Errorf("env: must be one of dev, test or prod, got %q", c.Env))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdown_timeout: must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if c.Server.DrainDelay < 0 || c.Server.DrainDelay >= c.Server.ShutdownTimeout {
+		errs = append(errs, fmt.Errorf("server.drain_delay: must be between 0 and server.shutdown_timeout, got %s", c.Server.DrainDelay))
+	}
+
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("auth.jwt_secret: is required"))
+	}
+	if c.Auth.JWTAlgorithm != "HS256" {
+		errs = append(errs, fmt.Errorf("auth.jwt_algorithm: only HS256 is supported, got %q", c.Auth.JWTAlgorithm))
+	}
+	if c.Auth.JWTExpiration < time.Second {
+		errs = append(errs, fmt.Errorf("auth.jwt_expiration: must be at least 1s, got %s", c.Auth.JWTExpiration))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("log.format: must be json or text, got %q", c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingNone, TracingStdout, TracingMemory:
+	case TracingOTLP:
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("tracing.otlp_endpoint: must be an http or https URL, got %q", c.Tracing.OTLPEndpoint))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: must be none, stdout, otlp or memory, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.service_name: is required"))
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.check_timeout: must be positive, got %s", c.Health.CheckTimeout))
+	}
+	if c.Health.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("health.cache_ttl: must not be negative, got %s", c.Health.CacheTTL))
+	}
+
+	errs = append(errs, c.RateLimit.validate()...)
+
+	if c.Idempotency.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("idempotency.ttl: must be positive, got %s", c.Idempotency.TTL))
+	}
+	if c.Idempotency.MaxBodyBytes < 1 {
+		errs = append(errs, fmt.Errorf("idempotency.max_body_bytes: must be at least 1, got %d", c.Idempotency.MaxBodyBytes))
+	}
+
+	if c.Calculator.MaxBatchSize < 1 {
+		errs = append(errs, fmt.Errorf("calculator.max_batch_size: must be at least 1, got %d", c.Calculator.MaxBatchSize))
+	}
+	if c.Calculator.BatchWorkers < 1 {
+		errs = append(errs, fmt.Errorf("calculator.batch_workers: must be at least 1, got %d", c.Calculator.BatchWorkers))
+	}
+	switch c.Calculator.HistoryStore {
+	case HistoryMemory:
+	case HistorySQLite:
+		if c.Calculator.HistoryDSN == "" {
+			errs = append(errs, errors.New("calculator.history_dsn: is required by the sqlite history store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("calculator.history_store: must be memory or sqlite, got %q", c.Calculator.HistoryStore))
+	}
+	if c.Calculator.HistoryLimit < 1 {
+		errs = append(errs, fmt.Errorf("calculator.history_limit: must be at least 1, got %d", c.Calculator.HistoryLimit))
+	}
+	if c.Calculator.MaxMatrixSize < 1 {
+		errs = append(errs, fmt.Errorf("calculator.max_matrix_size: must be at least 1, got %d", c.Calculator.MaxMatrixSize))
+	}
+	if c.Calculator.WSMessageRate < 1 {
+		errs = append(errs, fmt.Errorf("calculator.ws_message_rate: must be at least 1, got %d", c.Calculator.WSMessageRate))
+	}
+	if c.Calculator.WSMessageBurst < 1 {
+		errs = append(errs, fmt.Errorf("calculator.ws_message_burst: must be at least 1, got %d", c.Calculator.WSMessageBurst))
+	}
+	if c.Calculator.WSPingInterval < time.Second {
+		errs = append(errs, fmt.Errorf("calculator.ws_ping_interval: must be at least 1s, got %s", c.Calculator.WSPingInterval))
+	}
+
+	// Production refuses to boot with insecure settings
+	if c.Env == EnvProd {
+		for _, finding := range c.SelfCheck().Findings {
+			if finding.Severity == SeverityCritical {
+				errs = append(errs, fmt.Errorf("%s: %s (not allowed in prod)", finding.Setting, finding.Message))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// ValidationError lists every invalid configuration value
+type ValidationError struct {
+	Errors []error
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	msg := "invalid configuration:"
+	for _, err := range e.Errors {
+		msg += "\n  - " + err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the individual validation errors
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
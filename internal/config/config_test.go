@@ -0,0 +1,307 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes content to a file in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// clearEnv unsets the variables read by Load for the duration of the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"APP_ENV", "CORS_ALLOW_ORIGINS", "PORT", "SHUTDOWN_TIMEOUT", "DRAIN_DELAY", "JWT_SECRET", "JWT_ALGORITHM", "JWT_EXPIRATION", "CONFIG_FILE", "ENV_FILE", "TRACING_EXPORTER", "OTLP_ENDPOINT", "SERVICE_NAME", "HEALTH_CHECK_TIMEOUT", "HEALTH_CACHE_TTL", "RATE_LIMIT_ENABLED", "TRUSTED_PROXIES", "RATE_LIMIT_RULES", "IDEMPOTENCY_TTL", "IDEMPOTENCY_ROUTES", "IDEMPOTENCY_MAX_BODY_BYTES", "CALCULATOR_MAX_BATCH_SIZE", "CALCULATOR_BATCH_WORKERS", "CALCULATOR_HISTORY_STORE", "CALCULATOR_HISTORY_DSN", "CALCULATOR_HISTORY_LIMIT", "CALCULATOR_MAX_MATRIX_SIZE", "CALCULATOR_WS_MESSAGE_RATE", "CALCULATOR_WS_MESSAGE_BURST", "CALCULATOR_WS_PING_INTERVAL"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	envFile := writeFile(t, ".env", "")
+
+	cfg, rest, err := Load([]string{"-env-file", envFile, "extra"})
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+	assert.Equal(t, []string{"extra"}, rest)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+
+	yamlFile := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+  shutdown_timeout: 10s
+auth:
+  jwt_expiration: 2h
+`)
+	envFile := writeFile(t, ".env", "PORT=9100\nJWT_EXPIRATION=60\n")
+	t.Setenv("PORT", "9200")
+
+	cfg, _, err := Load([]string{"-config", yamlFile, "-env-file", envFile, "-shutdown-timeout", "5s"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9200, cfg.Server.Port)                     // env beats .env and file
+	assert.Equal(t, 5*time.Second, cfg.Server.ShutdownTimeout) // flag beats file
+	assert.Equal(t, 60*time.Second, cfg.Auth.JWTExpiration)    // .env beats file
+	assert.Equal(t, DefaultJWTSecret, cfg.Auth.JWTSecret)      // default kept
+	assert.Equal(t, "HS256", cfg.Auth.JWTAlgorithm)
+}
+
+func TestLoadTOML(t *testing.T) {
+	clearEnv(t)
+
+	tomlFile := writeFile(t, "config.toml", `
+[server]
+port = 7000
+
+[auth]
+jwt_secret = "toml-secret-that-is-long-enough-for-hs256"
+`)
+	cfg, _, err := Load([]string{"-config", tomlFile, "-env-file", writeFile(t, ".env", "")})
+	require.NoError(t, err)
+	assert.Equal(t, 7000, cfg.Server.Port)
+	assert.Equal(t, "toml-secret-that-is-long-enough-for-hs256", cfg.Auth.JWTSecret)
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errors  []string
+	}{
+		{
+			name:    "config.yaml",
+			content: "auth:\n  jwt_secrte: typo\nserver:\n  shutdown_timeout: 30\nverbose: true\n",
+			errors:  []string{"field jwt_secrte not found", "into time.Duration", "field verbose not found"},
+		},
+		{
+			name:    "config.toml",
+			content: "verbose = true\n\n[auth]\njwt_secrte = \"typo\"\n\n[server]\nshutdown_timeout = 30\n",
+			errors:  []string{"unknown key auth.jwt_secrte", "server.shutdown_timeout: write the duration as a string", "unknown key verbose"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			path := writeFile(t, tt.name, tt.content)
+
+			_, _, err := Load([]string{"-config", path, "-env-file", writeFile(t, ".env", "")})
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr), "error = %v", err)
+			assert.Len(t, validationErr.Errors, len(tt.errors))
+			for _, msg := range tt.errors {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("JWT_EXPIRATION", "soon")
+	t.Setenv("PORT", "eighty")
+
+	_, _, err := Load([]string{"-env-file", writeFile(t, ".env", "")})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Errors, 2)
+	assert.Contains(t, err.Error(), `JWT_EXPIRATION: invalid duration "soon"`)
+	assert.Contains(t, err.Error(), `PORT: invalid integer "eighty"`)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Auth.JWTAlgorithm = "RS256"
+	cfg.Tracing.Exporter = "jaeger"
+	cfg.Idempotency.TTL = 0
+	cfg.Idempotency.MaxBodyBytes = 0
+	cfg.Calculator.MaxBatchSize = 0
+	cfg.Calculator.HistoryStore = "redis"
+	cfg.Calculator.MaxMatrixSize = -1
+	cfg.Calculator.WSPingInterval = time.Millisecond
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "auth.jwt_algorithm")
+	assert.Contains(t, err.Error(), "tracing.exporter")
+	assert.Contains(t, err.Error(), "idempotency.ttl")
+	assert.Contains(t, err.Error(), "idempotency.max_body_bytes")
+	assert.Contains(t, err.Error(), "calculator.max_batch_size")
+	assert.Contains(t, err.Error(), "calculator.history_store")
+	assert.Contains(t, err.Error(), "calculator.max_matrix_size")
+	assert.Contains(t, err.Error(), "calculator.ws_ping_interval")
+
+	cfg = Default()
+	cfg.Tracing.Exporter = TracingOTLP
+	cfg.Tracing.OTLPEndpoint = "localhost:4318"
+	assert.ErrorContains(t, cfg.Validate(), "tracing.otlp_endpoint")
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	cfg := Default()
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Dump(&out))
+	assert.NotContains(t, out.String(), DefaultJWTSecret)
+	assert.Contains(t, out.String(), redacted)
+	assert.Contains(t, out.String(), "port: 8000")
+
+	// The original configuration is left untouched
+	assert.Equal(t, DefaultJWTSecret, cfg.Auth.JWTSecret)
+}
+
+func TestSelfCheck(t *testing.T) {
+	cfg := Default()
+
+	report := cfg.SelfCheck()
+	settings := make(map[string]string)
+	for _, f := range report.Findings {
+		settings[f.Setting] = f.Severity
+	}
+	assert.Equal(t, SeverityCritical, settings["auth.jwt_secret"])
+	assert.Equal(t, SeverityCritical, settings["cors.allow_origins"])
+
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "built-in development default")
+
+	cfg.Auth.JWTSecret = "a-production-secret-that-is-long-enough"
+	cfg.CORS.AllowOrigins = []string{"https://app.example.com"}
+	assert.Empty(t, cfg.SelfCheck().Findings)
+}
+
+func TestProdRefusesInsecureSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		message string
+	}{
+		{
+			name:    "default secret",
+			modify:  func(cfg *Config) {},
+			message: "built-in development default",
+		},
+		{
+			name:    "weak secret",
+			modify:  func(cfg *Config) { cfg.Auth.JWTSecret = "short" },
+			message: "shorter than 32 bytes",
+		},
+		{
+			name:    "missing secret",
+			modify:  func(cfg *Config) { cfg.Auth.JWTSecret = "" },
+			message: "JWT secret is missing",
+		},
+		{
+			name: "wildcard CORS",
+			modify: func(cfg *Config) {
+				cfg.Auth.JWTSecret = "a-production-secret-that-is-long-enough"
+				cfg.CORS.AllowOrigins = []string{"https://app.example.com", "*"}
+			},
+			message: "CORS allows any origin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Env = EnvProd
+			cfg.CORS.AllowOrigins = []string{"https://app.example.com"}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+
+	// The same settings are only reported in dev
+	cfg := Default()
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadProdProfile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("JWT_SECRET", "a-production-secret-that-is-long-enough")
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example.com, https://admin.example.com")
+
+	cfg, _, err := Load([]string{"-env-file", writeFile(t, ".env", "")})
+	require.NoError(t, err)
+	assert.Equal(t, EnvProd, cfg.Env)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, cfg.CORS.AllowOrigins)
+}
+
+func TestParseRateLimitRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RateLimitRule
+		wantErr string
+	}{
+		{
+			in:   "auth.login=10/1m key=ip algorithm=sliding_window",
+			want: RateLimitRule{Target: "auth.login", Limit: 10, Period: time.Minute, Burst: 10, Key: KeyIP, Algorithm: AlgorithmSlidingWindow},
+		},
+		{
+			in:   "users.*=5/s burst=20 key=subject",
+			want: RateLimitRule{Target: "users.*", Limit: 5, Period: time.Second, Burst: 20, Key: KeySubject, Algorithm: AlgorithmTokenBucket},
+		},
+		{
+			in:   "*=100/30",
+			want: RateLimitRule{Target: "*", Limit: 100, Period: 30 * time.Second, Burst: 100, Key: KeyIP, Algorithm: AlgorithmTokenBucket},
+		},
+		{in: "auth.login", wantErr: "expected <target>=<limit>/<period>"},
+		{in: "auth.login=10", wantErr: "expected <limit>/<period>"},
+		{in: "auth.login=0/1m", wantErr: "limit must be a positive integer"},
+		{in: "auth.login=10/soon", wantErr: "invalid period"},
+		{in: "auth.login=10/1m key=cookie", wantErr: "key must be"},
+		{in: "auth.login=10/1m algorithm=leaky", wantErr: "algorithm must be"},
+		{in: "auth.login=10/1m burst=-1", wantErr: "burst must be"},
+		{in: "auth.login=10/1m color=red", wantErr: "unknown option"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRateLimitRule(tt.in)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	cfg := Default()
+	cfg.RateLimit.Rules = []string{"users.create=10/1m", "broken"}
+	cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1", "proxy.local"}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `rate_limit.rules: rule "broken"`)
+	assert.Contains(t, err.Error(), `rate_limit.trusted_proxies: invalid IP or CIDR "proxy.local"`)
+
+	assert.Len(t, cfg.RateLimit.ParsedRules(), 1)
+	assert.Len(t, cfg.RateLimit.TrustedPrefixes(), 2)
+}
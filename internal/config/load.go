@@ -0,0 +1,306 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values in dumps
+const redacted = "[REDACTED]"
+
+// durationType is the type of duration settings
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a single configurable value discovered from the struct tags
+type field struct {
+	path   string // dotted file key, e.g. server.port
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// Load builds the configuration from the command line arguments (without the
+// program name) and the process environment. It returns the arguments left
+// once flags are parsed.
+//
+// The config file is taken from -config or CONFIG_FILE and the .env file from
+// -env-file or ENV_FILE, defaulting to .env in the working directory.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", "", "Path to a YAML or TOML config file")
+	envFile := fs.String("env-file", "", "Path to a .env file (default .env)")
+	flagValues := make(map[string]*string)
+	for _, f := range fields {
+		if f.flag != "" {
+			flagValues[f.flag] = fs.String(f.flag, "", f.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// Config file
+	var errs []error
+	path := firstNonEmpty(*configFile, os.Getenv("CONFIG_FILE"))
+	if path != "" {
+		fileErrs, err := loadFile(cfg, fields, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		errs = append(errs, fileErrs...)
+	}
+
+	// .env file, then the process environment
+	dotEnvPath := firstNonEmpty(*envFile, os.Getenv("ENV_FILE"))
+	dotEnv, err := readDotEnv(dotEnvPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, lookup := range []func(string) (string, bool){
+		func(key string) (string, bool) { v, ok := dotEnv[key]; return v, ok },
+		os.LookupEnv,
+	} {
+		for _, f := range fields {
+			if f.env == "" {
+				continue
+			}
+			if raw, ok := lookup(f.env); ok {
+				if err := setValue(f.value, raw); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+				}
+			}
+		}
+	}
+
+	// Command line flags explicitly set
+	set := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	for _, f := range fields {
+		if f.flag != "" && set[f.flag] {
+			if err := setValue(f.value, *flagValues[f.flag]); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.flag, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, &ValidationError{Errors: errs}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile decodes a YAML or TOML file, chosen by extension, over cfg. It
+// returns the invalid values of the file, such as unknown keys, to be
+// reported with the other configuration errors.
+//
+// Durations are written as strings ("30s", "1h"): YAML rejects integer
+// durations and TOML would read them as nanoseconds, unlike the whole
+// seconds of the environment and flags, so both are refused.
+func loadFile(cfg *Config, fields []field, path string) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var problems []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		var typeErr *yaml.TypeError
+		switch {
+		case errors.Is(err, io.EOF):
+			// An empty file keeps the defaults
+			err = nil
+		case errors.As(err, &typeErr):
+			problems, err = typeErr.Errors, nil
+		}
+	case ".toml":
+		var md toml.MetaData
+		if md, err = toml.Decode(string(data), cfg); err != nil {
+			break
+		}
+		for _, key := range md.Undecoded() {
+			problems = append(problems, fmt.Sprintf("unknown key %s", key))
+		}
+		for _, f := range fields {
+			if f.value.Type() == durationType && md.Type(strings.Split(f.path, ".")...) == "Integer" {
+				problems = append(problems, fmt.Sprintf("%s: write the duration as a string such as \"30s\"", f.path))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	errs := make([]error, len(problems))
+	for i, problem := range problems {
+		errs[i] = fmt.Errorf("config file %s: %s", path, problem)
+	}
+	return errs, nil
+}
+
+// readDotEnv reads a .env file. A missing default .env file is not an error.
+func readDotEnv(path string) (map[string]string, error) {
+	explicit := path != ""
+	if !explicit {
+		path = ".env"
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("env file: %w", err)
+	}
+	return values, nil
+}
+
+// collectFields walks the config struct and returns every leaf value
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("yaml")
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			fields = append(fields, collectFields(fv, path)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			flag:   sf.Tag.Get("flag"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  fv,
+		})
+	}
+	return fields
+}
+
+// setValue parses raw into the config value according to its type
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts whole seconds (3600) or Go durations (1h)
+func parseDuration(raw string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", raw)
+	}
+	return d, nil
+}
+
+// Redacted returns a copy of the configuration with every secret masked
+func (c *Config) Redacted() *Config {
+	clone := *c
+	for _, f := range collectFields(reflect.ValueOf(&clone).Elem(), "") {
+		if f.secret && f.value.Kind() == reflect.String && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+	return &clone
+}
+
+// Dump writes the redacted configuration as YAML
+func (c *Config) Dump(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
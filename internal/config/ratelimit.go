@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit algorithms
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Rate limit keys
+const (
+	KeyIP      = "ip"
+	KeySubject = "subject"
+	KeyAPIKey  = "api_key"
+)
+
+// RateLimitConfig configures request rate limiting
+type RateLimitConfig struct {
+	Enabled        bool     `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit-enabled" usage:"Enable rate limiting"`
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" flag:"trusted-proxies" usage:"Comma-separated proxy IPs or CIDRs whose X-Forwarded-For is trusted"`
+	Rules          []string `yaml:"rules" toml:"rules" env:"RATE_LIMIT_RULES" flag:"rate-limit-rules" usage:"Comma-separated rules such as 'auth.login=10/1m key=ip algorithm=sliding_window'"`
+}
+
+// RateLimitRule limits the requests of a route, a group of routes or every route
+type RateLimitRule struct {
+	// Target is a route name (users.create), a group (users.*) or * for every route
+	Target    string
+	Limit     int
+	Period    time.Duration
+	Burst     int
+	Key       string
+	Algorithm string
+}
+
+// String formats the rule in the syntax ParseRateLimitRule accepts
+func (r RateLimitRule) String() string {
+	s := fmt.Sprintf("%s=%d/%s key=%s algorithm=%s", r.Target, r.Limit, r.Period, r.Key, r.Algorithm)
+	if r.Algorithm == AlgorithmTokenBucket {
+		s += fmt.Sprintf(" burst=%d", r.Burst)
+	}
+	return s
+}
+
+// ParseRateLimitRule parses a rule written as
+//
+//	<target>=<limit>/<period> [key=ip|subject|api_key] [algorithm=token_bucket|sliding_window] [burst=<n>]
+//
+// The key defaults to ip, the algorithm to token_bucket and the burst to the
+// limit. The subject and api_key keys use the authenticated subject and the
+// API key an authenticator validated, never raw request headers, and fall
+// back to ip for requests that have none.
+func ParseRateLimitRule(s string) (RateLimitRule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return RateLimitRule{}, fmt.Errorf("empty rule")
+	}
+
+	target, rate, ok := strings.Cut(fields[0], "=")
+	if !ok || target == "" {
+		return RateLimitRule{}, fmt.Errorf("rule %q: expected <target>=<limit>/<period>", s)
+	}
+	count, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("rule %q: expected <limit>/<period>", s)
+	}
+
+	rule := RateLimitRule{Target: target, Key: KeyIP, Algorithm: AlgorithmTokenBucket}
+	var err error
+	if rule.Limit, err = strconv.Atoi(count); err != nil || rule.Limit < 1 {
+		return RateLimitRule{}, fmt.Errorf("rule %q: limit must be a positive integer", s)
+	}
+	// A bare unit such as /s or /m means one of it
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	if rule.Period, err = parseDuration(period); err != nil || rule.Period <= 0 {
+		return RateLimitRule{}, fmt.Errorf("rule %q: invalid period %q", s, period)
+	}
+
+	for _, option := range fields[1:] {
+		name, value, _ := strings.Cut(option, "=")
+		switch name {
+		case "key":
+			if value != KeyIP && value != KeySubject && value != KeyAPIKey {
+				return RateLimitRule{}, fmt.Errorf("rule %q: key must be ip, subject or api_key", s)
+			}
+			rule.Key = value
+		case "algorithm":
+			if value != AlgorithmTokenBucket && value != AlgorithmSlidingWindow {
+				return RateLimitRule{}, fmt.Errorf("rule %q: algorithm must be token_bucket or sliding_window", s)
+			}
+			rule.Algorithm = value
+		case "burst":
+			if rule.Burst, err = strconv.Atoi(value); err != nil || rule.Burst < 1 {
+				return RateLimitRule{}, fmt.Errorf("rule %q: burst must be a positive integer", s)
+			}
+		default:
+			return RateLimitRule{}, fmt.Errorf("rule %q: unknown option %q", s, name)
+		}
+	}
+	if rule.Burst == 0 {
+		rule.Burst = rule.Limit
+	}
+	return rule, nil
+}
+
+// ParsedRules returns the rules of a validated configuration, skipping the
+// invalid ones Validate reports
+func (c RateLimitConfig) ParsedRules() []RateLimitRule {
+	var rules []RateLimitRule
+	for _, s := range c.Rules {
+		if rule, err := ParseRateLimitRule(s); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// TrustedPrefixes returns the trusted proxies of a validated configuration,
+// a single IP becoming a one-address prefix
+func (c RateLimitConfig) TrustedPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, s := range c.TrustedProxies {
+		if prefix, err := parsePrefix(s); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parsePrefix parses a CIDR or a single IP
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// validate reports every invalid rule and trusted proxy
+func (c RateLimitConfig) validate() []error {
+	var errs []error
+	for _, s := range c.Rules {
+		if _, err := ParseRateLimitRule(s); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.rules: %w", err))
+		}
+	}
+	for _, s := range c.TrustedProxies {
+		if _, err := parsePrefix(s); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.trusted_proxies: invalid IP or CIDR %q", s))
+		}
+	}
+	return errs
+}
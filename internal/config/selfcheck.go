@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Finding severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// minSecretLength is the shortest JWT secret accepted as strong, 256 bits for HS256
+const minSecretLength = 32
+
+// Finding is an insecure setting found by the startup self-check
+type Finding struct {
+	Setting  string
+	Severity string
+	Message  string
+}
+
+// Report lists every finding of the startup self-check
+type Report struct {
+	Env      string
+	Findings []Finding
+}
+
+// SelfCheck inspects the configuration for insecure settings. Critical
+// findings prevent the prod profile from booting.
+func (c *Config) SelfCheck() Report {
+	report := Report{Env: c.Env}
+	add := func(setting, severity, message string) {
+		report.Findings = append(report.Findings, Finding{Setting: setting, Severity: severity, Message: message})
+	}
+
+	switch {
+	case c.Auth.JWTSecret == "":
+		add("auth.jwt_secret", SeverityCritical, "JWT secret is missing")
+	case c.Auth.JWTSecret == DefaultJWTSecret:
+		add("auth.jwt_secret", SeverityCritical, "JWT secret is the built-in development default")
+	case len(c.Auth.JWTSecret) < minSecretLength:
+		add("auth.jwt_secret", SeverityCritical, fmt.Sprintf("JWT secret is shorter than %d bytes", minSecretLength))
+	}
+
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			add("cors.allow_origins", SeverityCritical, "CORS allows any origin (*)")
+			break
+		}
+	}
+
+	if c.Auth.JWTExpiration > 24*time.Hour {
+		add("auth.jwt_expiration", SeverityWarning, fmt.Sprintf("JWT tokens live longer than a day (%s)", c.Auth.JWTExpiration))
+	}
+
+	return report
+}
+
+// Print writes the report in a human readable form
+func (r Report) Print(w io.Writer) {
+	if len(r.Findings) == 0 {
+		fmt.Fprintf(w, "Security self-check (%s): no insecure settings found\n", r.Env)
+		return
+	}
+
+	fmt.Fprintf(w, "Security self-check (%s): %d insecure setting(s)\n", r.Env, len(r.Findings))
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "  [%s] %s: %s\n", f.Severity, f.Setting, f.Message)
+	}
+}
+
+// Log writes every finding as a warning or error record
+func (r Report) Log(logger *slog.Logger) {
+	for _, f := range r.Findings {
+		level := slog.LevelWarn
+		if f.Severity == SeverityCritical {
+			level = slog.LevelError
+		}
+		logger.Log(context.Background(), level, "insecure setting",
+			"env", r.Env,
+			"setting", f.Setting,
+			"severity", f.Severity,
+			"message", f.Message,
+		)
+	}
+}
@@ -0,0 +1,49 @@
+package correlation
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// Transport propagates the request ID and trace context of the request
+// context to outbound HTTP calls. The span active in the context becomes the
+// parent of the remote span.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip adds the correlation headers before delegating to the base transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	requestID := RequestID(ctx)
+	tc, hasTrace := TraceContextFrom(ctx)
+	if requestID == "" && !hasTrace {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	out := req.Clone(ctx)
+	if requestID != "" && out.Header.Get(common.HeaderRequestID) == "" {
+		out.Header.Set(common.HeaderRequestID, requestID)
+	}
+	if hasTrace && out.Header.Get(HeaderTraceparent) == "" {
+		out.Header.Set(HeaderTraceparent, tc.String())
+	}
+	return base.RoundTrip(out)
+}
+
+// NewClient returns the shared HTTP client for outbound calls. Requests built
+// with http.NewRequestWithContext from a request context carry its correlation.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &Transport{Base: http.DefaultTransport},
+	}
+}
@@ -0,0 +1,174 @@
+// Package correlation ties a request together across logs, error bodies,
+// responses and outbound HTTP calls with X-Request-ID and W3C traceparent.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// HeaderTraceparent is the W3C Trace Context header
+const HeaderTraceparent = "traceparent"
+
+// maxRequestIDLength bounds accepted incoming request IDs
+const maxRequestIDLength = 128
+
+// Errors of ParseTraceparent
+var (
+	ErrInvalidTraceparent = errors.New("invalid traceparent")
+)
+
+// TraceContext is a W3C trace context: the trace, the parent span and flags
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// NewTraceContext starts a new sampled trace
+func NewTraceContext() TraceContext {
+	var tc TraceContext
+	_, _ = rand.Read(tc.TraceID[:])
+	_, _ = rand.Read(tc.SpanID[:])
+	tc.Flags = 0x01
+	return tc
+}
+
+// Child returns a trace context in the same trace with a new span ID
+func (tc TraceContext) Child() TraceContext {
+	child := tc
+	_, _ = rand.Read(child.SpanID[:])
+	return child
+}
+
+// TraceIDString returns the hex encoded trace ID
+func (tc TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+// SpanIDString returns the hex encoded span ID
+func (tc TraceContext) SpanIDString() string {
+	return hex.EncodeToString(tc.SpanID[:])
+}
+
+// String formats the trace context as a version 00 traceparent header
+func (tc TraceContext) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceIDString(), tc.SpanIDString(), tc.Flags)
+}
+
+// ParseTraceparent parses a traceparent header value
+func ParseTraceparent(value string) (TraceContext, error) {
+	var tc TraceContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, ErrInvalidTraceparent
+	}
+	// Version ff is forbidden and version 00 has exactly four fields
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return tc, ErrInvalidTraceparent
+	}
+
+	var version [1]byte
+	if !decodeHex(version[:], parts[0]) || !decodeHex(tc.TraceID[:], parts[1]) || !decodeHex(tc.SpanID[:], parts[2]) {
+		return tc, ErrInvalidTraceparent
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) {
+		return tc, ErrInvalidTraceparent
+	}
+	tc.Flags = flags[0]
+
+	if tc.TraceID == ([16]byte{}) || tc.SpanID == ([8]byte{}) {
+		return tc, ErrInvalidTraceparent
+	}
+	return tc, nil
+}
+
+// decodeHex decodes lowercase hex into dst, which must match its length
+func decodeHex(dst []byte, s string) bool {
+	if strings.ToLower(s) != s {
+		return false
+	}
+	n, err := hex.Decode(dst, []byte(s))
+	return err == nil && n == len(dst)
+}
+
+// traceKey is the context key of the request trace context
+type traceKey struct{}
+
+// WithTraceContext returns a copy of ctx carrying the trace context
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// TraceContextFrom returns the trace context stored in ctx
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// RequestID returns the request ID stored in ctx
+func RequestID(ctx context.Context) string {
+	if state := common.State(ctx); state != nil {
+		return state.RequestID
+	}
+	return ""
+}
+
+// NewRequestID returns a random 128-bit hex identifier
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts short IDs made of visible, header safe characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware accepts or generates the request ID and trace context, stores
+// them in the request context and echoes the request ID in the response
+func Middleware() common.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, state := common.WithState(r.Context())
+
+			requestID := r.Header.Get(common.HeaderRequestID)
+			if !validRequestID(requestID) {
+				requestID = NewRequestID()
+			}
+			state.RequestID = requestID
+
+			tc, err := ParseTraceparent(r.Header.Get(HeaderTraceparent))
+			if err != nil {
+				tc = NewTraceContext()
+			}
+			ctx = WithTraceContext(ctx, tc)
+
+			// Set before the handler runs so error bodies can echo it
+			w.Header().Set(common.HeaderRequestID, requestID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
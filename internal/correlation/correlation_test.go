@@ -0,0 +1,135 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"empty", "", false},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"short trace id", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, err := ParseTraceparent(tt.value)
+			if !tt.valid {
+				assert.ErrorIs(t, err, ErrInvalidTraceparent)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceIDString())
+			assert.Equal(t, "00f067aa0ba902b7", tc.SpanIDString())
+			assert.Equal(t, byte(0x01), tc.Flags)
+		})
+	}
+}
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	tc := NewTraceContext()
+	parsed, err := ParseTraceparent(tc.String())
+	require.NoError(t, err)
+	assert.Equal(t, tc, parsed)
+
+	child := tc.Child()
+	assert.Equal(t, tc.TraceID, child.TraceID)
+	assert.NotEqual(t, tc.SpanID, child.SpanID)
+}
+
+func TestMiddleware(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		wantID      string
+		wantTraceID string
+	}{
+		{"accepts incoming values", "abc-123", traceparent, "abc-123", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"generates missing values", "", "", "", ""},
+		{"replaces invalid values", "bad id\n", "garbage", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID string
+			var gotTrace TraceContext
+			handler := Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = RequestID(r.Context())
+				gotTrace, _ = TraceContextFrom(r.Context())
+				common.WriteBadRequest(w, "nope")
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(common.HeaderRequestID, tt.requestID)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set(HeaderTraceparent, tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, gotID)
+			} else {
+				assert.Len(t, gotID, 32)
+				assert.NotEqual(t, tt.requestID, gotID)
+			}
+			if tt.wantTraceID != "" {
+				assert.Equal(t, tt.wantTraceID, gotTrace.TraceIDString())
+			} else {
+				assert.NotEqual(t, [16]byte{}, gotTrace.TraceID)
+			}
+
+			assert.Equal(t, gotID, rec.Header().Get(common.HeaderRequestID))
+			assert.Contains(t, rec.Body.String(), `"request_id":"`+gotID+`"`)
+		})
+	}
+}
+
+func TestClientPropagatesCorrelation(t *testing.T) {
+	var gotID, gotTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get(common.HeaderRequestID)
+		gotTraceparent = r.Header.Get(HeaderTraceparent)
+	}))
+	defer upstream.Close()
+
+	tc := NewTraceContext()
+	ctx, state := common.WithState(context.Background())
+	state.RequestID = "req-42"
+	ctx = WithTraceContext(ctx, tc)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+	require.NoError(t, err)
+	resp, err := NewClient(0).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-42", gotID)
+	parsed, err := ParseTraceparent(gotTraceparent)
+	require.NoError(t, err)
+	assert.Equal(t, tc, parsed)
+	assert.Empty(t, req.Header.Get(common.HeaderRequestID), "caller's request must not be modified")
+}
@@ -0,0 +1,125 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hook is a component's start and stop logic. Either function may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts components in the order their hooks were appended and
+// stops them in reverse order. Providers append hooks as they are built, so
+// a component always starts after, and stops before, its dependencies.
+type Lifecycle struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+}
+
+// NewLifecycle creates an empty lifecycle
+func NewLifecycle(logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+// Append registers a hook. Hooks appended after Start are not started.
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Go registers a background worker. It runs fn in a goroutine on start and,
+// on stop, cancels the context passed to fn and waits for fn to return.
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context)) {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	l.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			// The worker outlives the start context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("worker did not stop: %w", ctx.Err())
+			}
+		},
+	})
+}
+
+// Start runs every start hook in order. When one fails, the hooks already
+// started are stopped and the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks[l.started:]...)
+	l.mu.Unlock()
+
+	for _, hook := range hooks {
+		if hook.Start != nil {
+			start := time.Now()
+			if err := hook.Start(ctx); err != nil {
+				l.logger.Error("component failed to start", "component", hook.Name, "error", err)
+				if stopErr := l.Stop(ctx); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return fmt.Errorf("start %s: %w", hook.Name, err)
+			}
+			l.logger.Debug("component started", "component", hook.Name, "duration_ms", time.Since(start).Milliseconds())
+		}
+
+		l.mu.Lock()
+		l.started++
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the stop hook of every started component in reverse order. Every
+// hook runs even when an earlier one fails or ctx expires; the errors are
+// joined.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks[:l.started]...)
+	l.started = 0
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := hook.Stop(ctx); err != nil {
+			l.logger.Error("component failed to stop", "component", hook.Name, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.Name, err))
+			continue
+		}
+		l.logger.Debug("component stopped", "component", hook.Name, "duration_ms", time.Since(start).Milliseconds())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHook appends start and stop events of the named component to log
+func recordingHook(name string, log *[]string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			*log = append(*log, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			*log = append(*log, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func newTestLifecycle() *Lifecycle {
+	return NewLifecycle(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestLifecycleRunsHooksInDependencyOrder(t *testing.T) {
+	var log []string
+	l := newTestLifecycle()
+	l.Append(recordingHook("repository", &log, nil))
+	l.Append(recordingHook("service", &log, nil))
+	l.Append(Hook{Name: "no-op"})
+	l.Append(recordingHook("http", &log, nil))
+
+	require.NoError(t, l.Start(context.Background()))
+	require.NoError(t, l.Stop(context.Background()))
+
+	assert.Equal(t, []string{
+		"start repository", "start service", "start http",
+		"stop http", "stop service", "stop repository",
+	}, log)
+
+	// Stopping twice does nothing
+	require.NoError(t, l.Stop(context.Background()))
+	assert.Len(t, log, 6)
+}
+
+func TestLifecycleRollsBackFailedStart(t *testing.T) {
+	var log []string
+	l := newTestLifecycle()
+	l.Append(recordingHook("repository", &log, nil))
+	l.Append(recordingHook("cache", &log, errors.New("connection refused")))
+	l.Append(recordingHook("http", &log, nil))
+
+	err := l.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start cache: connection refused")
+	assert.Equal(t, []string{"start repository", "start cache", "stop repository"}, log)
+}
+
+func TestLifecycleStopRunsEveryHook(t *testing.T) {
+	var log []string
+	l := newTestLifecycle()
+	l.Append(recordingHook("repository", &log, nil))
+	l.Append(Hook{Name: "queue", Start: func(context.Context) error { return nil }, Stop: func(context.Context) error {
+		return errors.New("flush failed")
+	}})
+
+	require.NoError(t, l.Start(context.Background()))
+	err := l.Stop(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stop queue: flush failed")
+	assert.Equal(t, []string{"start repository", "stop repository"}, log)
+}
+
+func TestLifecycleDrainsWorkers(t *testing.T) {
+	l := newTestLifecycle()
+	ticks := make(chan struct{}, 100)
+	drained := false
+	// Stopped last, once the grace period is used up
+	l.Go("stuck", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+	})
+	l.Go("sweeper", func(ctx context.Context) {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				drained = true
+				return
+			case <-ticker.C:
+				ticks <- struct{}{}
+			}
+		}
+	})
+
+	require.NoError(t, l.Start(context.Background()))
+	<-ticks
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := l.Stop(ctx)
+
+	assert.True(t, drained)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stop stuck: worker did not stop")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -1,29 +1,155 @@
 package di
 
 import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/example/go-template/internal/calculator"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+	"github.com/example/go-template/internal/health"
+	"github.com/example/go-template/internal/history"
+	"github.com/example/go-template/internal/idempotency"
+	"github.com/example/go-template/internal/logging"
+	"github.com/example/go-template/internal/metrics"
+	"github.com/example/go-template/internal/ratelimit"
 	"github.com/example/go-template/internal/repositories"
 	"github.com/example/go-template/internal/services"
+	"github.com/example/go-template/internal/tracing"
+	"github.com/example/go-template/internal/user"
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
+// outboundTimeout bounds every call made through the shared HTTP client
+const outboundTimeout = 10 * time.Second
+
+// sweepInterval is how often in-memory stores drop expired entries
+const sweepInterval = time.Minute
+
 // Providers holds all service providers (dependency injection container)
 type Providers struct {
+	Config          *config.Config
+	Logger          *slog.Logger
+	Lifecycle       *Lifecycle
+	HTTPClient      *http.Client
+	Metrics         *prometheus.Registry
+	TracerProvider  *sdktrace.TracerProvider
+	SpanExporter    sdktrace.SpanExporter
+	Health          *health.Health
+	RateLimiter     *ratelimit.Limiter
+	Idempotency     idempotency.Store
+	History         history.Store
+	Sessions        *calculator.Sessions
 	AuthService     *services.AuthService
 	CustomerService *services.CustomerService
 	CustomerRepo    repositories.CustomerRepository
+	UserService     *user.Service
 }
 
-// NewProviders initializes all providers
-func NewProviders() *Providers {
+// NewProviders initializes all providers from the loaded configuration
+func NewProviders(cfg *config.Config) *Providers {
+	logger := logging.New(cfg.Log, os.Stdout)
+	lifecycle := NewLifecycle(logger)
+
+	registry := metrics.NewRegistry()
+	serviceMetrics := services.NewMetrics(registry)
+
+	// The tracer provider stops last, flushing the spans of everything
+	// stopped before it. Spans are exported once it started, so commands
+	// that never serve requests start no exporter goroutine.
+	exporter, exporterErr := tracing.NewExporter(cfg.Tracing, os.Stdout)
+	tracerProvider := tracing.NewProvider(cfg.Tracing.ServiceName)
+	lifecycle.Append(Hook{
+		Name: "tracing",
+		Start: func(context.Context) error {
+			if exporterErr != nil {
+				return exporterErr
+			}
+			if exporter != nil {
+				tracerProvider.RegisterSpanProcessor(tracing.NewProcessor(exporter))
+			}
+			return nil
+		},
+		Stop: tracerProvider.Shutdown,
+	})
+
+	// Outbound calls get a client span and carry the correlation headers
+	httpClient := correlation.NewClient(outboundTimeout)
+	httpClient.Transport = &tracing.Transport{Base: httpClient.Transport}
+
 	// Initialize repositories
 	customerRepo := repositories.NewInMemoryCustomerRepository()
 
+	// Components register the checks behind /livez, /readyz and /startupz
+	checks := health.New(cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
+	checks.Register(health.Check{
+		Name:    "customer_repository",
+		Checker: health.CheckerFunc(customerRepo.Ping),
+		Probes:  []health.Probe{health.Readiness},
+	})
+
+	// Rate limit state lives in memory; swap the store for a shared backend
+	// when running several instances
+	limiter := ratelimit.New(cfg.RateLimit, ratelimit.NewMemoryStore())
+	lifecycle.Go("ratelimit-sweeper", limiter.Run)
+
+	// Stored responses are replayed to retries until idempotency.ttl elapses
+	idempotencyStore := idempotency.NewMemoryStore()
+	lifecycle.Go("idempotency-sweeper", func(ctx context.Context) {
+		idempotencyStore.Run(ctx, sweepInterval)
+	})
+
+	// Calculations of authenticated users, kept per user
+	historyStore := newHistoryStore(cfg.Calculator, lifecycle, checks)
+
+	// WebSocket sessions outlive http.Server.Shutdown, which stops before
+	// this hook, so they are closed here
+	sessions := calculator.NewSessions()
+	lifecycle.Append(Hook{Name: "calculator-sessions", Stop: sessions.Shutdown})
+
 	// Initialize services
-	authService := services.NewAuthService()
-	customerService := services.NewCustomerService(customerRepo)
+	authService := services.NewAuthService(cfg.Auth, serviceMetrics)
+	customerService := services.NewCustomerService(customerRepo, serviceMetrics)
+	userService := user.NewService()
 
 	return &Providers{
+		Config:          cfg,
+		Logger:          logger,
+		Lifecycle:       lifecycle,
+		HTTPClient:      httpClient,
+		Metrics:         registry,
+		TracerProvider:  tracerProvider,
+		SpanExporter:    exporter,
+		Health:          checks,
+		RateLimiter:     limiter,
+		Idempotency:     idempotencyStore,
+		History:         historyStore,
+		Sessions:        sessions,
 		AuthService:     authService,
 		CustomerService: customerService,
 		CustomerRepo:    customerRepo,
+		UserService:     userService,
 	}
 }
+
+// newHistoryStore creates the calculation history store selected by cfg. A
+// SQLite database is opened and migrated on start, closed on stop and
+// checked by the readiness probe.
+func newHistoryStore(cfg config.CalculatorConfig, lifecycle *Lifecycle, checks *health.Health) history.Store {
+	if cfg.HistoryStore != config.HistorySQLite {
+		return history.NewMemoryStore(cfg.HistoryLimit)
+	}
+
+	store := history.NewSQLite(cfg.HistoryDSN, cfg.HistoryLimit)
+	lifecycle.Append(Hook{Name: "calculator-history", Start: store.Open, Stop: store.Close})
+	checks.Register(health.Check{
+		Name:    "calculator_history",
+		Checker: health.CheckerFunc(store.Ping),
+		Probes:  []health.Probe{health.Readiness},
+	})
+	return store
+}
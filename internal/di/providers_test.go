@@ -0,0 +1,35 @@
+package di
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvidersOpensResourcesOnStart(t *testing.T) {
+	cfg := config.Default()
+	cfg.Calculator.HistoryStore = config.HistorySQLite
+	cfg.Calculator.HistoryDSN = "file:" + filepath.Join(t.TempDir(), "history.db")
+	cfg.Tracing.Exporter = config.TracingOTLP
+
+	goroutines := runtime.NumGoroutine()
+	providers := NewProviders(cfg)
+	ctx := context.Background()
+
+	store, ok := providers.History.(*history.SQLStore)
+	require.True(t, ok)
+
+	// Building the providers, as the routes subcommand does, opens nothing
+	assert.Error(t, store.Ping(ctx))
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+
+	require.NoError(t, providers.Lifecycle.Start(ctx))
+	assert.NoError(t, store.Ping(ctx))
+	require.NoError(t, providers.Lifecycle.Stop(ctx))
+}
@@ -5,6 +5,8 @@ type Customer struct {
 	ID    string
 	Name  string
 	Email string
+	// Version increases with every update and is served as the ETag
+	Version int
 }
 
 // LoginRequest represents a login request
@@ -28,7 +30,14 @@ type PublicResponse struct {
 
 // CustomerResponse represents a customer response
 type CustomerResponse struct {
-	ID    string `json:"id"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Version int    `json:"version"`
+}
+
+// CreateCustomerRequest represents a customer creation request
+type CreateCustomerRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
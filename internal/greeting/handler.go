@@ -24,26 +24,35 @@ func NewHandler() *Handler {
 
 // RegisterRoutes registers greeting routes
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	routes := common.RouteGroup{
+	for _, group := range h.Routes() {
+		common.RegisterGroup(router, group)
+	}
+}
+
+// Routes returns the greeting route groups
+func (h *Handler) Routes() []common.RouteGroup {
+	return []common.RouteGroup{{
 		Prefix: "/greeting",
 		Routes: []common.Route{
-			common.NamedRoute("/{name}", "GET", "greeting.hello", h.handleHello),
-			common.NamedRoute("/formal/{name}", "GET", "greeting.formal", h.handleFormalGreeting),
+			common.NamedRoute("/{name}", "GET", "greeting.hello", h.handleHello).
+				WithDoc(greetingDoc("Greet a person")),
+			common.NamedRoute("/formal/{name}", "GET", "greeting.formal", h.handleFormalGreeting).
+				WithDoc(greetingDoc("Formal greeting")),
 		},
+	}}
+}
+
+// greetingDoc documents a greeting route
+func greetingDoc(summary string) common.RouteDoc {
+	return common.RouteDoc{
+		Summary:  summary,
+		Tags:     []string{"greeting"},
+		Response: GreetingResponse{},
+		Errors:   []int{http.StatusBadRequest},
 	}
-	common.RegisterGroup(router, routes)
 }
 
 // handleHello handles simple greeting
-// @Summary Greet a person
-// @Description Returns a simple greeting message for the given name
-// @Tags greeting
-// @Accept json
-// @Produce json
-// @Param name path string true "Person's name"
-// @Success 200 {object} GreetingResponse
-// @Failure 400 {object} common.ErrorResponse
-// @Router /greeting/{name} [get]
 func (h *Handler) handleHello(w http.ResponseWriter, r *http.Request) {
 	params := h.GetURLParams(r)
 	name, exists := params.String("name")
@@ -59,15 +68,6 @@ func (h *Handler) handleHello(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleFormalGreeting handles formal greeting
-// @Summary Formal greeting
-// @Description Returns a formal greeting message for the given name
-// @Tags greeting
-// @Accept json
-// @Produce json
-// @Param name path string true "Person's name"
-// @Success 200 {object} GreetingResponse
-// @Failure 400 {object} common.ErrorResponse
-// @Router /greeting/formal/{name} [get]
 func (h *Handler) handleFormalGreeting(w http.ResponseWriter, r *http.Request) {
 	params := h.GetURLParams(r)
 	name, exists := params.String("name")
@@ -0,0 +1,22 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// Handler serves a probe, answering 200 when every check passes and 503
+// otherwise, with the result of every check
+func (h *Health) Handler(probe Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.Run(r.Context(), probe)
+
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		common.WriteJSON(w, status, report)
+	}
+}
@@ -0,0 +1,278 @@
+// Package health runs the liveness, readiness and startup checks registered
+// by the application components and serves them as /livez, /readyz and
+// /startupz.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe selects which endpoint a check contributes to
+type Probe string
+
+const (
+	Liveness  Probe = "liveness"
+	Readiness Probe = "readiness"
+	Startup   Probe = "startup"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Errors reported by the startup and readiness probes
+var (
+	ErrNotStarted   = errors.New("startup has not completed")
+	ErrShuttingDown = errors.New("shutting down")
+)
+
+// Checker reports whether a component is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Check is a named checker registered on one or more probes
+type Check struct {
+	Name    string
+	Checker Checker
+	Probes  []Probe
+	// Timeout bounds a single run; zero uses the default of the Health
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused; zero uses the default of the
+	// Health, negative disables caching
+	CacheTTL time.Duration
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Cached     bool      `json:"cached"`
+}
+
+// Report is the outcome of a probe
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// entry is a registered check with its cached result
+type entry struct {
+	check Check
+
+	mu     sync.Mutex
+	last   CheckResult
+	hasRun bool
+}
+
+// Health holds the registered checks and the process lifecycle state
+type Health struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.RWMutex
+	checks []*entry
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// New creates a Health whose checks default to the given timeout and cache TTL
+func New(timeout, cacheTTL time.Duration) *Health {
+	return &Health{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register adds a check. Registering a duplicate name panics, as it is a
+// programming error.
+func (h *Health) Register(check Check) {
+	if check.Name == "" || check.Checker == nil || len(check.Probes) == 0 {
+		panic("health: check needs a name, a checker and at least one probe")
+	}
+	if check.Timeout == 0 {
+		check.Timeout = h.timeout
+	}
+	if check.CacheTTL == 0 {
+		check.CacheTTL = h.cacheTTL
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range h.checks {
+		if e.check.Name == check.Name {
+			panic(fmt.Sprintf("health: duplicate check %q", check.Name))
+		}
+	}
+	h.checks = append(h.checks, &entry{check: check})
+}
+
+// MarkStarted records that startup completed, so the startup and readiness
+// probes can pass
+func (h *Health) MarkStarted() {
+	h.started.Store(true)
+}
+
+// MarkShuttingDown makes the readiness probe fail so load balancers stop
+// sending traffic while in-flight requests drain
+func (h *Health) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Started reports whether startup completed
+func (h *Health) Started() bool {
+	return h.started.Load()
+}
+
+// ShuttingDown reports whether shutdown began
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// Run runs the checks of a probe concurrently and reports their results in
+// name order, together with the lifecycle state
+func (h *Health) Run(ctx context.Context, probe Probe) Report {
+	h.mu.RLock()
+	var entries []*entry
+	for _, e := range h.checks {
+		for _, p := range e.check.Probes {
+			if p == probe {
+				entries = append(entries, e)
+				break
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = e.run(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	state := func(name string, err error) CheckResult {
+		result := CheckResult{Name: name, Status: StatusOK, CheckedAt: now}
+		if err != nil {
+			result.Status, result.Error = StatusFail, err.Error()
+		}
+		return result
+	}
+	switch probe {
+	case Startup:
+		results = append(results, state("startup", h.startedErr()))
+	case Readiness:
+		results = append(results, state("startup", h.startedErr()))
+		var err error
+		if h.ShuttingDown() {
+			err = ErrShuttingDown
+		}
+		results = append(results, state("shutdown", err))
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	report := Report{Status: StatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// startedErr returns ErrNotStarted until MarkStarted is called
+func (h *Health) startedErr() error {
+	if h.Started() {
+		return nil
+	}
+	return ErrNotStarted
+}
+
+// run runs the check, or returns its cached result while still fresh.
+// Concurrent probes share a single run. A run cut short by the caller, such
+// as a probe client hanging up, is not cached since it says nothing about
+// the checked dependency.
+func (e *entry) run(ctx context.Context) CheckResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.hasRun && e.check.CacheTTL > 0 && time.Since(e.last.CheckedAt) < e.check.CacheTTL {
+		cached := e.last
+		cached.Cached = true
+		return cached
+	}
+
+	start := time.Now()
+	err := runWithTimeout(ctx, e.check.Checker, e.check.Timeout)
+	result := CheckResult{
+		Name:       e.check.Name,
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+
+	e.last, e.hasRun = result, true
+	return result
+}
+
+// runWithTimeout runs the checker, giving up once the timeout elapses or ctx
+// is done even if the checker ignores its context. Panics are reported as
+// failures.
+func runWithTimeout(ctx context.Context, checker Checker, timeout time.Duration) error {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return fmt.Errorf("check cancelled: %w", err)
+		}
+		return fmt.Errorf("check timed out after %s", timeout)
+	}
+}
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultByName returns the result of the named check
+func resultByName(t *testing.T, report Report, name string) CheckResult {
+	t.Helper()
+	for _, r := range report.Checks {
+		if r.Name == name {
+			return r
+		}
+	}
+	require.Failf(t, "check not found", "no check named %q", name)
+	return CheckResult{}
+}
+
+func TestProbesSelectTheirChecks(t *testing.T) {
+	h := New(time.Second, -1)
+	h.Register(Check{Name: "process", Checker: CheckerFunc(func(context.Context) error { return nil }), Probes: []Probe{Liveness}})
+	h.Register(Check{Name: "db", Checker: CheckerFunc(func(context.Context) error { return errors.New("connection refused") }), Probes: []Probe{Readiness, Startup}})
+
+	live := h.Run(context.Background(), Liveness)
+	assert.True(t, live.OK())
+	require.Len(t, live.Checks, 1)
+	assert.Equal(t, "process", live.Checks[0].Name)
+
+	h.MarkStarted()
+	ready := h.Run(context.Background(), Readiness)
+	assert.False(t, ready.OK())
+	assert.Equal(t, []string{"db", "shutdown", "startup"}, names(ready))
+	db := resultByName(t, ready, "db")
+	assert.Equal(t, StatusFail, db.Status)
+	assert.Equal(t, "connection refused", db.Error)
+}
+
+func TestLifecycleGatesStartupAndReadiness(t *testing.T) {
+	h := New(time.Second, 0)
+
+	assert.False(t, h.Run(context.Background(), Startup).OK())
+	assert.False(t, h.Run(context.Background(), Readiness).OK())
+	assert.True(t, h.Run(context.Background(), Liveness).OK())
+
+	h.MarkStarted()
+	assert.True(t, h.Run(context.Background(), Startup).OK())
+	assert.True(t, h.Run(context.Background(), Readiness).OK())
+
+	h.MarkShuttingDown()
+	ready := h.Run(context.Background(), Readiness)
+	assert.False(t, ready.OK())
+	assert.Equal(t, ErrShuttingDown.Error(), resultByName(t, ready, "shutdown").Error)
+	assert.True(t, h.Run(context.Background(), Liveness).OK(), "liveness is unaffected by shutdown")
+}
+
+func TestCheckTimeoutAndPanic(t *testing.T) {
+	h := New(20*time.Millisecond, -1)
+	h.Register(Check{Name: "slow", Checker: CheckerFunc(func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}), Probes: []Probe{Liveness}})
+	h.Register(Check{Name: "broken", Checker: CheckerFunc(func(context.Context) error {
+		panic("boom")
+	}), Probes: []Probe{Liveness}})
+
+	start := time.Now()
+	report := h.Run(context.Background(), Liveness)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.Contains(t, resultByName(t, report, "slow").Error, "timed out")
+	assert.Contains(t, resultByName(t, report, "broken").Error, "panicked: boom")
+}
+
+func TestResultsAreCached(t *testing.T) {
+	var calls atomic.Int32
+	h := New(time.Second, time.Hour)
+	h.Register(Check{Name: "cached", Checker: CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}), Probes: []Probe{Liveness}})
+	h.Register(Check{Name: "uncached", CacheTTL: -1, Checker: CheckerFunc(func(context.Context) error {
+		calls.Add(10)
+		return nil
+	}), Probes: []Probe{Liveness}})
+
+	first := h.Run(context.Background(), Liveness)
+	second := h.Run(context.Background(), Liveness)
+
+	assert.Equal(t, int32(21), calls.Load())
+	assert.False(t, resultByName(t, first, "cached").Cached)
+	assert.True(t, resultByName(t, second, "cached").Cached)
+	assert.False(t, resultByName(t, second, "uncached").Cached)
+}
+
+func TestCancelledRunsAreNotCached(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	h := New(time.Second, time.Hour)
+	h.Register(Check{Name: "db", Checker: CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}), Probes: []Probe{Liveness}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	cancelled := resultByName(t, h.Run(ctx, Liveness), "db")
+	assert.Equal(t, StatusFail, cancelled.Status)
+	assert.Contains(t, cancelled.Error, "check cancelled")
+
+	// The next caller runs the check again instead of getting the cancellation
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, resultByName(t, h.Run(ctx, Liveness), "db").Cached)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	h := New(time.Second, 0)
+	check := Check{Name: "db", Checker: CheckerFunc(func(context.Context) error { return nil }), Probes: []Probe{Readiness}}
+	h.Register(check)
+
+	assert.Panics(t, func() { h.Register(check) })
+	assert.Panics(t, func() { h.Register(Check{Name: "no-probe", Checker: check.Checker}) })
+}
+
+func TestHandler(t *testing.T) {
+	h := New(time.Second, 0)
+
+	rec := httptest.NewRecorder()
+	h.Handler(Startup)(rec, httptest.NewRequest("GET", "/startupz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	h.MarkStarted()
+	rec = httptest.NewRecorder()
+	h.Handler(Startup)(rec, httptest.NewRequest("GET", "/startupz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, []string{"startup"}, names(report))
+}
+
+// names returns the check names of a report
+func names(report Report) []string {
+	var out []string
+	for _, r := range report.Checks {
+		out = append(out, r.Name)
+	}
+	return out
+}
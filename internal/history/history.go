@@ -0,0 +1,125 @@
+// Package history records the calculations of authenticated users so they
+// can be listed, cleared and referenced by later expressions.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Entry is a recorded calculation
+type Entry struct {
+	ID        int64       `json:"id"`
+	Operation string      `json:"operation"`
+	Inputs    []string    `json:"inputs"`
+	Result    json.Number `json:"result"`
+	// Variable is the name the result was assigned to, if any
+	Variable  string    `json:"variable,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Page is a page of history entries, newest first
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Total   int     `json:"total"`
+	Offset  int     `json:"offset"`
+	Limit   int     `json:"limit"`
+}
+
+// Variables are the results a user can reference in expressions: the last
+// one and the latest value of every named one. A variable lives as long as
+// the entry that set it is kept.
+type Variables struct {
+	Last  json.Number
+	Named map[string]json.Number
+}
+
+// Store keeps the calculation history of every principal. A shared backend
+// lets the history follow users across instances.
+type Store interface {
+	// Append records entries in order, assigning their IDs, and drops the
+	// oldest entries of principal beyond the store limit
+	Append(ctx context.Context, principal string, entries []Entry) error
+	// List returns a page of the entries of principal, newest first, and
+	// their total number
+	List(ctx context.Context, principal string, offset, limit int) ([]Entry, int, error)
+	// Variables returns the results principal can reference
+	Variables(ctx context.Context, principal string) (Variables, error)
+	// Clear forgets every entry of principal
+	Clear(ctx context.Context, principal string) error
+}
+
+// MemoryStore keeps the history in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	limit   int
+	lastID  int64
+	entries map[string][]Entry // Oldest first
+}
+
+// NewMemoryStore creates an empty in-memory store keeping at most limit
+// entries per principal
+func NewMemoryStore(limit int) *MemoryStore {
+	return &MemoryStore{limit: limit, entries: make(map[string][]Entry)}
+}
+
+// Append implements Store
+func (s *MemoryStore) Append(ctx context.Context, principal string, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.entries[principal]
+	for i := range entries {
+		s.lastID++
+		entries[i].ID = s.lastID
+		entry := entries[i]
+		entry.Inputs = append([]string(nil), entry.Inputs...)
+		kept = append(kept, entry)
+	}
+	if len(kept) > s.limit {
+		kept = append([]Entry(nil), kept[len(kept)-s.limit:]...)
+	}
+	s.entries[principal] = kept
+	return nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context, principal string, offset, limit int) ([]Entry, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.entries[principal]
+	page := []Entry{}
+	for i := len(kept) - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, kept[i])
+	}
+	return page, len(kept), nil
+}
+
+// Variables implements Store
+func (s *MemoryStore) Variables(ctx context.Context, principal string) (Variables, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vars := Variables{Named: make(map[string]json.Number)}
+	kept := s.entries[principal]
+	if len(kept) > 0 {
+		vars.Last = kept[len(kept)-1].Result
+	}
+	for _, entry := range kept {
+		if entry.Variable != "" {
+			vars.Named[entry.Variable] = entry.Result
+		}
+	}
+	return vars, nil
+}
+
+// Clear implements Store
+func (s *MemoryStore) Clear(ctx context.Context, principal string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, principal)
+	return nil
+}
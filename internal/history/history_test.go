@@ -0,0 +1,92 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// stores returns a store of each implementation keeping limit entries
+func stores(t *testing.T, limit int) map[string]Store {
+	t.Helper()
+	sqlite := NewSQLite(":memory:", limit)
+	require.ErrorIs(t, sqlite.Ping(context.Background()), errNotOpen)
+	require.NoError(t, sqlite.Open(context.Background()))
+	t.Cleanup(func() { sqlite.Close(context.Background()) })
+
+	return map[string]Store{
+		"memory": NewMemoryStore(limit),
+		"sqlite": sqlite,
+	}
+}
+
+func entry(operation, result, variable string, inputs ...string) Entry {
+	return Entry{Operation: operation, Inputs: inputs, Result: json.Number(result), Variable: variable, CreatedAt: epoch}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range stores(t, 3) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			entries := []Entry{
+				entry("add", "3", "", "1", "2"),
+				entry("evaluate", "5", "x", "x = 2 + 3"),
+			}
+			require.NoError(t, store.Append(ctx, "alice", entries))
+			assert.NotZero(t, entries[0].ID)
+			assert.Greater(t, entries[1].ID, entries[0].ID)
+			require.NoError(t, store.Append(ctx, "alice", []Entry{entry("evaluate", "0.25", "", "1 / 4")}))
+			require.NoError(t, store.Append(ctx, "bob", []Entry{entry("sqrt", "4", "", "16")}))
+
+			page, total, err := store.List(ctx, "alice", 0, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			require.Len(t, page, 2)
+			assert.Equal(t, "1 / 4", page[0].Inputs[0])
+			assert.Equal(t, json.Number("0.25"), page[0].Result)
+			assert.Equal(t, entries[1].ID, page[1].ID)
+			assert.Equal(t, "x", page[1].Variable)
+			assert.True(t, epoch.Equal(page[1].CreatedAt))
+
+			page, _, err = store.List(ctx, "alice", 2, 2)
+			require.NoError(t, err)
+			require.Len(t, page, 1)
+			assert.Equal(t, []string{"1", "2"}, page[0].Inputs)
+
+			vars, err := store.Variables(ctx, "alice")
+			require.NoError(t, err)
+			assert.Equal(t, json.Number("0.25"), vars.Last)
+			assert.Equal(t, map[string]json.Number{"x": "5"}, vars.Named)
+
+			// Only the newest 3 entries are kept, so the first add is dropped
+			require.NoError(t, store.Append(ctx, "alice", []Entry{entry("evaluate", "6", "x", "x = x + 1")}))
+			page, total, err = store.List(ctx, "alice", 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			assert.Equal(t, json.Number("5"), page[2].Result)
+			vars, _ = store.Variables(ctx, "alice")
+			assert.Equal(t, map[string]json.Number{"x": "6"}, vars.Named)
+
+			require.NoError(t, store.Clear(ctx, "alice"))
+			page, total, err = store.List(ctx, "alice", 0, 10)
+			require.NoError(t, err)
+			assert.Zero(t, total)
+			assert.Empty(t, page)
+			vars, err = store.Variables(ctx, "alice")
+			require.NoError(t, err)
+			assert.Empty(t, vars.Last)
+			assert.Empty(t, vars.Named)
+
+			// Other principals keep their history
+			_, total, _ = store.List(ctx, "bob", 0, 10)
+			assert.Equal(t, 1, total)
+		})
+	}
+}
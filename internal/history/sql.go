@@ -0,0 +1,224 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	// Registers the pure Go "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the history table. It sticks to SQL understood by SQLite
+// and by most other databases.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS calculator_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		principal TEXT NOT NULL,
+		operation TEXT NOT NULL,
+		inputs TEXT NOT NULL,
+		result TEXT NOT NULL,
+		variable TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS calculator_history_principal ON calculator_history (principal, id)`,
+}
+
+// errNotOpen reports a call to a SQLite store before Open
+var errNotOpen = errors.New("history database is not open")
+
+// SQLStore keeps the history in a SQL database
+type SQLStore struct {
+	db    *sql.DB
+	dsn   string
+	limit int
+}
+
+// NewSQLStore creates a store on db keeping at most limit entries per
+// principal. Migrate creates its table.
+func NewSQLStore(db *sql.DB, limit int) *SQLStore {
+	return &SQLStore{db: db, limit: limit}
+}
+
+// NewSQLite creates a store on the SQLite database named by dsn, such as
+// "file:history.db" or ":memory:". Nothing is opened until Open, so it can
+// be built by commands that never serve requests.
+func NewSQLite(dsn string, limit int) *SQLStore {
+	return &SQLStore{dsn: dsn, limit: limit}
+}
+
+// Open opens the SQLite database of a store created by NewSQLite, when not
+// open yet, and migrates it
+func (s *SQLStore) Open(ctx context.Context) error {
+	if s.db == nil {
+		db, err := sql.Open("sqlite", s.dsn)
+		if err != nil {
+			return fmt.Errorf("open history database: %w", err)
+		}
+		// SQLite serializes writers, and every connection to :memory:
+		// would open a database of its own
+		db.SetMaxOpenConns(1)
+		s.db = db
+	}
+	return s.Migrate(ctx)
+}
+
+// Migrate creates the history table when it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	if s.db == nil {
+		return errNotOpen
+	}
+	for _, stmt := range schema {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate history database: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable
+func (s *SQLStore) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return errNotOpen
+	}
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLStore) Close(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Append implements Store
+func (s *SQLStore) Append(ctx context.Context, principal string, entries []Entry) error {
+	if s.db == nil {
+		return errNotOpen
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, entry := range entries {
+		inputs, err := json.Marshal(entry.Inputs)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO calculator_history (principal, operation, inputs, result, variable, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			principal, entry.Operation, string(inputs), entry.Result.String(), entry.Variable,
+			entry.CreatedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+		if entries[i].ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	// Drop everything older than the newest limit entries
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM calculator_history WHERE principal = ? AND id <= (
+			SELECT id FROM calculator_history WHERE principal = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+		principal, principal, s.limit)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List implements Store
+func (s *SQLStore) List(ctx context.Context, principal string, offset, limit int) ([]Entry, int, error) {
+	if s.db == nil {
+		return nil, 0, errNotOpen
+	}
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM calculator_history WHERE principal = ?`, principal).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, operation, inputs, result, variable, created_at FROM calculator_history
+		WHERE principal = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		principal, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	page := []Entry{}
+	for rows.Next() {
+		var (
+			entry     Entry
+			inputs    string
+			result    string
+			createdAt string
+		)
+		if err := rows.Scan(&entry.ID, &entry.Operation, &inputs, &result, &entry.Variable, &createdAt); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(inputs), &entry.Inputs); err != nil {
+			return nil, 0, fmt.Errorf("entry %d: invalid inputs: %w", entry.ID, err)
+		}
+		if entry.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, 0, fmt.Errorf("entry %d: invalid timestamp: %w", entry.ID, err)
+		}
+		entry.Result = json.Number(result)
+		page = append(page, entry)
+	}
+	return page, total, rows.Err()
+}
+
+// Variables implements Store
+func (s *SQLStore) Variables(ctx context.Context, principal string) (Variables, error) {
+	if s.db == nil {
+		return Variables{}, errNotOpen
+	}
+	vars := Variables{Named: make(map[string]json.Number)}
+
+	var last string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT result FROM calculator_history WHERE principal = ? ORDER BY id DESC LIMIT 1`, principal).Scan(&last)
+	if err == sql.ErrNoRows {
+		return vars, nil
+	}
+	if err != nil {
+		return Variables{}, err
+	}
+	vars.Last = json.Number(last)
+
+	// The latest value of every variable
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT variable, result FROM calculator_history WHERE id IN (
+			SELECT MAX(id) FROM calculator_history WHERE principal = ? AND variable <> '' GROUP BY variable)`,
+		principal)
+	if err != nil {
+		return Variables{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, result string
+		if err := rows.Scan(&name, &result); err != nil {
+			return Variables{}, err
+		}
+		vars.Named[name] = json.Number(result)
+	}
+	return vars, rows.Err()
+}
+
+// Clear implements Store
+func (s *SQLStore) Clear(ctx context.Context, principal string) error {
+	if s.db == nil {
+		return errNotOpen
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM calculator_history WHERE principal = ?`, principal)
+	return err
+}
@@ -0,0 +1,124 @@
+// Package idempotency stores the responses of requests sent with an
+// Idempotency-Key so retries can be answered without running them again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a stored response, replayed to retries
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the state of a key
+type Record struct {
+	// Fingerprint identifies the request that claimed the key
+	Fingerprint string
+	// Response is nil while the first request is in flight
+	Response *Response
+	Expires  time.Time
+}
+
+// Store keeps idempotency records. A shared backend lets retries reach any
+// instance.
+type Store interface {
+	// Reserve claims key for a request until ttl elapses. When the key is
+	// already claimed it returns the existing record and false.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration, now time.Time) (Record, bool, error)
+	// Complete stores the response of the request holding key
+	Complete(ctx context.Context, key string, resp Response, ttl time.Duration, now time.Time) error
+	// Release forgets key, letting a retry run again
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint identifies a request by its method, path and body
+func Fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryStore keeps the records in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+// Reserve implements Store
+func (s *MemoryStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration, now time.Time) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok && now.Before(rec.Expires) {
+		return *rec, false, nil
+	}
+	rec := &Record{Fingerprint: fingerprint, Expires: now.Add(ttl)}
+	s.records[key] = rec
+	return *rec, true, nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(ctx context.Context, key string, resp Response, ttl time.Duration, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok {
+		rec.Response = &resp
+		rec.Expires = now.Add(ttl)
+	}
+	return nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Len returns the number of keys tracked
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Sweep drops the expired records
+func (s *MemoryStore) Sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, rec := range s.records {
+		if !now.Before(rec.Expires) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// Run sweeps the store every interval until ctx is canceled
+func (s *MemoryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Sweep(now)
+		}
+	}
+}
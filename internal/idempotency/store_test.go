@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	rec, created, err := store.Reserve(ctx, "k", "fp", time.Minute, epoch)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Nil(t, rec.Response)
+
+	// In flight
+	rec, created, _ = store.Reserve(ctx, "k", "other", time.Minute, epoch.Add(time.Second))
+	assert.False(t, created)
+	assert.Equal(t, "fp", rec.Fingerprint)
+	assert.Nil(t, rec.Response)
+
+	// Completing extends the record by the TTL
+	require.NoError(t, store.Complete(ctx, "k", Response{Status: http.StatusCreated, Body: []byte("ok")}, time.Minute, epoch.Add(30*time.Second)))
+	rec, created, _ = store.Reserve(ctx, "k", "fp", time.Minute, epoch.Add(80*time.Second))
+	assert.False(t, created)
+	require.NotNil(t, rec.Response)
+	assert.Equal(t, http.StatusCreated, rec.Response.Status)
+	assert.Equal(t, "ok", string(rec.Response.Body))
+
+	// Expired records are claimed again
+	_, created, _ = store.Reserve(ctx, "k", "fp", time.Minute, epoch.Add(90*time.Second))
+	assert.True(t, created)
+
+	require.NoError(t, store.Release(ctx, "k"))
+	_, created, _ = store.Reserve(ctx, "k", "fp", time.Minute, epoch)
+	assert.True(t, created)
+}
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	_, _, _ = store.Reserve(ctx, "short", "fp", time.Second, epoch)
+	_, _, _ = store.Reserve(ctx, "long", "fp", time.Hour, epoch)
+
+	store.Sweep(epoch.Add(time.Minute))
+	assert.Equal(t, 1, store.Len())
+	store.Sweep(epoch.Add(2 * time.Hour))
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestFingerprint(t *testing.T) {
+	post := httptest.NewRequest("POST", "/users?x=1", strings.NewReader(""))
+	put := httptest.NewRequest("PUT", "/users", strings.NewReader(""))
+
+	assert.Equal(t, Fingerprint(post, []byte("a")), Fingerprint(httptest.NewRequest("POST", "/users", nil), []byte("a")))
+	assert.NotEqual(t, Fingerprint(post, []byte("a")), Fingerprint(post, []byte("b")))
+	assert.NotEqual(t, Fingerprint(post, []byte("a")), Fingerprint(put, []byte("a")))
+}
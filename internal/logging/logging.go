@@ -0,0 +1,114 @@
+// Package logging provides the log/slog based structured logger and the
+// request scoped loggers handed to handlers and services.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+)
+
+// loggerKey is the context key of the request logger
+type loggerKey struct{}
+
+// New creates a logger writing to w with the configured level and format.
+// Records logged with a request context carry its request ID, route, user
+// and the trace and span IDs.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// WithLogger returns a copy of ctx carrying the logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request carried by ctx, falling back
+// to slog.Default. The returned logger reports the request attributes even
+// when called without a context, e.g. logger.Info.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	return slog.New(boundHandler{ctx: ctx, next: logger.Handler()})
+}
+
+// contextHandler adds the request attributes found in the record context
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds request_id, route, user, trace_id and span_id before delegating
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if state := common.State(ctx); state != nil {
+		if state.RequestID != "" {
+			r.AddAttrs(slog.String("request_id", state.RequestID))
+		}
+		if state.Route != "" {
+			r.AddAttrs(slog.String("route", state.Route))
+		}
+		if state.Principal != "" {
+			r.AddAttrs(slog.String("user", state.Principal))
+		}
+	}
+	if tc, ok := correlation.TraceContextFrom(ctx); ok {
+		r.AddAttrs(
+			slog.String("trace_id", tc.TraceIDString()),
+			slog.String("span_id", tc.SpanIDString()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context handler around the new handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context handler around the new handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// boundHandler handles every record with the context it was created from
+type boundHandler struct {
+	ctx  context.Context
+	next slog.Handler
+}
+
+// Enabled reports whether the next handler handles the level
+func (h boundHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.next.Enabled(h.ctx, level)
+}
+
+// Handle delegates the record with the bound context
+func (h boundHandler) Handle(_ context.Context, r slog.Record) error {
+	return h.next.Handle(h.ctx, r)
+}
+
+// WithAttrs keeps the bound context around the new handler
+func (h boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return boundHandler{ctx: h.ctx, next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the bound context around the new handler
+func (h boundHandler) WithGroup(name string) slog.Handler {
+	return boundHandler{ctx: h.ctx, next: h.next.WithGroup(name)}
+}
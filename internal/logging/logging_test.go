@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContextAddsRequestAttributes(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(config.LogConfig{Level: "debug", Format: "json"}, &out)
+
+	ctx, state := common.WithState(context.Background())
+	state.RequestID = "req-1"
+	state.Route = "users.get"
+	ctx = WithLogger(ctx, logger)
+
+	// The principal is set after the logger was obtained, as JWT middleware does
+	requestLogger := FromContext(ctx)
+	common.WithPrincipal(ctx, "alice")
+	requestLogger.Debug("loading user", "user_id", 7)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "loading user", record["msg"])
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "users.get", record["route"])
+	assert.Equal(t, "alice", record["user"])
+	assert.Equal(t, float64(7), record["user_id"])
+}
+
+func TestNewRespectsLevelAndFormat(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(config.LogConfig{Level: "warn", Format: "text"}, &out)
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "level=WARN msg=shown")
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	// Falls back to slog.Default without panicking
+	FromContext(context.Background()).Debug("no request logger")
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerServesRegisteredMetrics(t *testing.T) {
+	reg := NewRegistry()
+	requests := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "Requests by route.",
+	}, []string{"route", "code"})
+	requests.WithLabelValues("users.get", "200").Add(2)
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE requests_total counter")
+	assert.Contains(t, body, `requests_total{code="200",route="users.get"} 2`)
+	assert.Contains(t, body, "# TYPE go_goroutines gauge")
+	assert.Contains(t, body, `go_info{version="go`)
+	assert.Contains(t, body, "promhttp_metric_handler_errors_total")
+}
+
+func TestNewRegistryRejectsDuplicates(t *testing.T) {
+	reg := NewRegistry()
+	opts := prometheus.CounterOpts{Name: "dup_total", Help: "Duplicate."}
+	promauto.With(reg).NewCounter(opts)
+
+	assert.Panics(t, func() { promauto.With(reg).NewCounter(opts) })
+}
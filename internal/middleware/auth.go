@@ -4,42 +4,60 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/example/go-template/internal/common"
 	"github.com/example/go-template/internal/services"
-	"github.com/labstack/echo/v4"
 )
 
 // JWTMiddleware validates JWT tokens from Authorization header
-func JWTMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			authHeader := c.Request().Header.Get("Authorization")
+func JWTMiddleware(authService *services.AuthService) common.Middleware {
+	return jwtMiddleware(authService, true)
+}
+
+// OptionalJWTMiddleware authenticates requests carrying a valid bearer token
+// and lets every other request through anonymously, so that a stale or
+// malformed token never fails a route that works without one.
+func OptionalJWTMiddleware(authService *services.AuthService) common.Middleware {
+	return jwtMiddleware(authService, false)
+}
+
+// jwtMiddleware validates the bearer token of requests. When required is
+// set, requests without a valid token are rejected; otherwise they pass
+// anonymously.
+func jwtMiddleware(authService *services.AuthService, required bool) common.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reject := func(message string) {
+				if !required {
+					next.ServeHTTP(w, r)
+					return
+				}
+				common.WriteError(w, http.StatusUnauthorized, message)
+			}
+
+			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "missing authorization header",
-				})
+				reject("missing authorization header")
+				return
 			}
 
 			// Extract Bearer token
 			const bearerPrefix = "Bearer "
 			if !strings.HasPrefix(authHeader, bearerPrefix) {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid authorization header format",
-				})
+				reject("invalid authorization header format")
+				return
 			}
 
 			token := strings.TrimPrefix(authHeader, bearerPrefix)
 
 			// Validate token
-			claims, err := authService.ValidateToken(token)
+			claims, err := authService.ValidateToken(r.Context(), token)
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": err.Error(),
-				})
+				reject(err.Error())
+				return
 			}
 
-			// Store claims in context for later use
-			c.Set("user", claims.Sub)
-			return next(c)
-		}
+			// Store the subject in the request context for later use
+			next.ServeHTTP(w, r.WithContext(common.WithPrincipal(r.Context(), claims.Sub)))
+		})
 	}
 }
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// CORSConfig configures the CORS middleware
+type CORSConfig struct {
+	AllowOrigins  []string
+	AllowMethods  []string
+	AllowHeaders  []string
+	ExposeHeaders []string
+}
+
+// CORS answers preflight requests and sets the CORS response headers
+func CORS(config CORSConfig) common.Middleware {
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			allowOrigin := matchOrigin(config.AllowOrigins, origin)
+
+			if allowOrigin != "" {
+				w.Header().Add("Vary", "Origin")
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			}
+
+			// Preflight request
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowOrigin != "" {
+					w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+					if allowHeaders != "" {
+						w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+					} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+						w.Header().Set("Access-Control-Allow-Headers", requested)
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if allowOrigin != "" && exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for the request origin
+func matchOrigin(allowed []string, origin string) string {
+	for _, o := range allowed {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
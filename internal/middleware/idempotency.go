@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/idempotency"
+	"github.com/example/go-template/internal/logging"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// Idempotency honors the Idempotency-Key header on the configured routes. The
+// first request with a key runs and its response is stored for cfg.TTL;
+// retries with the same key and body get that response replayed, while a
+// retry arriving before it completes gets 409 and one with a different body
+// gets 422. Bodies over cfg.MaxBodyBytes get 413. Keys are scoped by route
+// and authenticated user. Server errors are not stored so the request can be
+// retried, and a failing store lets requests through.
+func Idempotency(store idempotency.Store, cfg config.IdempotencyConfig) common.RouteMiddleware {
+	return func(info common.RouteInfo) common.Middleware {
+		if info.Name == "" || !slices.Contains(cfg.Routes, info.Name) {
+			return func(next http.Handler) http.Handler { return next }
+		}
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key := r.Header.Get(HeaderIdempotencyKey)
+				if key == "" || !isUnsafe(r.Method) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				if len(key) > maxIdempotencyKeyLength {
+					common.WriteError(w, http.StatusBadRequest, "idempotency key is too long")
+					return
+				}
+
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(cfg.MaxBodyBytes)))
+				if err != nil {
+					var tooLarge *http.MaxBytesError
+					if errors.As(err, &tooLarge) {
+						common.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is over %d bytes", tooLarge.Limit))
+						return
+					}
+					common.WriteError(w, http.StatusBadRequest, "invalid request body")
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				principal, _ := common.Principal(r.Context())
+				scoped := info.Name + "|" + principal + "|" + key
+				fingerprint := idempotency.Fingerprint(r, body)
+				logger := logging.FromContext(r.Context())
+
+				rec, created, err := store.Reserve(r.Context(), scoped, fingerprint, cfg.TTL, time.Now())
+				if err != nil {
+					logger.Warn("idempotency store failed, running request", "error", err)
+					next.ServeHTTP(w, r)
+					return
+				}
+				if !created {
+					switch {
+					case rec.Fingerprint != fingerprint:
+						common.WriteError(w, http.StatusUnprocessableEntity, "idempotency key was used with a different request")
+					case rec.Response == nil:
+						w.Header().Set("Retry-After", "1")
+						common.WriteError(w, http.StatusConflict, "a request with this idempotency key is in progress")
+					default:
+						logger.Info("replaying idempotent response", "status", rec.Response.Status)
+						replay(w, rec.Response)
+					}
+					return
+				}
+
+				capture := newResponseCapture(w)
+				completed := false
+				defer func() {
+					// Nothing is stored for panics and server errors, so a
+					// retry runs the request again
+					if !completed || capture.status >= http.StatusInternalServerError {
+						if err := store.Release(r.Context(), scoped); err != nil {
+							logger.Warn("idempotency store failed to release key", "error", err)
+						}
+						return
+					}
+					if err := store.Complete(r.Context(), scoped, capture.response(), cfg.TTL, time.Now()); err != nil {
+						logger.Warn("idempotency store failed to save response", "error", err)
+					}
+				}()
+
+				next.ServeHTTP(capture, r)
+				completed = true
+			})
+		}
+	}
+}
+
+// isUnsafe reports whether method may change state
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(HeaderIdempotentReplayed, "true")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// responseCapture writes through to the client while keeping a copy of the
+// response. Only the headers set by the handler are kept, so a replay keeps
+// the request ID and rate limit headers of the retry.
+type responseCapture struct {
+	http.ResponseWriter
+	before  http.Header
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+// newResponseCapture wraps w, defaulting the status to 200
+func newResponseCapture(w http.ResponseWriter) *responseCapture {
+	return &responseCapture{ResponseWriter: w, before: w.Header().Clone(), status: http.StatusOK}
+}
+
+// WriteHeader records the status code and the headers set by the handler
+func (c *responseCapture) WriteHeader(status int) {
+	if c.written {
+		return
+	}
+	c.written = true
+	c.status = status
+	c.header = make(http.Header)
+	for name, values := range c.ResponseWriter.Header() {
+		if !slices.Equal(values, c.before[name]) {
+			c.header[name] = slices.Clone(values)
+		}
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if !c.written {
+		c.WriteHeader(http.StatusOK)
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// response returns the captured response
+func (c *responseCapture) response() idempotency.Response {
+	if !c.written {
+		c.header = make(http.Header)
+	}
+	return idempotency.Response{Status: c.status, Header: c.header, Body: bytes.Clone(c.body.Bytes())}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+	"github.com/example/go-template/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := common.NewRouter()
+	router.Use("correlation", correlation.Middleware())
+	router.Use("recover", Recover())
+	router.UseAfterAuth("idempotency", Idempotency(idempotency.NewMemoryStore(), config.IdempotencyConfig{
+		TTL:          time.Hour,
+		Routes:       []string{"items.create", "items.slow", "items.fail"},
+		MaxBodyBytes: 64,
+	}))
+	router.HandleGroup(common.RouteGroup{
+		Prefix: "/items",
+		Routes: []common.Route{
+			common.NamedRoute("", "POST", "items.create", func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.Header().Set("Location", "/items/1")
+				common.WriteJSON(w, http.StatusCreated, map[string]int32{"call": n})
+			}),
+			common.NamedRoute("/slow", "POST", "items.slow", func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+				common.WriteJSON(w, http.StatusCreated, "done")
+			}),
+			common.NamedRoute("/fail", "POST", "items.fail", func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				common.WriteError(w, http.StatusInternalServerError, "boom")
+			}),
+		},
+	})
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("/items", "k1", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.JSONEq(t, `{"call":1}`, first.Body.String())
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		rec := send("/items", "k1", `{"name":"a"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"call":1}`, rec.Body.String())
+		assert.Equal(t, "/items/1", rec.Header().Get("Location"))
+		assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+		// The retry keeps its own request ID
+		assert.NotEqual(t, first.Header().Get(common.HeaderRequestID), rec.Header().Get(common.HeaderRequestID))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("rejects a different body", func(t *testing.T) {
+		rec := send("/items", "k1", `{"name":"b"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("runs requests without a key", func(t *testing.T) {
+		send("/items", "", `{"name":"a"}`)
+		send("/items", "k2", `{"name":"a"}`)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("rejects duplicates in flight", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- send("/items/slow", "k3", "") }()
+
+		<-started
+		rec := send("/items/slow", "k3", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+		close(release)
+		assert.Equal(t, http.StatusCreated, (<-done).Code)
+		assert.Equal(t, "true", send("/items/slow", "k3", "").Header().Get(HeaderIdempotentReplayed))
+	})
+
+	t.Run("does not store server errors", func(t *testing.T) {
+		before := calls.Load()
+		assert.Equal(t, http.StatusInternalServerError, send("/items/fail", "k4", "").Code)
+		assert.Equal(t, http.StatusInternalServerError, send("/items/fail", "k4", "").Code)
+		assert.Equal(t, before+2, calls.Load())
+	})
+
+	t.Run("rejects large bodies", func(t *testing.T) {
+		before := calls.Load()
+		rec := send("/items", "k5", `{"name":"`+strings.Repeat("a", 64)+`"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), "request body is over 64 bytes")
+		assert.Equal(t, before, calls.Load())
+	})
+
+	t.Run("rejects long keys", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send("/items", strings.Repeat("k", 256), "").Code)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+	"github.com/example/go-template/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerLogsCompletedRequests(t *testing.T) {
+	var out bytes.Buffer
+	logger := logging.New(config.LogConfig{Level: "info", Format: "json"}, &out)
+
+	router := common.NewRouter()
+	router.Use("correlation", correlation.Middleware())
+	router.Use("logger", Logger(logger))
+	router.Use("recover", Recover())
+	router.HandleGroup(common.RouteGroup{
+		Prefix: "/items",
+		Routes: []common.Route{
+			common.NamedRoute("/{id}", "GET", "items.get", func(w http.ResponseWriter, r *http.Request) {
+				logging.FromContext(r.Context()).Info("handling item")
+				common.WriteSuccess(w, "ok")
+			}),
+			common.NamedRoute("/{id}", "DELETE", "items.delete", func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			}),
+		},
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/1", nil))
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var handled, completed map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &handled))
+	require.NoError(t, json.Unmarshal(lines[1], &completed))
+
+	assert.Equal(t, "handling item", handled["msg"])
+	assert.Equal(t, "items.get", handled["route"])
+	assert.NotEmpty(t, handled["request_id"])
+
+	assert.Equal(t, "request completed", completed["msg"])
+	assert.Equal(t, handled["request_id"], completed["request_id"])
+	assert.Equal(t, float64(http.StatusOK), completed["status"])
+	assert.Contains(t, completed, "latency_ms")
+	assert.Len(t, completed["trace_id"], 32)
+
+	// Panics are logged and answered with a 500
+	out.Reset()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("DELETE", "/items/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, out.String(), "panic recovered")
+	assert.Contains(t, out.String(), `"level":"ERROR"`)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics records the request count, latency and in-flight requests of every
+// route. Routes are labelled by name or path template, never by raw path, to
+// keep label cardinality bounded.
+func Metrics(reg prometheus.Registerer) common.RouteMiddleware {
+	factory := promauto.With(reg)
+	requests := factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by route and status code.",
+	}, []string{"route", "code"})
+	duration := factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+	inFlight := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served by route.",
+	}, []string{"route"})
+
+	return func(info common.RouteInfo) common.Middleware {
+		route := common.RouteLabel(info)
+		latency := duration.WithLabelValues(route)
+		active := inFlight.WithLabelValues(route)
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				start := time.Now()
+				active.Inc()
+				rec := newStatusRecorder(w)
+
+				completed := false
+				defer func() {
+					active.Dec()
+					status := rec.status
+					// A panic is answered with a 500 by the recover middleware
+					if !completed {
+						status = http.StatusInternalServerError
+					}
+					requests.WithLabelValues(route, strconv.Itoa(status)).Inc()
+					latency.Observe(time.Since(start).Seconds())
+				}()
+
+				next.ServeHTTP(rec, r)
+				completed = true
+			})
+		}
+	}
+}
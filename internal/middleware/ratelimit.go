@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/logging"
+	"github.com/example/go-template/internal/ratelimit"
+)
+
+// RateLimit enforces the rule matching each route. It reports the quota in
+// the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers and answers 429 with Retry-After once it is used
+// up. A failing store lets requests through.
+func RateLimit(limiter *ratelimit.Limiter) common.RouteMiddleware {
+	return func(info common.RouteInfo) common.Middleware {
+		rule, ok := limiter.RuleFor(info.Name)
+		if !ok {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		policy := strconv.Itoa(rule.Limit) + ";w=" + strconv.Itoa(ceilSeconds(rule.Period))
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				result, err := limiter.Allow(r, rule)
+				if err != nil {
+					logging.FromContext(r.Context()).Warn("rate limit store failed, allowing request", "error", err)
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				h := w.Header()
+				h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+				h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+				h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+				h.Set("RateLimit-Policy", policy)
+
+				if !result.Allowed {
+					retryAfter := ceilSeconds(result.RetryAfter)
+					h.Set("Retry-After", strconv.Itoa(retryAfter))
+					logging.FromContext(r.Context()).Info("rate limit exceeded", "rule", rule.Target, "retry_after_s", retryAfter)
+					common.WriteError(w, http.StatusTooManyRequests, "rate limit exceeded")
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, at least one
+func ceilSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/logging"
+)
+
+// Recover turns panics in handlers into internal server errors
+func Recover() common.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+					logging.FromContext(r.Context()).Error("panic recovered",
+						"error", err,
+						"stack", string(debug.Stack()),
+					)
+					common.WriteInternalError(w, "internal server error")
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
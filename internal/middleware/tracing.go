@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span named after every route, continuing the trace
+// of an incoming traceparent. Services start their spans from it through the
+// request context.
+func Tracing(provider trace.TracerProvider) common.RouteMiddleware {
+	tracer := provider.Tracer(tracing.ScopeName)
+	propagator := propagation.TraceContext{}
+
+	return func(info common.RouteInfo) common.Middleware {
+		name := common.RouteLabel(info)
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attrs := []attribute.KeyValue{
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				}
+				if info.Path != "" {
+					attrs = append(attrs, attribute.String("http.route", info.Path))
+				}
+
+				ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+				ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+				rec := newStatusRecorder(w)
+
+				completed := false
+				defer func() {
+					status := rec.status
+					if !completed {
+						status = http.StatusInternalServerError
+						span.SetStatus(codes.Error, "panic")
+					}
+					span.SetAttributes(attribute.Int("http.response.status_code", status))
+					if status >= http.StatusInternalServerError {
+						span.SetStatus(codes.Error, http.StatusText(status))
+					}
+					span.End()
+				}()
+
+				next.ServeHTTP(rec, r.WithContext(tracing.Correlate(ctx)))
+				completed = true
+			})
+		}
+	}
+}
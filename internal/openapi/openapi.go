@@ -0,0 +1,358 @@
+// Package openapi builds an OpenAPI 3.1 document from the registered route table.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// Version is the OpenAPI specification version of the generated documents
+const Version = "3.1.0"
+
+// Info holds the document metadata
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Components holds the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes an authentication scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation describes a single method on a path
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path parameter
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Schema is a JSON Schema fragment
+type Schema map[string]interface{}
+
+// bearerScheme is the name of the JWT security scheme
+const bearerScheme = "bearerAuth"
+
+// Generate builds the document for the given routes. Hidden routes are skipped.
+func Generate(info Info, routes []common.RouteInfo) *Document {
+	g := &generator{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]map[string]Operation),
+			Components: Components{
+				Schemas: make(map[string]Schema),
+			},
+		},
+	}
+
+	for _, route := range routes {
+		if route.Doc.Hidden {
+			continue
+		}
+		g.addRoute(route)
+	}
+
+	return g.doc
+}
+
+// Handler returns an HTTP handler serving the document as JSON
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			common.WriteInternalError(w, "failed to encode OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// Path converts a mux ({id:[0-9]+}) path template to OpenAPI form.
+// It returns the converted path and its parameter names in order.
+func Path(template string) (string, []string) {
+	var params []string
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if idx := strings.Index(name, ":"); idx >= 0 {
+			name = name[:idx]
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+type generator struct {
+	doc *Document
+}
+
+// addRoute adds the operation of a single route
+func (g *generator) addRoute(route common.RouteInfo) {
+	path, params := Path(route.Path)
+	doc := route.Doc
+
+	op := Operation{
+		OperationID: route.Name,
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Tags:        doc.Tags,
+		Responses:   make(map[string]Response),
+	}
+
+	for _, name := range params {
+		paramType := doc.Params[name]
+		if paramType == "" {
+			paramType = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   Schema{"type": paramType},
+		})
+	}
+	queryNames := make([]string, 0, len(doc.Query))
+	for name := range doc.Query {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: Schema{"type": doc.Query[name]},
+		})
+	}
+
+	if doc.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(g.schemaFor(reflect.TypeOf(doc.Request))),
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if doc.Response != nil {
+		schema := g.schemaFor(reflect.TypeOf(doc.Response))
+		if !doc.Raw {
+			schema = Schema{
+				"type":       "object",
+				"properties": map[string]Schema{"data": schema},
+				"required":   []string{"data"},
+			}
+		}
+		success.Content = jsonContent(schema)
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	errorCodes := doc.Errors
+	switch route.Auth {
+	case common.AuthJWT:
+		errorCodes = append(append([]int{}, errorCodes...), http.StatusUnauthorized)
+		op.Security = []map[string][]string{{bearerScheme: {}}}
+		g.addBearerScheme()
+	case common.AuthOptionalJWT:
+		// The empty requirement lets anonymous requests through. Invalid
+		// tokens count as anonymous too, so there is no 401 to document.
+		op.Security = []map[string][]string{{}, {bearerScheme: {}}}
+		g.addBearerScheme()
+	}
+	for _, code := range errorCodes {
+		op.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     jsonContent(g.schemaFor(reflect.TypeOf(common.ErrorResponse{}))),
+		}
+	}
+
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = make(map[string]Operation)
+	}
+	g.doc.Paths[path][strings.ToLower(route.Method)] = op
+}
+
+// addBearerScheme registers the JWT bearer security scheme
+func (g *generator) addBearerScheme() {
+	if g.doc.Components.SecuritySchemes == nil {
+		g.doc.Components.SecuritySchemes = make(map[string]SecurityScheme)
+	}
+	g.doc.Components.SecuritySchemes[bearerScheme] = SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// numberType is encoded as a JSON number although it is a string
+var numberType = reflect.TypeOf(json.Number(""))
+
+// schemaFor returns the schema of a Go type. Named structs are added to the
+// components and referenced.
+func (g *generator) schemaFor(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+	if t == numberType {
+		return Schema{"type": "number"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, exists := g.doc.Components.Schemas[name]; !exists {
+			// Reserve the name first so recursive types terminate
+			g.doc.Components.Schemas[name] = Schema{}
+			g.doc.Components.Schemas[name] = g.structSchema(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	default:
+		return Schema{}
+	}
+}
+
+// structSchema builds an object schema from the exported fields of a struct
+func (g *generator) structSchema(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitEmpty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+
+		properties[name] = g.schemaFor(field.Type)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schemaName returns the component name of a named type, e.g. user.User
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+// jsonContent wraps a schema in an application/json media type
+func jsonContent(schema Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,102 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name,omitempty"`
+	Tags      []string    `json:"tags"`
+	CreatedAt time.Time   `json:"created_at"`
+	Weight    json.Number `json:"weight,omitempty"`
+	Parent    *testItem   `json:"parent,omitempty"`
+	Secret    string      `json:"-"`
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+		params   []string
+	}{
+		{template: "/users/{id}", expected: "/users/{id}", params: []string{"id"}},
+		{template: "/users/{id:[0-9]+}", expected: "/users/{id}", params: []string{"id"}},
+		{template: "/health", expected: "/health"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			path, params := Path(tt.template)
+			assert.Equal(t, tt.expected, path)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	routes := []common.RouteInfo{
+		{
+			Method: "POST",
+			Path:   "/items/{id}",
+			Name:   "items.create",
+			Doc: common.RouteDoc{
+				Summary:  "Create item",
+				Params:   map[string]string{"id": "integer"},
+				Query:    map[string]string{"verbose": "boolean"},
+				Request:  testItem{},
+				Response: testItem{},
+				Status:   http.StatusCreated,
+				Errors:   []int{http.StatusBadRequest},
+			},
+		},
+		{Method: "GET", Path: "/hidden", Doc: common.RouteDoc{Hidden: true}},
+	}
+
+	doc := Generate(Info{Title: "Test", Version: "1"}, routes)
+
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.NotContains(t, doc.Paths, "/hidden")
+
+	op := doc.Paths["/items/{id}"]["post"]
+	assert.Equal(t, "items.create", op.OperationID)
+	assert.Equal(t, "integer", op.Parameters[0].Schema["type"])
+	assert.Equal(t, "query", op.Parameters[1].In)
+	assert.False(t, op.Parameters[1].Required)
+	assert.Contains(t, op.Responses, "201")
+	assert.Contains(t, op.Responses, "400")
+
+	// Wrapped responses put the payload under data
+	data := op.Responses["201"].Content["application/json"].Schema["properties"].(map[string]Schema)["data"]
+	assert.Equal(t, "#/components/schemas/openapi.testItem", data["$ref"])
+
+	item := doc.Components.Schemas["openapi.testItem"]
+	properties := item["properties"].(map[string]Schema)
+	assert.Equal(t, "date-time", properties["created_at"]["format"])
+	assert.Equal(t, "array", properties["tags"]["type"])
+	assert.Equal(t, "number", properties["weight"]["type"])
+	assert.NotContains(t, properties, "Secret")
+	assert.Equal(t, []string{"created_at", "id", "tags"}, item["required"])
+}
+
+func TestGenerateSecurity(t *testing.T) {
+	routes := []common.RouteInfo{
+		{Method: "GET", Path: "/public", Auth: common.AuthNone},
+		{Method: "GET", Path: "/private", Auth: common.AuthJWT},
+		{Method: "GET", Path: "/optional", Auth: common.AuthOptionalJWT},
+	}
+
+	doc := Generate(Info{Title: "Test", Version: "1"}, routes)
+
+	assert.Empty(t, doc.Paths["/public"]["get"].Security)
+	assert.Equal(t, []map[string][]string{{bearerScheme: {}}}, doc.Paths["/private"]["get"].Security)
+	assert.Equal(t, []map[string][]string{{}, {bearerScheme: {}}}, doc.Paths["/optional"]["get"].Security)
+	assert.NotContains(t, doc.Paths["/optional"]["get"].Responses, "401")
+	assert.Contains(t, doc.Components.SecuritySchemes, bearerScheme)
+}
@@ -0,0 +1,358 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// operation is a single JSON Patch operation
+type operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// applyJSONPatch applies an RFC 6902 patch. Operations apply in order and
+// the patch is atomic: the first failing operation discards all of them.
+func applyJSONPatch(doc interface{}, data []byte) (interface{}, error) {
+	ops, err := decodeOperations(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// decodeOperations decodes and checks the members of every operation
+func decodeOperations(data []byte) ([]operation, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations: %v", ErrInvalidPatch, err)
+	}
+
+	ops := make([]operation, len(raw))
+	for i, members := range raw {
+		op := &ops[i]
+		if err := decodeMember(members, "op", &op.Op); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+		if err := decodeMember(members, "path", &op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if err := decodeMember(members, "value", &op.Value); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "move", "copy":
+			if err := decodeMember(members, "from", &op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+	}
+	return ops, nil
+}
+
+// decodeMember decodes a required member of an operation object
+func decodeMember(members map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := members[name]
+	if !ok {
+		return fmt.Errorf("missing %q", name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid %q: %v", name, err)
+	}
+	return nil
+}
+
+// applyOperation applies a single operation to doc
+func applyOperation(doc interface{}, op operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, op.Value)
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return op.Value, nil
+		}
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, op.Value)
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move %q into one of its children", ErrInvalidPath, op.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	default: // test
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, fmt.Errorf("%w: value is %s", ErrTestFailed, encode(value))
+		}
+		return doc, nil
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: %q must be empty or start with /", ErrInvalidPath, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] != '~' {
+				continue
+			}
+			if j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1') {
+				return nil, fmt.Errorf("%w: %q has an invalid ~ escape", ErrInvalidPath, pointer)
+			}
+			j++
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at path
+func get(node interface{}, path []string) (interface{}, error) {
+	for depth, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, notFound(path[:depth+1])
+			}
+			node = child
+		case []interface{}:
+			i, err := index(token, len(n)-1, path[:depth+1])
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, notFound(path[:depth+1])
+		}
+	}
+	return node, nil
+}
+
+// add inserts value at path: it sets an object member, inserts into an
+// array before the index or appends with -, and returns the updated node
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	return addAt(node, path, value, 0)
+}
+
+func addAt(node interface{}, path []string, value interface{}, depth int) (interface{}, error) {
+	if depth == len(path) {
+		return value, nil
+	}
+	token := path[depth]
+	last := depth == len(path)-1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, notFound(path[:depth+1])
+		}
+		updated, err := addAt(child, path, value, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		if last {
+			i := len(n)
+			if token != "-" {
+				var err error
+				if i, err = index(token, len(n), path[:depth+1]); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := index(token, len(n)-1, path[:depth+1])
+		if err != nil {
+			return nil, err
+		}
+		if n[i], err = addAt(n[i], path, value, depth+1); err != nil {
+			return nil, err
+		}
+		return n, nil
+	default:
+		return nil, notFound(path[:depth+1])
+	}
+}
+
+// remove deletes the value at path, returning the updated node and the
+// removed value
+func remove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPath)
+	}
+	return removeAt(node, path, 0)
+}
+
+func removeAt(node interface{}, path []string, depth int) (interface{}, interface{}, error) {
+	token := path[depth]
+	last := depth == len(path)-1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, notFound(path[:depth+1])
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		updated, removed, err := removeAt(child, path, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = updated
+		return n, removed, nil
+	case []interface{}:
+		i, err := index(token, len(n)-1, path[:depth+1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		updated, removed, err := removeAt(n[i], path, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = updated
+		return n, removed, nil
+	default:
+		return nil, nil, notFound(path[:depth+1])
+	}
+}
+
+// index parses an array index token no greater than max
+func index(token string, max int, path []string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPath, pointer(path))
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > max {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidPath, pointer(path))
+	}
+	return i, nil
+}
+
+// notFound reports a path referencing a missing value
+func notFound(path []string) error {
+	return fmt.Errorf("%w: %q does not exist", ErrInvalidPath, pointer(path))
+}
+
+// pointer formats reference tokens back into a JSON Pointer
+func pointer(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// isProperPrefix reports whether prefix refers to an ancestor of path
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// deepCopy copies a decoded JSON value so later operations cannot alias it
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			c[name] = deepCopy(member)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, element := range v {
+			c[i] = deepCopy(element)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// encode formats a value for error messages
+func encode(value interface{}) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
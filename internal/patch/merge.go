@@ -0,0 +1,38 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// applyMergePatch applies an RFC 7396 merge patch: object members are
+// merged recursively, null removes a member and any other value replaces
+// the target
+func applyMergePatch(target interface{}, data []byte) (interface{}, error) {
+	var patch interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return mergePatch(target, patch), nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7396
+func mergePatch(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = mergePatch(result[name], value)
+	}
+	return result
+}
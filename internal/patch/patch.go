@@ -0,0 +1,145 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON resources.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/example/go-template/internal/common"
+)
+
+// Patch media types
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// AcceptPatch lists the supported media types, as sent in Accept-Patch
+const AcceptPatch = MediaTypeMergePatch + ", " + MediaTypeJSONPatch
+
+// MaxBodyBytes bounds the patch documents read by Request
+const MaxBodyBytes = 1 << 20
+
+// Errors of reading and applying patch documents
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type, use " + AcceptPatch)
+	ErrInvalidPatch         = errors.New("invalid patch")
+	ErrTooLarge             = errors.New("patch is too large")
+	ErrInvalidPath          = errors.New("invalid path")
+	ErrTestFailed           = errors.New("test failed")
+	ErrInvalidResult        = errors.New("invalid patched resource")
+)
+
+// OpError reports the JSON Patch operation that failed
+type OpError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+// Error implements the error interface
+func (e *OpError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Apply applies a patch of the given media type to a JSON document
+func Apply(mediaType string, doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("decoding document: %w", err)
+	}
+
+	var result interface{}
+	var err error
+	switch mediaType {
+	case MediaTypeMergePatch:
+		result, err = applyMergePatch(target, patch)
+	case MediaTypeJSONPatch:
+		result, err = applyJSONPatch(target, patch)
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// Request applies the patch in the body of r to current and decodes the
+// patched resource into out. The media type comes from Content-Type, and
+// fields unknown to out are rejected. Bodies over MaxBodyBytes are refused.
+func Request(w http.ResponseWriter, r *http.Request, current, out interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ErrUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("%w: the body is over %d bytes", ErrTooLarge, tooLarge.Limit)
+		}
+		return fmt.Errorf("%w: reading body: %v", ErrInvalidPatch, err)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	patched, err := Apply(mediaType, doc, body)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidResult, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// StatusCode returns the HTTP status answering a patch error: 415 for
+// unsupported media types, 413 for oversized patches, 400 for malformed
+// patches, 409 for failed test operations and 422 for invalid paths and
+// results
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrInvalidPatch):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrTestFailed):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidPath), errors.Is(err, ErrInvalidResult):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError writes a patch error with its status code, advertising the
+// supported media types on 415
+func WriteError(w http.ResponseWriter, err error) {
+	status := StatusCode(err)
+	if status == http.StatusUnsupportedMediaType {
+		w.Header().Set("Accept-Patch", AcceptPatch)
+	}
+	common.WriteError(w, status, err.Error())
+}
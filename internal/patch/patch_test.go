@@ -0,0 +1,159 @@
+package patch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396 appendix A
+	tests := []struct {
+		target   string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			result, err := Apply(MediaTypeMergePatch, []byte(tt.target), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	_, err := Apply(MediaTypeMergePatch, []byte(`{}`), []byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestJSONPatch(t *testing.T) {
+	// Mostly examples from RFC 6902 appendix A
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      error
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{"add null value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":null}]`, `{"foo":"bar","baz":null}`, nil},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"replace document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"copy value", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`, nil},
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, "", ErrTestFailed},
+		{"add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, "", ErrInvalidPath},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, "", ErrInvalidPath},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, "", ErrInvalidPath},
+		{"array index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":1}]`, "", ErrInvalidPath},
+		{"leading zero index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, "", ErrInvalidPath},
+		{"invalid pointer", `{"foo":1}`, `[{"op":"remove","path":"foo"}]`, "", ErrInvalidPath},
+		{"invalid escape", `{"foo":1}`, `[{"op":"remove","path":"/f~2"}]`, "", ErrInvalidPath},
+		{"move into child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "", ErrInvalidPath},
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a"}]`, "", ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, "", ErrInvalidPatch},
+		{"missing from", `{}`, `[{"op":"copy","path":"/a"}]`, "", ErrInvalidPatch},
+		{"not an array", `{}`, `{"op":"add","path":"/a","value":1}`, "", ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply(MediaTypeJSONPatch, []byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+func TestJSONPatchReportsFailingOperation(t *testing.T) {
+	_, err := Apply(MediaTypeJSONPatch, []byte(`{"name":"Ada"}`),
+		[]byte(`[{"op":"replace","path":"/name","value":"Bob"},{"op":"test","path":"/name","value":"Ada"}]`))
+
+	var opErr *OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, 1, opErr.Index)
+	assert.Equal(t, "test", opErr.Op)
+	assert.Equal(t, `operation 1 (test /name): test failed: value is "Bob"`, err.Error())
+}
+
+func TestRequest(t *testing.T) {
+	type resource struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	current := resource{Name: "Ada", Email: "ada@example.com"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    resource
+		status      int
+	}{
+		{"merge patch", MediaTypeMergePatch, `{"email":"ada@lovelace.dev"}`, resource{"Ada", "ada@lovelace.dev"}, http.StatusOK},
+		{"json patch with charset", MediaTypeJSONPatch + "; charset=utf-8", `[{"op":"replace","path":"/name","value":"Ada L."}]`, resource{"Ada L.", "ada@example.com"}, http.StatusOK},
+		{"plain json", "application/json", `{"name":"x"}`, resource{}, http.StatusUnsupportedMediaType},
+		{"unknown field", MediaTypeMergePatch, `{"age":36}`, resource{}, http.StatusUnprocessableEntity},
+		{"wrong type", MediaTypeMergePatch, `{"name":1}`, resource{}, http.StatusUnprocessableEntity},
+		{"failed test", MediaTypeJSONPatch, `[{"op":"test","path":"/name","value":"Bob"}]`, resource{}, http.StatusConflict},
+		{"invalid path", MediaTypeJSONPatch, `[{"op":"remove","path":"/nickname"}]`, resource{}, http.StatusUnprocessableEntity},
+		{"malformed", MediaTypeJSONPatch, `[{"op":"add"}]`, resource{}, http.StatusBadRequest},
+		{"too large", MediaTypeMergePatch, `{"name":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, resource{}, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			var patched resource
+			err := Request(httptest.NewRecorder(), req, current, &patched)
+			if tt.status != http.StatusOK {
+				assert.Equal(t, tt.status, StatusCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, patched)
+		})
+	}
+}
+
+func TestWriteErrorAdvertisesMediaTypes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, ErrUnsupportedMediaType)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, AcceptPatch, rec.Header().Get("Accept-Patch"))
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+)
+
+// Limiter matches routes to rules and decides on requests
+type Limiter struct {
+	rules   []config.RateLimitRule
+	trusted []netip.Prefix
+	store   Store
+	now     func() time.Time
+}
+
+// New creates a limiter applying the rules of cfg with the given store
+func New(cfg config.RateLimitConfig, store Store) *Limiter {
+	return &Limiter{
+		rules:   cfg.ParsedRules(),
+		trusted: cfg.TrustedPrefixes(),
+		store:   store,
+		now:     time.Now,
+	}
+}
+
+// RuleFor returns the most specific rule matching a route name: an exact
+// match, then the longest matching group such as users.*, then *
+func (l *Limiter) RuleFor(route string) (config.RateLimitRule, bool) {
+	var best config.RateLimitRule
+	bestScore := -1
+	for _, rule := range l.rules {
+		score := -1
+		switch {
+		case rule.Target == route:
+			score = len(rule.Target) + 1<<16
+		case rule.Target == "*":
+			score = 0
+		case strings.HasSuffix(rule.Target, ".*") && strings.HasPrefix(route, strings.TrimSuffix(rule.Target, "*")):
+			score = len(rule.Target)
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// Allow counts the request against the rule, keyed by the client the rule
+// selects
+func (l *Limiter) Allow(r *http.Request, rule config.RateLimitRule) (Result, error) {
+	key := rule.Target + "|" + l.ClientKey(r, rule.Key)
+	return l.store.Allow(r.Context(), key, Limit{
+		Algorithm: rule.Algorithm,
+		Limit:     rule.Limit,
+		Period:    rule.Period,
+		Burst:     rule.Burst,
+	}, l.now())
+}
+
+// ClientKey identifies the client of a request. Subject and API key
+// identities come from the context, where authenticators put them once
+// validated, and fall back to the client IP when the request has none. A
+// raw X-API-Key header is never trusted, as a client could otherwise get a
+// fresh bucket with every request. API keys are hashed so they are never
+// stored.
+func (l *Limiter) ClientKey(r *http.Request, key string) string {
+	switch key {
+	case config.KeySubject:
+		if subject, ok := common.Principal(r.Context()); ok && subject != "" {
+			return "sub:" + subject
+		}
+	case config.KeyAPIKey:
+		if apiKey, ok := common.APIKey(r.Context()); ok {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + l.ClientIP(r)
+}
+
+// ClientIP returns the address of the client. When the peer is a trusted
+// proxy, X-Forwarded-For is walked from the right and the first address that
+// is not a trusted proxy is the client.
+func (l *Limiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	peer = peer.Unmap()
+	if !l.isTrusted(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !l.isTrusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// isTrusted reports whether addr is a trusted proxy
+func (l *Limiter) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range l.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run sweeps the store when it keeps state in memory, until ctx is canceled
+func (l *Limiter) Run(ctx context.Context) {
+	if store, ok := l.store.(*MemoryStore); ok {
+		store.Run(ctx, time.Minute)
+	}
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Algorithm: config.AlgorithmTokenBucket, Limit: 1, Period: time.Second, Burst: 3}
+	allow := func(at time.Duration) Result {
+		res, err := store.Allow(context.Background(), "k", limit, epoch.Add(at))
+		require.NoError(t, err)
+		return res
+	}
+
+	// The burst is available at once
+	for i := 2; i >= 0; i-- {
+		res := allow(0)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+		assert.Equal(t, 3, res.Limit)
+	}
+
+	res := allow(0)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.ResetAfter)
+
+	// One token refills per second
+	assert.False(t, allow(500*time.Millisecond).Allowed)
+	assert.True(t, allow(time.Second).Allowed)
+	assert.False(t, allow(time.Second).Allowed)
+
+	// The bucket never holds more than the burst
+	res = allow(time.Hour)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Algorithm: config.AlgorithmSlidingWindow, Limit: 4, Period: time.Minute}
+	allow := func(at time.Duration) Result {
+		res, err := store.Allow(context.Background(), "k", limit, epoch.Add(at))
+		require.NoError(t, err)
+		return res
+	}
+
+	for i := 3; i >= 0; i-- {
+		res := allow(30 * time.Second)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res := allow(30 * time.Second)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 30*time.Second, res.ResetAfter)
+	// In the next window the 4 requests weigh 3 after 15s, leaving room for one
+	assert.Equal(t, 45*time.Second, res.RetryAfter)
+
+	assert.False(t, allow(74*time.Second).Allowed)
+	assert.True(t, allow(75*time.Second).Allowed)
+	assert.False(t, allow(75*time.Second).Allowed)
+
+	// Two windows later nothing is remembered
+	res = allow(3 * time.Minute)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 3, res.Remaining)
+}
+
+func TestSlidingWindowRetryAfterDecay(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Algorithm: config.AlgorithmSlidingWindow, Limit: 2, Period: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		_, _ = store.Allow(context.Background(), "k", limit, epoch.Add(50*time.Second))
+	}
+	// At 1m10s the previous window weighs 2*50/60, the current one 0
+	res, _ := store.Allow(context.Background(), "k", limit, epoch.Add(70*time.Second))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 20*time.Second, res.RetryAfter)
+}
+
+func TestSweepDropsExpiredKeys(t *testing.T) {
+	store := NewMemoryStore()
+	bucket := Limit{Algorithm: config.AlgorithmTokenBucket, Limit: 1, Period: time.Second, Burst: 1}
+	window := Limit{Algorithm: config.AlgorithmSlidingWindow, Limit: 1, Period: time.Minute}
+
+	_, _ = store.Allow(context.Background(), "bucket", bucket, epoch)
+	_, _ = store.Allow(context.Background(), "window", window, epoch)
+
+	store.Sweep(epoch.Add(2 * time.Second))
+	assert.Equal(t, 1, store.Len())
+	store.Sweep(epoch.Add(3 * time.Minute))
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestRuleFor(t *testing.T) {
+	limiter := New(config.RateLimitConfig{Rules: []string{
+		"*=100/1s",
+		"users.*=20/1s",
+		"users.create=5/1s",
+		"calculator.*=50/1s",
+	}}, NewMemoryStore())
+
+	tests := []struct {
+		route  string
+		target string
+	}{
+		{"users.create", "users.create"},
+		{"users.get", "users.*"},
+		{"calculator.add", "calculator.*"},
+		{"auth.login", "*"},
+		{"usersx.get", "*"},
+	}
+	for _, tt := range tests {
+		rule, ok := limiter.RuleFor(tt.route)
+		require.True(t, ok, tt.route)
+		assert.Equal(t, tt.target, rule.Target, tt.route)
+	}
+
+	_, ok := New(config.RateLimitConfig{Rules: []string{"users.*=1/1s"}}, NewMemoryStore()).RuleFor("auth.login")
+	assert.False(t, ok)
+}
+
+func TestClientIP(t *testing.T) {
+	limiter := New(config.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}}, NewMemoryStore())
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer ignores header", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.0.0.2:5000", []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, "198.51.100.1"},
+		{"spoofed leftmost entry", "10.0.0.2:5000", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"ipv6 proxy", "[2001:db8::1]:443", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"garbage header", "10.0.0.2:5000", []string{"not-an-ip"}, "10.0.0.2"},
+		{"only proxies", "10.0.0.2:5000", []string{"10.9.9.9"}, "10.9.9.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			assert.Equal(t, tt.want, limiter.ClientIP(req))
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	limiter := New(config.RateLimitConfig{}, NewMemoryStore())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+
+	assert.Equal(t, "ip:203.0.113.7", limiter.ClientKey(req, config.KeyIP))
+	assert.Equal(t, "ip:203.0.113.7", limiter.ClientKey(req, config.KeySubject))
+	assert.Equal(t, "ip:203.0.113.7", limiter.ClientKey(req, config.KeyAPIKey))
+
+	// An unvalidated header does not pick a bucket
+	req.Header.Set("X-API-Key", "secret-key")
+	assert.Equal(t, "ip:203.0.113.7", limiter.ClientKey(req, config.KeyAPIKey))
+
+	req = req.WithContext(common.WithAPIKey(req.Context(), "secret-key"))
+	key := limiter.ClientKey(req, config.KeyAPIKey)
+	assert.Regexp(t, `^key:[0-9a-f]{32}$`, key)
+	assert.NotContains(t, key, "secret-key")
+
+	req = req.WithContext(common.WithPrincipal(req.Context(), "user@example.com"))
+	assert.Equal(t, "sub:user@example.com", limiter.ClientKey(req, config.KeySubject))
+}
@@ -0,0 +1,193 @@
+// Package ratelimit limits requests per client IP, authenticated subject or
+// API key with token bucket or sliding window algorithms.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/example/go-template/internal/config"
+)
+
+// Limit is the quota applied to a single key
+type Limit struct {
+	Algorithm string
+	// Limit requests are allowed per Period
+	Limit  int
+	Period time.Duration
+	// Burst is the token bucket capacity
+	Burst int
+}
+
+// Result is the decision for a single request
+type Result struct {
+	Allowed bool
+	// Limit is the quota reported to clients: the bucket capacity or the
+	// window limit
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the quota is fully available again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied
+	RetryAfter time.Duration
+}
+
+// Store keeps the rate limit state. A shared backend, such as Redis running
+// the algorithms in scripts, lets several instances enforce one quota.
+type Store interface {
+	// Allow counts one request for key and reports whether it fits the limit
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// state is the per-key state of both algorithms
+type state struct {
+	// Token bucket
+	tokens float64
+	last   time.Time
+
+	// Sliding window
+	windowStart time.Time
+	previous    float64
+	current     float64
+
+	expires time.Time
+}
+
+// MemoryStore keeps the state in process memory
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*state
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*state)}
+}
+
+// Allow implements Store
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[key]
+	if !ok {
+		st = &state{}
+		s.states[key] = st
+	}
+	if limit.Algorithm == config.AlgorithmSlidingWindow {
+		return slidingWindow(st, limit, now), nil
+	}
+	return tokenBucket(st, limit, now), nil
+}
+
+// Len returns the number of keys tracked
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.states)
+}
+
+// Sweep drops the keys whose state no longer affects any decision
+func (s *MemoryStore) Sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, st := range s.states {
+		if now.After(st.expires) {
+			delete(s.states, key)
+		}
+	}
+}
+
+// Run sweeps the store every interval until ctx is canceled
+func (s *MemoryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Sweep(now)
+		}
+	}
+}
+
+// tokenBucket refills Limit tokens per Period up to Burst and takes one
+func tokenBucket(st *state, limit Limit, now time.Time) Result {
+	capacity := float64(limit.Burst)
+	perSecond := float64(limit.Limit) / limit.Period.Seconds()
+
+	if st.last.IsZero() {
+		st.tokens = capacity
+	} else if elapsed := now.Sub(st.last).Seconds(); elapsed > 0 {
+		st.tokens = math.Min(capacity, st.tokens+elapsed*perSecond)
+	}
+	st.last = now
+
+	result := Result{Limit: limit.Burst}
+	if st.tokens >= 1 {
+		st.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - st.tokens) / perSecond)
+	}
+	result.Remaining = int(math.Floor(st.tokens))
+	result.ResetAfter = seconds((capacity - st.tokens) / perSecond)
+	st.expires = now.Add(result.ResetAfter)
+	return result
+}
+
+// slidingWindow estimates the requests in the last Period from the counts of
+// the current and previous fixed windows, weighting the previous one by its
+// overlap with the sliding window
+func slidingWindow(st *state, limit Limit, now time.Time) Result {
+	start := now.Truncate(limit.Period)
+	switch {
+	case st.windowStart.Equal(start):
+	case st.windowStart.Add(limit.Period).Equal(start):
+		st.previous, st.current = st.current, 0
+		st.windowStart = start
+	default:
+		st.previous, st.current = 0, 0
+		st.windowStart = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - elapsed.Seconds()/limit.Period.Seconds()
+	max := float64(limit.Limit)
+	estimate := st.previous*weight + st.current
+
+	result := Result{Limit: limit.Limit, ResetAfter: limit.Period - elapsed}
+	if estimate+1 <= max {
+		st.current++
+		estimate++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = slidingRetryAfter(st, max, elapsed, limit.Period)
+	}
+	result.Remaining = int(math.Max(0, math.Floor(max-estimate)))
+	st.expires = start.Add(2 * limit.Period)
+	return result
+}
+
+// slidingRetryAfter returns the time until the estimate leaves room for one
+// more request
+func slidingRetryAfter(st *state, max float64, elapsed, period time.Duration) time.Duration {
+	untilNextWindow := period - elapsed
+	if st.current+1 > max {
+		// The current window alone is full; in the next one it becomes the
+		// previous window
+		weight := (max - 1) / st.current
+		return untilNextWindow + time.Duration((1-weight)*float64(period))
+	}
+	// Wait until the previous window's weight has decayed enough
+	needed := 1 - (max-1-st.current)/st.previous
+	return time.Duration(needed*float64(period)) - elapsed
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
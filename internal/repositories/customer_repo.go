@@ -1,43 +1,153 @@
 package repositories
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
 	"github.com/example/go-template/internal/domain"
+	"github.com/example/go-template/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrVersionMismatch  = errors.New("customer was modified since it was read")
 )
 
-// CustomerRepository interface for customer data access
+// CustomerRepository interface for customer data access. Updates and deletes
+// take the version the caller last read and fail with ErrVersionMismatch
+// when the stored customer changed since; 0 skips the check.
 type CustomerRepository interface {
-	GetCustomer(id string) (*domain.Customer, error)
-	ListCustomers() ([]*domain.Customer, error)
+	GetCustomer(ctx context.Context, id string) (*domain.Customer, error)
+	ListCustomers(ctx context.Context) ([]*domain.Customer, error)
+	CreateCustomer(ctx context.Context, customer *domain.Customer) error
+	UpdateCustomer(ctx context.Context, customer *domain.Customer, expectedVersion int) error
+	DeleteCustomer(ctx context.Context, id string, expectedVersion int) error
+	// Ping reports whether the store is reachable
+	Ping(ctx context.Context) error
+}
+
+// startSpan starts a span for a repository operation
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "CustomerRepository."+operation,
+		attribute.String("db.system", "memory"), attribute.String("db.operation", operation))
 }
 
-// InMemoryCustomerRepository implements CustomerRepository with in-memory storage
+// InMemoryCustomerRepository implements CustomerRepository with in-memory
+// storage. It hands out copies, so callers never share the stored customers.
 type InMemoryCustomerRepository struct {
-	customers map[string]*domain.Customer
+	mu        sync.RWMutex
+	customers map[string]domain.Customer
+	nextID    int
 }
 
 // NewInMemoryCustomerRepository creates a new in-memory customer repository
 func NewInMemoryCustomerRepository() *InMemoryCustomerRepository {
 	return &InMemoryCustomerRepository{
-		customers: map[string]*domain.Customer{
-			"1": {ID: "1", Name: "John Doe", Email: "john@example.com"},
-			"2": {ID: "2", Name: "Jane Smith", Email: "jane@example.com"},
+		customers: map[string]domain.Customer{
+			"1": {ID: "1", Name: "John Doe", Email: "john@example.com", Version: 1},
+			"2": {ID: "2", Name: "Jane Smith", Email: "jane@example.com", Version: 1},
 		},
+		nextID: 3,
 	}
 }
 
-// GetCustomer retrieves a customer by ID
-func (r *InMemoryCustomerRepository) GetCustomer(id string) (*domain.Customer, error) {
+// GetCustomer retrieves a customer by ID, returning nil when it does not exist
+func (r *InMemoryCustomerRepository) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	_, span := startSpan(ctx, "GetCustomer")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	if customer, exists := r.customers[id]; exists {
-		return customer, nil
+		return &customer, nil
 	}
 	return nil, nil
 }
 
-// ListCustomers returns all customers
-func (r *InMemoryCustomerRepository) ListCustomers() ([]*domain.Customer, error) {
+// ListCustomers returns all customers ordered by ID
+func (r *InMemoryCustomerRepository) ListCustomers(ctx context.Context) ([]*domain.Customer, error) {
+	_, span := startSpan(ctx, "ListCustomers")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	customers := make([]*domain.Customer, 0, len(r.customers))
 	for _, customer := range r.customers {
-		customers = append(customers, customer)
+		customer := customer
+		customers = append(customers, &customer)
 	}
+	sort.Slice(customers, func(i, j int) bool {
+		a, _ := strconv.Atoi(customers[i].ID)
+		b, _ := strconv.Atoi(customers[j].ID)
+		return a < b
+	})
 	return customers, nil
 }
+
+// CreateCustomer stores a new customer, assigning its ID and first version
+func (r *InMemoryCustomerRepository) CreateCustomer(ctx context.Context, customer *domain.Customer) error {
+	_, span := startSpan(ctx, "CreateCustomer")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer.ID = strconv.Itoa(r.nextID)
+	customer.Version = 1
+	r.nextID++
+	r.customers[customer.ID] = *customer
+	return nil
+}
+
+// UpdateCustomer replaces a customer, bumping its version
+func (r *InMemoryCustomerRepository) UpdateCustomer(ctx context.Context, customer *domain.Customer, expectedVersion int) error {
+	_, span := startSpan(ctx, "UpdateCustomer")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.customers[customer.ID]
+	if !exists {
+		return ErrCustomerNotFound
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return ErrVersionMismatch
+	}
+
+	customer.Version = stored.Version + 1
+	r.customers[customer.ID] = *customer
+	return nil
+}
+
+// DeleteCustomer removes a customer
+func (r *InMemoryCustomerRepository) DeleteCustomer(ctx context.Context, id string, expectedVersion int) error {
+	_, span := startSpan(ctx, "DeleteCustomer")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.customers[id]
+	if !exists {
+		return ErrCustomerNotFound
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return ErrVersionMismatch
+	}
+
+	delete(r.customers, id)
+	return nil
+}
+
+// Ping reports whether the store is reachable, which memory always is
+func (r *InMemoryCustomerRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/example/go-template/internal/di"
+	"github.com/example/go-template/internal/health"
+)
+
+// ListenHook returns the lifecycle hook running httpServer. Start binds the
+// listener, so a busy port fails startup, and serves in the background,
+// sending a serve failure on errs. Stop fails readiness, waits drainDelay for
+// load balancers to notice, then drains in-flight requests until ctx expires
+// and closes the remaining connections.
+func ListenHook(httpServer *http.Server, checks *health.Health, drainDelay time.Duration, errs chan<- error) di.Hook {
+	return di.Hook{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			var lc net.ListenConfig
+			listener, err := lc.Listen(ctx, "tcp", httpServer.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errs <- err
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			checks.MarkShuttingDown()
+
+			select {
+			case <-time.After(drainDelay):
+			case <-ctx.Done():
+			}
+
+			if err := httpServer.Shutdown(ctx); err != nil {
+				_ = httpServer.Close()
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/example/go-template/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr returns a local address with a free port
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+// slowServer returns a server whose requests block until release is closed
+func slowServer(addr string, entered chan<- struct{}, release <-chan struct{}) *http.Server {
+	return &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+}
+
+func TestListenHookDrainsInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	checks := health.New(time.Second, 0)
+	checks.MarkStarted()
+	hook := ListenHook(slowServer(addr, entered, release), checks, 10*time.Millisecond, make(chan error, 1))
+
+	require.NoError(t, hook.Start(context.Background()))
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- hook.Stop(context.Background()) }()
+
+	// Readiness fails while the in-flight request drains
+	assert.Eventually(t, checks.ShuttingDown, time.Second, time.Millisecond)
+	select {
+	case <-stopped:
+		t.Fatal("stop returned before the in-flight request completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.NoError(t, <-stopped)
+}
+
+func TestListenHookForcesCloseAfterGracePeriod(t *testing.T) {
+	addr := freeAddr(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	hook := ListenHook(slowServer(addr, entered, release), health.New(time.Second, 0), 0, make(chan error, 1))
+
+	require.NoError(t, hook.Start(context.Background()))
+	go func() {
+		if resp, err := http.Get("http://" + addr); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hook.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestListenHookFailsOnBusyPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	hook := ListenHook(&http.Server{Addr: l.Addr().String()}, health.New(time.Second, 0), 0, make(chan error, 1))
+	assert.Error(t, hook.Start(context.Background()))
+}
@@ -3,35 +3,47 @@ package server
 import (
 	"net/http"
 
+	"github.com/example/go-template/internal/api"
 	"github.com/example/go-template/internal/calculator"
 	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/correlation"
+	"github.com/example/go-template/internal/di"
 	"github.com/example/go-template/internal/greeting"
+	"github.com/example/go-template/internal/health"
+	"github.com/example/go-template/internal/metrics"
+	"github.com/example/go-template/internal/middleware"
+	"github.com/example/go-template/internal/openapi"
+	"github.com/example/go-template/internal/units"
 	"github.com/example/go-template/internal/user"
-	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // Server represents the HTTP server
 type Server struct {
 	common.BaseHandler
-	router            *mux.Router
-	calculatorHandler *calculator.Handler
-	userHandler       *user.Handler
-	greetingHandler   *greeting.Handler
+	router  *common.Router
+	modules []common.Module
+	openAPI http.HandlerFunc
+	metrics http.Handler
+	health  *health.Health
 }
 
-// New creates a new server instance
-func New() *Server {
-	// Create user service
-	userService := user.NewService()
-
+// New creates a new server instance mounting every feature module
+func New(providers *di.Providers) *Server {
 	s := &Server{
-		router:            mux.NewRouter(),
-		calculatorHandler: calculator.NewHandler(),
-		userHandler:       user.NewHandler(userService),
-		greetingHandler:   greeting.NewHandler(),
+		router: common.NewRouter(),
+		modules: []common.Module{
+			calculator.NewHandler(providers.Config.Calculator, providers.History, providers.Sessions),
+			units.NewHandler(units.Default()),
+			user.NewHandler(providers.UserService),
+			greeting.NewHandler(),
+			api.NewHandler(providers),
+		},
+		metrics: metrics.Handler(providers.Metrics),
+		health:  providers.Health,
 	}
 
+	s.setupMiddleware(providers)
 	s.setupRoutes()
 	return s
 }
@@ -41,41 +53,133 @@ func (s *Server) Router() http.Handler {
 	return s.router
 }
 
+// Routes returns the table of registered routes
+func (s *Server) Routes() *common.RouteTable {
+	return s.router.Routes()
+}
+
+// setupMiddleware configures the middleware shared by every module
+func (s *Server) setupMiddleware(providers *di.Providers) {
+	s.router.Use("correlation", correlation.Middleware())
+	s.router.Use("logger", middleware.Logger(providers.Logger))
+	s.router.Use("recover", middleware.Recover())
+	s.router.Use("cors", middleware.CORS(middleware.CORSConfig{
+		AllowOrigins: providers.Config.CORS.AllowOrigins,
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		ExposeHeaders: []string{
+			common.HeaderRequestID, common.HeaderETag,
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+			middleware.HeaderIdempotentReplayed,
+		},
+	}))
+	s.router.UseRoute("tracing", middleware.Tracing(providers.TracerProvider))
+	s.router.UseRoute("metrics", middleware.Metrics(providers.Metrics))
+	s.router.RequireAuth(common.AuthJWT, "jwt", middleware.JWTMiddleware(providers.AuthService))
+	s.router.RequireAuth(common.AuthOptionalJWT, "jwt_optional", middleware.OptionalJWTMiddleware(providers.AuthService))
+	if providers.Config.RateLimit.Enabled {
+		s.router.UseAfterAuth("ratelimit", middleware.RateLimit(providers.RateLimiter))
+	}
+	s.router.UseAfterAuth("idempotency", middleware.Idempotency(providers.Idempotency, providers.Config.Idempotency))
+}
+
 // setupRoutes configures the server routes
 func (s *Server) setupRoutes() {
-	// Health and root routes
-	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
-	s.router.HandleFunc("/", s.handleRoot).Methods("GET")
+	// Health, root and introspection routes
+	s.router.HandleGroup(common.RouteGroup{
+		Prefix: "",
+		Routes: []common.Route{
+			common.NamedRoute("/health", "GET", "system.health", s.handleHealth).WithDoc(common.RouteDoc{
+				Summary:     "Health check endpoint",
+				Description: "Returns the liveness status of the API; use /livez, /readyz and /startupz for per-check details",
+				Tags:        []string{"health"},
+				Response:    map[string]string{},
+				Errors:      []int{http.StatusServiceUnavailable},
+			}),
+			common.NamedRoute("/livez", "GET", "system.livez", s.health.Handler(health.Liveness)).WithDoc(probeDoc("Liveness probe")),
+			common.NamedRoute("/readyz", "GET", "system.readyz", s.health.Handler(health.Readiness)).WithDoc(probeDoc("Readiness probe")),
+			common.NamedRoute("/startupz", "GET", "system.startupz", s.health.Handler(health.Startup)).WithDoc(probeDoc("Startup probe")),
+			common.NamedRoute("/", "GET", "system.root", s.handleRoot).WithDoc(common.RouteDoc{
+				Summary:  "Welcome endpoint",
+				Tags:     []string{"general"},
+				Response: map[string]string{},
+			}),
+			common.NamedRoute("/_routes", "GET", "system.routes", s.handleRoutes).WithDoc(common.RouteDoc{
+				Summary:     "List routes",
+				Description: "Returns every registered route with its name, auth requirements and middleware",
+				Tags:        []string{"general"},
+				Response:    []common.RouteInfo{},
+			}),
+			common.NamedRoute("/openapi.json", "GET", "system.openapi", s.handleOpenAPI).WithDoc(common.RouteDoc{
+				Summary:  "OpenAPI document",
+				Tags:     []string{"general"},
+				Response: map[string]interface{}{},
+				Raw:      true,
+			}),
+			common.NamedRoute("/metrics", "GET", "system.metrics", s.handleMetrics).WithDoc(common.RouteDoc{Hidden: true}),
+			common.NamedRoute("/docs", "GET", "system.docs", s.handleDocs).WithDoc(common.RouteDoc{Hidden: true}),
+		},
+	})
+
+	// Swagger UI, rendering the document generated from the route table
+	swaggerUI := httpSwagger.Handler(httpSwagger.URL("/openapi.json"))
+	s.router.HandlePrefix("/docs/", "system.swagger", swaggerUI, common.RouteDoc{Hidden: true})
 
-	// Swagger UI
-	s.router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	// Mount every feature module
+	for _, module := range s.modules {
+		s.router.Mount(module)
+	}
+
+	// The OpenAPI document is built once every route is known
+	s.openAPI = openapi.Handler(openapi.Generate(openapi.Info{
+		Title:       "Go Template API",
+		Version:     "1.0",
+		Description: "A simple Go API template with JWT authentication, customer, calculator and user management endpoints",
+	}, s.router.Routes().Routes()))
+}
 
-	// Register all handlers
-	s.calculatorHandler.RegisterRoutes(s.router)
-	s.userHandler.RegisterRoutes(s.router)
-	s.greetingHandler.RegisterRoutes(s.router)
+// probeDoc documents a health probe route. Failing probes answer 503 with
+// the same report.
+func probeDoc(summary string) common.RouteDoc {
+	return common.RouteDoc{
+		Summary:     summary,
+		Description: "Runs the registered checks and reports each one; answers 503 when any fails",
+		Tags:        []string{"health"},
+		Response:    health.Report{},
+		Errors:      []int{http.StatusServiceUnavailable},
+		Raw:         true,
+	}
 }
 
 // handleHealth handles health check requests
-// @Summary Health check endpoint
-// @Description Returns the health status of the API
-// @Tags health
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Router /health [get]
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !s.health.Run(r.Context(), health.Liveness).OK() {
+		s.WriteError(w, http.StatusServiceUnavailable, "unhealthy")
+		return
+	}
 	s.WriteSuccess(w, map[string]string{"status": "ok"})
 }
 
+// handleRoutes lists the registered routes
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	s.WriteSuccess(w, s.router.Routes().Routes())
+}
+
+// handleOpenAPI serves the OpenAPI 3.1 document generated from the route table
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	s.openAPI(w, r)
+}
+
+// handleMetrics serves the metrics in the Prometheus text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.metrics.ServeHTTP(w, r)
+}
+
+// handleDocs redirects to the Swagger UI
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
+}
+
 // handleRoot handles root requests
-// @Summary Welcome endpoint
-// @Description Returns a welcome message for the API
-// @Tags general
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Router / [get]
 func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	s.WriteSuccess(w, map[string]string{"message": "Welcome to the Go Template API"})
 }
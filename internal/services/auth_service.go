@@ -1,17 +1,19 @@
 package services
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/example/go-template/internal/config"
 	"github.com/example/go-template/internal/domain"
+	"github.com/example/go-template/internal/logging"
+	"github.com/example/go-template/internal/tracing"
 )
 
 // AuthService handles JWT authentication
@@ -19,36 +21,24 @@ type AuthService struct {
 	secret    string
 	algorithm string
 	expiresIn int64
+	metrics   *Metrics
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService() *AuthService {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-super-secret-jwt-key-at-least-32-characters-long-for-hs256"
-	}
-
-	algorithm := os.Getenv("JWT_ALGORITHM")
-	if algorithm == "" {
-		algorithm = "HS256"
-	}
-
-	expiresIn := int64(3600)
-	if exp := os.Getenv("JWT_EXPIRATION"); exp != "" {
-		if parsedExp, err := strconv.ParseInt(exp, 10, 64); err == nil {
-			expiresIn = parsedExp
-		}
-	}
-
+// NewAuthService creates a new authentication service. m may be nil.
+func NewAuthService(cfg config.AuthConfig, m *Metrics) *AuthService {
 	return &AuthService{
-		secret:    secret,
-		algorithm: algorithm,
-		expiresIn: expiresIn,
+		secret:    cfg.JWTSecret,
+		algorithm: cfg.JWTAlgorithm,
+		expiresIn: int64(cfg.JWTExpiration / time.Second),
+		metrics:   m.orNone(),
 	}
 }
 
 // IssueToken generates a JWT token
-func (s *AuthService) IssueToken(subject string) (string, error) {
+func (s *AuthService) IssueToken(ctx context.Context, subject string) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.IssueToken")
+	defer span.End()
+
 	now := time.Now().Unix()
 	exp := now + s.expiresIn
 
@@ -76,11 +66,28 @@ func (s *AuthService) IssueToken(subject string) (string, error) {
 	signature := s.signHS256(message)
 
 	token := message + "." + signature
+	s.metrics.TokensIssued.Inc()
+	logging.FromContext(ctx).Info("token issued", "subject", subject, "expires_at", exp)
 	return token, nil
 }
 
 // ValidateToken validates a JWT token and returns claims
-func (s *AuthService) ValidateToken(tokenString string) (*domain.TokenClaims, error) {
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.TokenClaims, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateToken")
+	defer span.End()
+
+	claims, err := s.validateToken(tokenString)
+	if err != nil {
+		tracing.RecordError(span, err)
+		s.metrics.TokensRejected.Inc()
+		logging.FromContext(ctx).Warn("token rejected", "error", err)
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validateToken checks the token signature and expiration
+func (s *AuthService) validateToken(tokenString string) (*domain.TokenClaims, error) {
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
 		return nil, fmt.Errorf("invalid token format")
@@ -1,28 +1,129 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/example/go-template/internal/domain"
+	"github.com/example/go-template/internal/logging"
 	"github.com/example/go-template/internal/repositories"
+	"github.com/example/go-template/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Errors of customer creation and updates
+var (
+	ErrInvalidCustomer = errors.New("invalid customer")
 )
 
 // CustomerService handles customer business logic
 type CustomerService struct {
-	repo repositories.CustomerRepository
+	repo    repositories.CustomerRepository
+	metrics *Metrics
 }
 
-// NewCustomerService creates a new customer service
-func NewCustomerService(repo repositories.CustomerRepository) *CustomerService {
+// NewCustomerService creates a new customer service. m may be nil.
+func NewCustomerService(repo repositories.CustomerRepository, m *Metrics) *CustomerService {
 	return &CustomerService{
-		repo: repo,
+		repo:    repo,
+		metrics: m.orNone(),
 	}
 }
 
 // GetCustomer gets a customer by ID
-func (s *CustomerService) GetCustomer(id string) (*domain.Customer, error) {
-	return s.repo.GetCustomer(id)
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetCustomer", attribute.String("customer.id", id))
+	defer span.End()
+
+	logging.FromContext(ctx).Debug("getting customer", "customer_id", id)
+	customer, err := s.repo.GetCustomer(ctx, id)
+	tracing.RecordError(span, err)
+	return customer, err
 }
 
 // ListCustomers lists all customers
-func (s *CustomerService) ListCustomers() ([]*domain.Customer, error) {
-	return s.repo.ListCustomers()
+func (s *CustomerService) ListCustomers(ctx context.Context) ([]*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.ListCustomers")
+	defer span.End()
+
+	customers, err := s.repo.ListCustomers(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Error("failed to list customers", "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("customer.count", len(customers)))
+	logging.FromContext(ctx).Debug("listed customers", "count", len(customers))
+	return customers, nil
+}
+
+// CreateCustomer validates and stores a new customer
+func (s *CustomerService) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.CreateCustomer")
+	defer span.End()
+
+	if err := validateCustomer(name, email); err != nil {
+		return nil, err
+	}
+
+	customer := &domain.Customer{Name: name, Email: email}
+	if err := s.repo.CreateCustomer(ctx, customer); err != nil {
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Error("failed to create customer", "error", err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("customer.id", customer.ID))
+	s.metrics.CustomersCreated.Inc()
+	logging.FromContext(ctx).Info("customer created", "customer_id", customer.ID)
+	return customer, nil
+}
+
+// UpdateCustomer validates and replaces a customer. A non-zero version must
+// match the stored one, otherwise repositories.ErrVersionMismatch is returned.
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id, name, email string, version int) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.UpdateCustomer", attribute.String("customer.id", id))
+	defer span.End()
+
+	if err := validateCustomer(name, email); err != nil {
+		return nil, err
+	}
+
+	customer := &domain.Customer{ID: id, Name: name, Email: email}
+	if err := s.repo.UpdateCustomer(ctx, customer, version); err != nil {
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Info("customer not updated", "customer_id", id, "error", err)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("customer updated", "customer_id", id, "version", customer.Version)
+	return customer, nil
+}
+
+// DeleteCustomer deletes a customer. A non-zero version must match the stored
+// one, otherwise repositories.ErrVersionMismatch is returned.
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id string, version int) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.DeleteCustomer", attribute.String("customer.id", id))
+	defer span.End()
+
+	if err := s.repo.DeleteCustomer(ctx, id, version); err != nil {
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Info("customer not deleted", "customer_id", id, "error", err)
+		return err
+	}
+
+	logging.FromContext(ctx).Info("customer deleted", "customer_id", id)
+	return nil
+}
+
+// validateCustomer checks the fields every customer needs
+func validateCustomer(name, email string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+	if email == "" {
+		return fmt.Errorf("%w: email is required", ErrInvalidCustomer)
+	}
+	return nil
 }
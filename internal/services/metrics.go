@@ -0,0 +1,42 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics holds the business counters updated by the services. A nil
+// *Metrics disables them.
+type Metrics struct {
+	TokensIssued     prometheus.Counter
+	TokensRejected   prometheus.Counter
+	CustomersCreated prometheus.Counter
+}
+
+// NewMetrics registers the business counters on reg. A nil reg creates
+// counters that are never exposed.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	factory := promauto.With(reg)
+	return &Metrics{
+		TokensIssued: factory.NewCounter(prometheus.CounterOpts{
+			Name: "auth_tokens_issued_total",
+			Help: "Total number of JWT tokens issued.",
+		}),
+		TokensRejected: factory.NewCounter(prometheus.CounterOpts{
+			Name: "auth_token_rejections_total",
+			Help: "Total number of bearer tokens rejected because they are invalid or expired.",
+		}),
+		CustomersCreated: factory.NewCounter(prometheus.CounterOpts{
+			Name: "customers_created_total",
+			Help: "Total number of customers created.",
+		}),
+	}
+}
+
+// orNone returns m, or unexposed metrics when m is nil
+func (m *Metrics) orNone() *Metrics {
+	if m == nil {
+		return NewMetrics(nil)
+	}
+	return m
+}
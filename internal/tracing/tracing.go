@@ -0,0 +1,134 @@
+// Package tracing sets up OpenTelemetry tracing for requests, services and
+// repositories, exporting spans as OTLP, to stdout or in memory.
+//
+// Spans are started from the tracer provider of the span active in the
+// context: code calls tracing.Start(ctx, name) and gets a non-recording span
+// when the context is not traced.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of every span of the service
+const ScopeName = "github.com/example/go-template"
+
+// NewExporter creates the span exporter selected by the configuration, or
+// nil for none. The stdout exporter writes to w.
+func NewExporter(cfg config.TracingConfig, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case config.TracingStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("create stdout span exporter: %w", err)
+		}
+		return exporter, nil
+	case config.TracingMemory:
+		return tracetest.NewInMemoryExporter(), nil
+	case config.TracingOTLP:
+		// Creating the exporter does not connect to the collector
+		exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP span exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, nil
+	}
+}
+
+// NewProvider creates a tracer provider for serviceName. It propagates trace
+// contexts but exports nothing until a processor from NewProcessor is
+// registered.
+func NewProvider(serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithIDGenerator(requestIDs{}),
+	)
+}
+
+// NewProcessor creates the span processor handing ended spans to exporter.
+// The in-memory exporter receives them as soon as they end, so tests can
+// assert them. The others receive them in batches from a goroutine started
+// here, which Shutdown of the provider stops.
+func NewProcessor(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+	if _, ok := exporter.(*tracetest.InMemoryExporter); ok {
+		return sdktrace.NewSimpleSpanProcessor(exporter)
+	}
+	return sdktrace.NewBatchSpanProcessor(exporter)
+}
+
+// requestIDs generates span IDs, starting root spans in the trace of the
+// request correlation context so that every log line of a request shares
+// the trace ID of its spans
+type requestIDs struct{}
+
+// NewIDs returns the IDs of a root span
+func (requestIDs) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	if tc, ok := correlation.TraceContextFrom(ctx); ok {
+		traceID = tc.TraceID
+	} else {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, newSpanID()
+}
+
+// NewSpanID returns the ID of a child span
+func (requestIDs) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var id trace.SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+// Start starts a span, child of the span active in ctx and created by its
+// tracer provider, with the given attributes. The returned context carries
+// the span, and its trace context for logs and outbound calls.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(ScopeName)
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return Correlate(ctx), span
+}
+
+// Correlate returns a copy of ctx whose correlation trace context names the
+// span active in ctx, or ctx itself when no valid span is active
+func Correlate(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx
+	}
+	return correlation.WithTraceContext(ctx, correlation.TraceContext{
+		TraceID: sc.TraceID(),
+		SpanID:  sc.SpanID(),
+		Flags:   byte(sc.TraceFlags()),
+	})
+}
+
+// RecordError records err as an exception event and marks the span failed.
+// A nil err does nothing.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestProvider returns a tracer provider exporting to exporter
+func newTestProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	provider := NewProvider("test")
+	provider.RegisterSpanProcessor(NewProcessor(exporter))
+	return provider
+}
+
+// attributeValue returns the value of the named attribute of a span
+func attributeValue(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, a := range span.Attributes {
+		if string(a.Key) == key {
+			return a.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestStartBuildsSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newTestProvider(exporter)
+
+	ctx, root := provider.Tracer(ScopeName).Start(context.Background(), "root", trace.WithSpanKind(trace.SpanKindServer))
+	childCtx, child := Start(ctx, "child", attribute.String("k", "v"))
+	_, grandchild := Start(childCtx, "grandchild")
+	RecordError(grandchild, errors.New("boom"))
+	RecordError(grandchild, nil)
+	grandchild.End()
+	child.End()
+	root.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	g, c, r := spans[0], spans[1], spans[2]
+
+	assert.False(t, r.Parent.IsValid())
+	assert.Equal(t, trace.SpanKindServer, r.SpanKind)
+	assert.Equal(t, r.SpanContext.SpanID(), c.Parent.SpanID())
+	assert.Equal(t, c.SpanContext.SpanID(), g.Parent.SpanID())
+	assert.Equal(t, r.SpanContext.TraceID(), g.SpanContext.TraceID())
+
+	v, ok := attributeValue(c, "k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", v.AsString())
+
+	assert.Equal(t, codes.Error, g.Status.Code)
+	require.Len(t, g.Events, 1)
+	assert.Equal(t, "exception", g.Events[0].Name)
+
+	// The context carries the active span for logs and outbound calls
+	tc, ok := correlation.TraceContextFrom(childCtx)
+	require.True(t, ok)
+	assert.Equal(t, c.SpanContext.SpanID(), trace.SpanID(tc.SpanID))
+}
+
+func TestStartParents(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := newTestProvider(exporter).Tracer(ScopeName)
+	propagator := propagation.TraceContext{}
+
+	header := http.Header{}
+	header.Set(correlation.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, span := tracer.Start(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)), "remote")
+	span.End()
+
+	request := correlation.NewTraceContext()
+	_, span = tracer.Start(correlation.WithTraceContext(context.Background(), request), "root")
+	span.End()
+
+	header.Set(correlation.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span = tracer.Start(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)), "unsampled")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2, "unsampled spans are not exported")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	assert.Equal(t, trace.TraceID(request.TraceID), spans[1].SpanContext.TraceID(), "root spans join the request trace")
+	assert.False(t, spans[1].Parent.IsValid())
+}
+
+func TestStartWithoutSpan(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+
+	assert.False(t, span.IsRecording())
+	_, ok := correlation.TraceContextFrom(ctx)
+	assert.False(t, ok)
+	assert.NotPanics(t, func() {
+		span.SetAttributes(attribute.Int("n", 1))
+		RecordError(span, errors.New("ignored"))
+		span.End()
+	})
+}
+
+func TestNewExporter(t *testing.T) {
+	exporter, err := NewExporter(config.TracingConfig{Exporter: config.TracingNone}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, exporter)
+
+	exporter, err = NewExporter(config.TracingConfig{Exporter: config.TracingMemory}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &tracetest.InMemoryExporter{}, exporter)
+
+	var out bytes.Buffer
+	exporter, err = NewExporter(config.TracingConfig{Exporter: config.TracingStdout}, &out)
+	require.NoError(t, err)
+	provider := newTestProvider(exporter)
+	_, span := provider.Tracer(ScopeName).Start(context.Background(), "work", trace.WithAttributes(attribute.Int("items", 3)))
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	var line map[string]interface{}
+	require.NoError(t, json.NewDecoder(&out).Decode(&line))
+	assert.Equal(t, "work", line["Name"])
+}
+
+func TestOTLPExporter(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer collector.Close()
+
+	exporter, err := NewExporter(config.TracingConfig{Exporter: config.TracingOTLP, OTLPEndpoint: collector.URL + "/v1/traces"}, nil)
+	require.NoError(t, err)
+	provider := newTestProvider(exporter)
+
+	_, span := provider.Tracer(ScopeName).Start(context.Background(), "work")
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	r := <-requests
+	assert.Equal(t, "/v1/traces", r.URL.Path)
+	assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+}
+
+func TestTransportRecordsClientSpan(t *testing.T) {
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(correlation.HeaderTraceparent)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	ctx, _ := common.WithState(context.Background())
+	ctx, parent := newTestProvider(exporter).Tracer(ScopeName).Start(ctx, "handler")
+
+	client := &http.Client{Transport: &Transport{Base: &correlation.Transport{}}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/items", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	clientSpan := spans[0]
+	assert.Equal(t, "HTTP GET", clientSpan.Name)
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), clientSpan.Parent.SpanID())
+	assert.Equal(t, codes.Error, clientSpan.Status.Code)
+
+	// The upstream sees the client span as its parent
+	tc, err := correlation.ParseTraceparent(traceparent)
+	require.NoError(t, err)
+	assert.Equal(t, clientSpan.SpanContext.SpanID(), trace.SpanID(tc.SpanID))
+}
@@ -0,0 +1,109 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/gorilla/mux"
+)
+
+// ConversionResponse represents the result of a unit conversion
+type ConversionResponse struct {
+	Value    float64 `json:"value"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Result   float64 `json:"result"`
+	Quantity string  `json:"quantity"`
+}
+
+// UnitsResponse lists unit definitions and the prefixes they accept
+type UnitsResponse struct {
+	Units    []Definition        `json:"units"`
+	Prefixes map[string][]Prefix `json:"prefixes"`
+}
+
+// Handler handles unit conversion HTTP requests
+type Handler struct {
+	common.BaseHandler
+	registry *Registry
+}
+
+// NewHandler creates a new units handler converting with registry
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{registry: registry}
+}
+
+// RegisterRoutes registers the units routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	for _, group := range h.Routes() {
+		common.RegisterGroup(router, group)
+	}
+}
+
+// Routes returns the units route groups
+func (h *Handler) Routes() []common.RouteGroup {
+	return []common.RouteGroup{{
+		Routes: []common.Route{
+			common.NamedRoute("/convert", "GET", "units.convert", h.handleConvert).WithDoc(common.RouteDoc{
+				Summary:     "Convert between units",
+				Description: "Converts a value between units of the same quantity. Units take SI prefixes (km, mg, kWh) or binary ones for data (KiB), and combine with *, / and ^ into compound units such as km/h or kg*m/s^2.",
+				Tags:        []string{"units"},
+				Query:       map[string]string{"value": "number", "from": "string", "to": "string"},
+				Response:    ConversionResponse{},
+				Errors:      []int{http.StatusBadRequest},
+			}),
+			common.NamedRoute("/units", "GET", "units.list", h.handleListUnits).WithDoc(common.RouteDoc{
+				Summary:     "List units",
+				Description: "Lists the units that can be converted, optionally only those of one quantity, and the prefix groups they accept",
+				Tags:        []string{"units"},
+				Query:       map[string]string{"quantity": "string"},
+				Response:    UnitsResponse{},
+				Errors:      []int{http.StatusBadRequest},
+			}),
+		},
+	}}
+}
+
+// handleConvert handles unit conversion
+func (h *Handler) handleConvert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	value, err := strconv.ParseFloat(query.Get("value"), 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		h.WriteBadRequest(w, "value must be a finite number")
+		return
+	}
+	from, to := query.Get("from"), query.Get("to")
+	if from == "" || to == "" {
+		h.WriteBadRequest(w, "from and to are required")
+		return
+	}
+
+	result, err := h.registry.Convert(value, from, to)
+	if err != nil {
+		h.WriteBadRequest(w, err.Error())
+		return
+	}
+	unit, _ := h.registry.Parse(from)
+	h.WriteSuccess(w, ConversionResponse{
+		Value:    value,
+		From:     from,
+		To:       to,
+		Result:   result,
+		Quantity: h.registry.Quantity(unit),
+	})
+}
+
+// handleListUnits handles listing the units
+func (h *Handler) handleListUnits(w http.ResponseWriter, r *http.Request) {
+	quantity := r.URL.Query().Get("quantity")
+	defs := h.registry.Units(quantity)
+	if len(defs) == 0 {
+		h.WriteBadRequest(w, fmt.Sprintf("unknown quantity %q, use one of %s", quantity, strings.Join(h.registry.Quantities(), ", ")))
+		return
+	}
+	h.WriteSuccess(w, UnitsResponse{Units: defs, Prefixes: h.registry.Prefixes()})
+}
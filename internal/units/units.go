@@ -0,0 +1,470 @@
+// Package units converts values between units of measurement.
+package units
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// definitions is the data file read by Default
+//
+//go:embed units.json
+var definitions []byte
+
+// Conversion errors
+var (
+	ErrUnknownUnit  = errors.New("unknown unit")
+	ErrIncompatible = errors.New("units measure different quantities")
+)
+
+// maxExponent bounds the powers of unit expressions, which no real unit
+// comes near, so that exact factors stay small
+const maxExponent = 32
+
+// Dimensionless names the quantity of units whose dimensions cancel out,
+// such as km/m
+const Dimensionless = "dimensionless"
+
+// Dimension maps the base dimensions of a unit, such as length or time, to
+// their exponents
+type Dimension map[string]int
+
+// String lists the base dimensions in order, such as length*time^-1
+func (d Dimension) String() string {
+	if len(d) == 0 {
+		return Dimensionless
+	}
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if d[name] != 1 {
+			names[i] = name + "^" + strconv.Itoa(d[name])
+		}
+	}
+	return strings.Join(names, "*")
+}
+
+// Equal reports whether d and other have the same exponents
+func (d Dimension) Equal(other Dimension) bool {
+	if len(d) != len(other) {
+		return false
+	}
+	for name, exp := range d {
+		if other[name] != exp {
+			return false
+		}
+	}
+	return true
+}
+
+// Prefix scales a unit, such as k for 1000
+type Prefix struct {
+	Symbol  string   `json:"symbol"`
+	Name    string   `json:"name"`
+	Factor  float64  `json:"factor"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Definition describes a unit in the data file. A unit is either a base
+// unit of a dimension or defined from earlier units, such as "0.0254 m" or
+// "kg*m/s^2". Values of a unit with an offset, such as degC, are
+// (value + offset) times the definition.
+type Definition struct {
+	Symbol     string   `json:"symbol"`
+	Name       string   `json:"name"`
+	Quantity   string   `json:"quantity"`
+	Base       string   `json:"base,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+	Offset     float64  `json:"offset,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Prefixes   []string `json:"prefixes,omitempty"`
+}
+
+// Unit is a parsed unit expression. A value v of the unit measures
+// (v + Offset) * Factor in base units. Factor and Offset are exact, so that
+// conversions do not accumulate rounding errors.
+type Unit struct {
+	Symbol    string
+	Factor    *big.Rat
+	Offset    *big.Rat
+	Dimension Dimension
+}
+
+// unit is a registered unit with the prefix groups it accepts
+type unit struct {
+	Unit
+	prefixes []string
+}
+
+// prefix is a registered prefix with its group and exact factor
+type prefix struct {
+	Prefix
+	group  string
+	factor *big.Rat
+}
+
+// Registry holds unit definitions and the prefixes they accept
+type Registry struct {
+	groups      map[string][]Prefix
+	definitions []Definition
+	units       map[string]unit
+	prefixes    map[string]prefix
+	// longestPrefix bounds the prefixes tried when parsing a symbol
+	longestPrefix int
+}
+
+// file is the layout of a data file
+type file struct {
+	Prefixes map[string][]Prefix `json:"prefixes"`
+	Units    []Definition        `json:"units"`
+}
+
+// Load reads a registry from a JSON data file laid out like the embedded
+// units.json
+func Load(r io.Reader) (*Registry, error) {
+	var f file
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("units: decode: %w", err)
+	}
+
+	reg := &Registry{
+		groups:   f.Prefixes,
+		units:    make(map[string]unit),
+		prefixes: make(map[string]prefix),
+	}
+	for group, prefixes := range f.Prefixes {
+		for _, p := range prefixes {
+			if p.Factor <= 0 || math.IsInf(p.Factor, 0) {
+				return nil, fmt.Errorf("units: prefix %q: factor must be positive", p.Symbol)
+			}
+			for _, symbol := range append([]string{p.Symbol}, p.Aliases...) {
+				if symbol == "" {
+					return nil, fmt.Errorf("units: prefix group %q: empty symbol", group)
+				}
+				if _, ok := reg.prefixes[symbol]; ok {
+					return nil, fmt.Errorf("units: prefix %q defined twice", symbol)
+				}
+				reg.prefixes[symbol] = prefix{Prefix: p, group: group, factor: decimal(p.Factor)}
+				reg.longestPrefix = max(reg.longestPrefix, len(symbol))
+			}
+		}
+	}
+	for _, def := range f.Units {
+		if err := reg.define(def); err != nil {
+			return nil, fmt.Errorf("units: unit %q: %w", def.Symbol, err)
+		}
+	}
+	return reg, nil
+}
+
+// define adds a unit of the data file
+func (r *Registry) define(def Definition) error {
+	if def.Symbol == "" || def.Quantity == "" {
+		return errors.New("symbol and quantity are required")
+	}
+	for _, group := range def.Prefixes {
+		if _, ok := r.groups[group]; !ok {
+			return fmt.Errorf("unknown prefix group %q", group)
+		}
+	}
+	if def.Offset != 0 && len(def.Prefixes) > 0 {
+		return errors.New("a unit with an offset cannot take prefixes")
+	}
+
+	var u Unit
+	switch {
+	case def.Base != "" && def.Definition == "":
+		u = Unit{Factor: big.NewRat(1, 1), Dimension: Dimension{def.Base: 1}}
+	case def.Base == "" && def.Definition != "":
+		var err error
+		if u, err = r.Parse(def.Definition); err != nil {
+			return err
+		}
+		if u.Offset.Sign() != 0 {
+			return errors.New("a definition cannot use units with an offset")
+		}
+	default:
+		return errors.New("exactly one of base and definition is required")
+	}
+	u.Symbol = def.Symbol
+	u.Offset = decimal(def.Offset)
+
+	for _, symbol := range append([]string{def.Symbol}, def.Aliases...) {
+		if _, ok := r.units[symbol]; ok {
+			return fmt.Errorf("symbol %q defined twice", symbol)
+		}
+		r.units[symbol] = unit{Unit: u, prefixes: def.Prefixes}
+	}
+	r.definitions = append(r.definitions, def)
+	return nil
+}
+
+// loadDefault reads the embedded data file once
+var loadDefault = sync.OnceValues(func() (*Registry, error) {
+	return Load(bytes.NewReader(definitions))
+})
+
+// Default returns the registry of the embedded units.json. It panics if the
+// embedded file is invalid, which the package tests rule out.
+func Default() *Registry {
+	reg, err := loadDefault()
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}
+
+// term is a factor of a unit expression, divided when divide is set
+type term struct {
+	text   string
+	divide bool
+}
+
+// splitTerms splits a unit expression at the *, · and / operators. Spaces
+// multiply as well, so that definitions read like "0.0254 m".
+func splitTerms(expr string) ([]term, error) {
+	var (
+		terms    []term
+		current  strings.Builder
+		divide   bool
+		operator bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			terms = append(terms, term{text: current.String(), divide: divide})
+			current.Reset()
+			divide, operator = false, false
+		}
+	}
+	for _, c := range expr {
+		switch c {
+		case ' ', '\t':
+			flush()
+		case '*', '·', '/':
+			flush()
+			if operator || len(terms) == 0 {
+				return nil, fmt.Errorf("unit %q: %q needs a unit on both sides", expr, c)
+			}
+			divide, operator = c == '/', true
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+	if len(terms) == 0 {
+		return nil, errors.New("unit is required")
+	}
+	if operator {
+		return nil, fmt.Errorf("unit %q ends with an operator", expr)
+	}
+	return terms, nil
+}
+
+// Parse reads a unit expression: symbols, optionally prefixed and raised to
+// an integer power with ^, combined with * (or ·) and /, from left to right.
+// Numbers scale the unit, as in the definitions of the data file. A unit
+// with an offset, such as degC, must stand alone.
+func (r *Registry) Parse(expr string) (Unit, error) {
+	terms, err := splitTerms(expr)
+	if err != nil {
+		return Unit{}, err
+	}
+
+	u := Unit{Symbol: strings.TrimSpace(expr), Factor: big.NewRat(1, 1), Offset: new(big.Rat), Dimension: Dimension{}}
+	for _, t := range terms {
+		symbol, exp := t.text, 1
+		if i := strings.IndexByte(symbol, '^'); i >= 0 {
+			if exp, err = strconv.Atoi(symbol[i+1:]); err != nil || exp == 0 {
+				return Unit{}, fmt.Errorf("unit %q: exponent of %q must be a non-zero whole number", expr, symbol[:i])
+			}
+			if exp < -maxExponent || exp > maxExponent {
+				return Unit{}, fmt.Errorf("unit %q: exponent of %q must be between -%d and %d", expr, symbol[:i], maxExponent, maxExponent)
+			}
+			symbol = symbol[:i]
+		}
+		if t.divide {
+			exp = -exp
+		}
+
+		if x, err := strconv.ParseFloat(symbol, 64); err == nil && x > 0 && !math.IsInf(x, 0) {
+			u.Factor.Mul(u.Factor, pow(number(symbol, x), exp))
+			continue
+		}
+		factor, err := r.lookup(symbol)
+		if err != nil {
+			return Unit{}, err
+		}
+		if factor.Offset.Sign() != 0 {
+			if len(terms) > 1 || exp != 1 {
+				return Unit{}, fmt.Errorf("unit %q: %s has an offset and cannot be combined with other units", expr, symbol)
+			}
+			u.Offset = factor.Offset
+		}
+		u.Factor.Mul(u.Factor, pow(factor.Factor, exp))
+		for name, e := range factor.Dimension {
+			u.Dimension[name] += e * exp
+			if u.Dimension[name] == 0 {
+				delete(u.Dimension, name)
+			}
+		}
+	}
+	return u, nil
+}
+
+// lookup finds a symbol, which is either a unit or a prefix followed by a
+// unit that accepts it. Exact matches win, so min is a minute rather than a
+// milli-inch, then the longest prefix.
+func (r *Registry) lookup(symbol string) (Unit, error) {
+	if u, ok := r.units[symbol]; ok {
+		return u.Unit, nil
+	}
+	for n := min(r.longestPrefix, len(symbol)-1); n > 0; n-- {
+		p, ok := r.prefixes[symbol[:n]]
+		if !ok {
+			continue
+		}
+		u, ok := r.units[symbol[n:]]
+		if !ok || !accepts(u.prefixes, p.group) {
+			continue
+		}
+		scaled := u.Unit
+		scaled.Symbol = symbol
+		scaled.Factor = new(big.Rat).Mul(u.Factor, p.factor)
+		return scaled, nil
+	}
+	return Unit{}, fmt.Errorf("%w %q", ErrUnknownUnit, symbol)
+}
+
+// accepts reports whether group is one of groups
+func accepts(groups []string, group string) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Quantity names what u measures, such as length or speed, from the first
+// registered unit of the same dimension
+func (r *Registry) Quantity(u Unit) string {
+	if len(u.Dimension) == 0 {
+		return Dimensionless
+	}
+	for _, def := range r.definitions {
+		if r.units[def.Symbol].Dimension.Equal(u.Dimension) {
+			return def.Quantity
+		}
+	}
+	return u.Dimension.String()
+}
+
+// Convert converts value from one unit expression to another. The
+// conversion is exact, offsets included, and the result is rounded to 15
+// significant digits.
+func (r *Registry) Convert(value float64, from, to string) (float64, error) {
+	source, err := r.Parse(from)
+	if err != nil {
+		return 0, err
+	}
+	target, err := r.Parse(to)
+	if err != nil {
+		return 0, err
+	}
+	if !source.Dimension.Equal(target.Dimension) {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s): %w",
+			source.Symbol, r.Quantity(source), target.Symbol, r.Quantity(target), ErrIncompatible)
+	}
+
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("cannot convert %g %s: the value must be finite", value, source.Symbol)
+	}
+	exact := new(big.Rat).SetFloat64(value)
+	exact.Add(exact, source.Offset)
+	exact.Mul(exact, source.Factor)
+	exact.Quo(exact, target.Factor)
+	exact.Sub(exact, target.Offset)
+	result, _ := exact.Float64()
+	if math.IsInf(result, 0) {
+		return 0, fmt.Errorf("converting %g %s to %s overflows", value, source.Symbol, target.Symbol)
+	}
+	return strconv.ParseFloat(strconv.FormatFloat(result, 'g', 15, 64), 64)
+}
+
+// Units returns the definitions measuring quantity, or all of them when
+// quantity is empty, in the order of the data file
+func (r *Registry) Units(quantity string) []Definition {
+	var defs []Definition
+	for _, def := range r.definitions {
+		if quantity == "" || def.Quantity == quantity {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// Quantities returns the sorted names of the quantities of the registry
+func (r *Registry) Quantities() []string {
+	seen := make(map[string]bool)
+	var quantities []string
+	for _, def := range r.definitions {
+		if !seen[def.Quantity] {
+			seen[def.Quantity] = true
+			quantities = append(quantities, def.Quantity)
+		}
+	}
+	sort.Strings(quantities)
+	return quantities
+}
+
+// Prefixes returns the prefix groups, such as si and binary
+func (r *Registry) Prefixes() map[string][]Prefix {
+	return r.groups
+}
+
+// decimal returns the exact value of f as written in decimal in the data
+// file, such as 273.15 rather than its nearest binary fraction
+func decimal(f float64) *big.Rat {
+	return number(strconv.FormatFloat(f, 'g', -1, 64), f)
+}
+
+// number returns the exact value of the numeric literal text, or of its
+// parsed value x when big.Rat does not read its syntax
+func number(text string, x float64) *big.Rat {
+	if r, ok := new(big.Rat).SetString(text); ok {
+		return r
+	}
+	return new(big.Rat).SetFloat64(x)
+}
+
+// pow returns x^exp for a positive x
+func pow(x *big.Rat, exp int) *big.Rat {
+	base := new(big.Rat).Set(x)
+	if exp < 0 {
+		base.Inv(base)
+		exp = -exp
+	}
+	result := big.NewRat(1, 1)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package units
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLoads(t *testing.T) {
+	_, err := loadDefault()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data", "energy", "force", "length", "mass", "pressure", "speed", "temperature", "time"}, Default().Quantities())
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{10, "mi", "km", 16.09344},
+		{1, "ft", "in", 12},
+		{100, "km/h", "m/s", 27.7777777777778},
+		{1, "kn", "km/h", 1.852},
+		{60, "mph", "km/h", 96.56064},
+		{1, "lb", "kg", 0.45359237},
+		{1, "t", "g", 1e6},
+		{100, "degC", "degF", 212},
+		{10, "degC", "degF", 50},
+		{0, "degC", "degF", 32},
+		{100, "degF", "degC", 37.7777777777778},
+		{0, "°F", "°C", -17.7777777777778},
+		{-40, "°F", "celsius", -40},
+		{0, "K", "degC", -273.15},
+		{491.67, "degR", "K", 273.15},
+		{1, "KiB", "B", 1024},
+		{1, "kB", "bit", 8000},
+		{1, "GiB", "MiB", 1024},
+		{1, "atm", "kPa", 101.325},
+		{1, "bar", "psi", 14.5037737730209},
+		{1, "atm", "Torr", 760},
+		{1, "mmHg", "Pa", 133.322387415},
+		{1, "kWh", "J", 3.6e6},
+		{1, "kcal", "kJ", 4.184},
+		{1, "J", "N*m", 1},
+		{1, "N", "kg·m/s^2", 1},
+		{1, "Pa", "kg/m/s^2", 1},
+		{1, "m^2", "cm^2", 10000},
+		{1, "h", "min", 60},
+		{1, "µs", "ns", 1000},
+		{1, "us", "ns", 1000},
+		{1, "km/m", "mm/m", 1e6},
+	}
+
+	reg := Default()
+	for _, tt := range tests {
+		got, err := reg.Convert(tt.value, tt.from, tt.to)
+		if assert.NoError(t, err, "%v %s to %s", tt.value, tt.from, tt.to) {
+			assert.Equal(t, tt.want, got, "%v %s to %s", tt.value, tt.from, tt.to)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     error
+		message  string
+	}{
+		{"km", "s", ErrIncompatible, "cannot convert km (length) to s (time)"},
+		{"km/h", "kg", ErrIncompatible, "cannot convert km/h (speed) to kg (mass)"},
+		{"furlong", "m", ErrUnknownUnit, `unknown unit "furlong"`},
+		{"kmi", "m", ErrUnknownUnit, `unknown unit "kmi"`},
+		{"Kim", "m", ErrUnknownUnit, `unknown unit "Kim"`},
+		{"m", "", nil, "unit is required"},
+		{"m/", "m", nil, `unit "m/" ends with an operator`},
+		{"m//s", "m", nil, `unit "m//s": '/' needs a unit on both sides`},
+		{"m^x", "m", nil, `unit "m^x": exponent of "m" must be a non-zero whole number`},
+		{"degC/s", "K/s", nil, `unit "degC/s": degC has an offset and cannot be combined with other units`},
+		{"degC^2", "K^2", nil, "degC has an offset"},
+		{"m^33", "m^33", nil, `unit "m^33": exponent of "m" must be between -32 and 32`},
+	}
+
+	reg := Default()
+	for _, tt := range tests {
+		_, err := reg.Convert(1, tt.from, tt.to)
+		require.Error(t, err, "%s to %s", tt.from, tt.to)
+		if tt.want != nil {
+			assert.ErrorIs(t, err, tt.want)
+		}
+		assert.Contains(t, err.Error(), tt.message)
+	}
+}
+
+func TestQuantity(t *testing.T) {
+	reg := Default()
+	for expr, want := range map[string]string{
+		"km":       "length",
+		"m/s":      "speed",
+		"kJ":       "energy",
+		"lbf/in^2": "pressure",
+		"degF":     "temperature",
+		"m/km":     Dimensionless,
+		"m^3":      "length^3",
+		"kg/m^3":   "length^-3*mass",
+	} {
+		u, err := reg.Parse(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, reg.Quantity(u), expr)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown field", `{"units":[],"extra":1}`, "unknown field"},
+		{"undefined unit", `{"units":[{"symbol":"ft","quantity":"length","definition":"12 in"}]}`, `unit "ft": unknown unit "in"`},
+		{"base and definition", `{"units":[{"symbol":"m","quantity":"length","base":"length","definition":"m"}]}`, "exactly one of base and definition"},
+		{"duplicate symbol", `{"units":[{"symbol":"m","quantity":"length","base":"length"},{"symbol":"m","quantity":"length","definition":"m"}]}`, `symbol "m" defined twice`},
+		{"unknown prefix group", `{"units":[{"symbol":"m","quantity":"length","base":"length","prefixes":["si"]}]}`, `unknown prefix group "si"`},
+		{"prefixed offset", `{"prefixes":{"si":[{"symbol":"k","factor":1000}]},"units":[{"symbol":"K","quantity":"temperature","base":"temperature"},{"symbol":"degC","quantity":"temperature","definition":"K","offset":273.15,"prefixes":["si"]}]}`, "cannot take prefixes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
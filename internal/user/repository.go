@@ -0,0 +1,107 @@
+package user
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Repository stores users. Updates and deletes take the version the caller
+// last read and fail with ErrVersionMismatch when the stored user changed
+// since; 0 skips the check.
+type Repository interface {
+	Get(id int) (*User, error)
+	List() []*User
+	Create(user *User) error
+	Update(user *User, expectedVersion int) error
+	Delete(id int, expectedVersion int) error
+}
+
+// MemoryRepository implements Repository in process memory. It hands out
+// copies, so callers never share the stored users.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+}
+
+// NewMemoryRepository creates an empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:  make(map[int]User),
+		nextID: 1,
+	}
+}
+
+// Get retrieves a user by ID
+func (r *MemoryRepository) Get(id int) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, errors.New(ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+// List returns every user ordered by ID
+func (r *MemoryRepository) List() []*User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*User, 0, len(r.users))
+	for _, user := range r.users {
+		user := user
+		users = append(users, &user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// Create stores a new user, assigning its ID and first version
+func (r *MemoryRepository) Create(user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.ID = r.nextID
+	user.Version = 1
+	r.nextID++
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Update replaces a user, bumping its version
+func (r *MemoryRepository) Update(user *User, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.users[user.ID]
+	if !exists {
+		return errors.New(ErrUserNotFound)
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return errors.New(ErrVersionMismatch)
+	}
+
+	user.Version = stored.Version + 1
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Delete removes a user
+func (r *MemoryRepository) Delete(id int, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.users[id]
+	if !exists {
+		return errors.New(ErrUserNotFound)
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return errors.New(ErrVersionMismatch)
+	}
+
+	delete(r.users, id)
+	return nil
+}
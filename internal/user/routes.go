@@ -2,8 +2,10 @@ package user
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/patch"
 	"github.com/gorilla/mux"
 )
 
@@ -13,6 +15,9 @@ type CreateUserRequest struct {
 	Email string `json:"email"`
 }
 
+// idParam documents the integer user ID path parameter
+var idParam = map[string]string{"id": "integer"}
+
 // Handler handles user HTTP requests
 type Handler struct {
 	common.BaseHandler
@@ -28,43 +33,75 @@ func NewHandler(service *Service) *Handler {
 
 // RegisterRoutes registers user routes
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	routes := common.RouteGroup{
+	for _, group := range h.Routes() {
+		common.RegisterGroup(router, group)
+	}
+}
+
+// Routes returns the user route groups
+func (h *Handler) Routes() []common.RouteGroup {
+	return []common.RouteGroup{{
 		Prefix: "/users",
 		Routes: []common.Route{
-			common.NamedRoute("", "GET", "users.getAll", h.handleGetAllUsers),
-			common.NamedRoute("", "POST", "users.create", h.handleCreateUser),
-			common.NamedRoute("/{id}", "GET", "users.get", h.handleGetUser),
-			common.NamedRoute("/{id}", "PUT", "users.update", h.handleUpdateUser),
-			common.NamedRoute("/{id}", "DELETE", "users.delete", h.handleDeleteUser),
+			common.NamedRoute("", "GET", "users.getAll", h.handleGetAllUsers).WithDoc(common.RouteDoc{
+				Summary:  "Get all users",
+				Tags:     []string{"users"},
+				Response: []User{},
+			}),
+			common.NamedRoute("", "POST", "users.create", h.handleCreateUser).WithDoc(common.RouteDoc{
+				Summary:  "Create a new user",
+				Tags:     []string{"users"},
+				Request:  CreateUserRequest{},
+				Response: User{},
+				Status:   http.StatusCreated,
+				Errors:   []int{http.StatusBadRequest},
+			}),
+			common.NamedRoute("/{id}", "GET", "users.get", h.handleGetUser).WithDoc(common.RouteDoc{
+				Summary:     "Get user by ID",
+				Description: "The ETag header holds the user version; a matching If-None-Match answers 304",
+				Tags:        []string{"users"},
+				Params:      idParam,
+				Response:    User{},
+				Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			}),
+			common.NamedRoute("/{id}", "PUT", "users.update", h.handleUpdateUser).WithDoc(common.RouteDoc{
+				Summary:     "Update user by ID",
+				Description: "With If-Match, the update only applies to that version",
+				Tags:        []string{"users"},
+				Params:      idParam,
+				Request:     CreateUserRequest{},
+				Response:    User{},
+				Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed},
+			}),
+			common.NamedRoute("/{id}", "PATCH", "users.patch", h.handlePatchUser).WithDoc(common.RouteDoc{
+				Summary:     "Partially update user by ID",
+				Description: "Applies a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) to the user, validating the patched result. With If-Match, the patch only applies to that version.",
+				Tags:        []string{"users"},
+				Params:      idParam,
+				Request:     map[string]interface{}{},
+				Response:    User{},
+				Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+					http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+			}),
+			common.NamedRoute("/{id}", "DELETE", "users.delete", h.handleDeleteUser).WithDoc(common.RouteDoc{
+				Summary:     "Delete user by ID",
+				Description: "With If-Match, only that version is deleted",
+				Tags:        []string{"users"},
+				Params:      idParam,
+				Status:      http.StatusNoContent,
+				Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed},
+			}),
 		},
-	}
-	common.RegisterGroup(router, routes)
+	}}
 }
 
 // handleGetAllUsers handles GET requests for all users
-// @Summary Get all users
-// @Description Retrieves a list of all users in the system
-// @Tags users
-// @Accept json
-// @Produce json
-// @Success 200 {array} User
-// @Router /users [get]
 func (h *Handler) handleGetAllUsers(w http.ResponseWriter, r *http.Request) {
 	users := h.service.GetAllUsers()
 	h.WriteSuccess(w, users)
 }
 
 // handleGetUser handles GET requests for a specific user
-// @Summary Get user by ID
-// @Description Retrieves a specific user by their ID
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path int true "User ID"
-// @Success 200 {object} User
-// @Failure 400 {object} common.ErrorResponse
-// @Failure 404 {object} common.ErrorResponse
-// @Router /users/{id} [get]
 func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getIDFromURL(r)
 	if err != nil {
@@ -74,27 +111,17 @@ func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.GetUser(id)
 	if err != nil {
-		if err.Error() == ErrUserNotFound {
-			h.WriteNotFound(w, err.Error())
-		} else {
-			h.WriteBadRequest(w, err.Error())
-		}
+		h.writeServiceError(w, err)
 		return
 	}
 
+	if common.NotModified(w, r, user.Version) {
+		return
+	}
 	h.WriteSuccess(w, user)
 }
 
 // handleCreateUser handles POST requests to create a new user
-// @Summary Create a new user
-// @Description Creates a new user with the provided name and email
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param user body CreateUserRequest true "User creation request"
-// @Success 201 {object} User
-// @Failure 400 {object} common.ErrorResponse
-// @Router /users [post]
 func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	if err := h.ParseJSON(r, &req); err != nil {
@@ -108,21 +135,15 @@ func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if location, err := common.RequestURL(r, "users.get", "id", strconv.Itoa(user.ID)); err == nil {
+		w.Header().Set("Location", location)
+	}
+
+	common.SetETag(w, user.Version)
 	h.WriteCreated(w, user)
 }
 
 // handleUpdateUser handles PUT requests to update an existing user
-// @Summary Update user by ID
-// @Description Updates an existing user with the provided data
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path int true "User ID"
-// @Param user body CreateUserRequest true "User update request"
-// @Success 200 {object} User
-// @Failure 400 {object} common.ErrorResponse
-// @Failure 404 {object} common.ErrorResponse
-// @Router /users/{id} [put]
 func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getIDFromURL(r)
 	if err != nil {
@@ -130,36 +151,86 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := common.IfMatch(r)
+	if err != nil {
+		h.WritePreconditionFailed(w, ErrVersionMismatch)
+		return
+	}
+
 	var req CreateUserRequest
 	if err := h.ParseJSON(r, &req); err != nil {
 		h.WriteBadRequest(w, "invalid JSON payload")
 		return
 	}
 
-	user, err := h.service.UpdateUser(id, req.Name, req.Email)
+	user, err := h.service.UpdateUser(id, req.Name, req.Email, version)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	common.SetETag(w, user.Version)
+	h.WriteSuccess(w, user)
+}
+
+// handlePatchUser handles PATCH requests to partially update a user
+func (h *Handler) handlePatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getIDFromURL(r)
+	if err != nil {
+		h.WriteBadRequest(w, "invalid user ID")
+		return
+	}
+
+	version, err := common.IfMatch(r)
+	if err != nil {
+		h.WritePreconditionFailed(w, ErrVersionMismatch)
+		return
+	}
+
+	current, err := h.service.GetUser(id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if version != common.AnyVersion && version != current.Version {
+		h.WritePreconditionFailed(w, ErrVersionMismatch)
+		return
+	}
+
+	var patched User
+	if err := patch.Request(w, r, current, &patched); err != nil {
+		patch.WriteError(w, err)
+		return
+	}
+	if patched.ID != current.ID || patched.Version != current.Version {
+		h.WriteError(w, http.StatusUnprocessableEntity, "id and version are read-only")
+		return
+	}
+
+	// The patch was computed from the version just read, so the update must
+	// not apply on top of a newer one
+	user, err := h.service.UpdateUser(id, patched.Name, patched.Email, current.Version)
 	if err != nil {
-		if err.Error() == ErrUserNotFound {
+		switch err.Error() {
+		case ErrUserNotFound:
 			h.WriteNotFound(w, err.Error())
-		} else {
-			h.WriteBadRequest(w, err.Error())
+		case ErrVersionMismatch:
+			if version != common.AnyVersion {
+				h.WritePreconditionFailed(w, err.Error())
+			} else {
+				h.WriteError(w, http.StatusConflict, "user was modified concurrently, retry the request")
+			}
+		default:
+			h.WriteError(w, http.StatusUnprocessableEntity, err.Error())
 		}
 		return
 	}
 
+	common.SetETag(w, user.Version)
 	h.WriteSuccess(w, user)
 }
 
 // handleDeleteUser handles DELETE requests to delete a user
-// @Summary Delete user by ID
-// @Description Deletes a user from the system
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path int true "User ID"
-// @Success 204 "No Content"
-// @Failure 400 {object} common.ErrorResponse
-// @Failure 404 {object} common.ErrorResponse
-// @Router /users/{id} [delete]
 func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getIDFromURL(r)
 	if err != nil {
@@ -167,19 +238,32 @@ func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteUser(id)
+	version, err := common.IfMatch(r)
 	if err != nil {
-		if err.Error() == ErrUserNotFound {
-			h.WriteNotFound(w, err.Error())
-		} else {
-			h.WriteBadRequest(w, err.Error())
-		}
+		h.WritePreconditionFailed(w, ErrVersionMismatch)
+		return
+	}
+
+	if err := h.service.DeleteUser(id, version); err != nil {
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writeServiceError maps a service error to its status code
+func (h *Handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case ErrUserNotFound:
+		h.WriteNotFound(w, err.Error())
+	case ErrVersionMismatch:
+		h.WritePreconditionFailed(w, err.Error())
+	default:
+		h.WriteBadRequest(w, err.Error())
+	}
+}
+
 // getIDFromURL extracts the ID parameter from the URL
 func (h *Handler) getIDFromURL(r *http.Request) (int, error) {
 	params := h.GetURLParams(r)
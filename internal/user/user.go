@@ -6,8 +6,9 @@ import (
 )
 
 const (
-	ErrInvalidUserID = "invalid user ID"
-	ErrUserNotFound  = "user not found"
+	ErrInvalidUserID   = "invalid user ID"
+	ErrUserNotFound    = "user not found"
+	ErrVersionMismatch = "user was modified since it was read"
 )
 
 // User represents a user in the system
@@ -15,20 +16,23 @@ type User struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	// Version increases with every update and is served as the ETag
+	Version int `json:"version"`
 }
 
 // Service provides user operations
 type Service struct {
-	users  map[int]*User
-	nextID int
+	repo Repository
 }
 
-// NewService creates a new user service
+// NewService creates a new user service backed by an in-memory repository
 func NewService() *Service {
-	return &Service{
-		users:  make(map[int]*User),
-		nextID: 1,
-	}
+	return NewServiceWithRepository(NewMemoryRepository())
+}
+
+// NewServiceWithRepository creates a new user service backed by repo
+func NewServiceWithRepository(repo Repository) *Service {
+	return &Service{repo: repo}
 }
 
 // GetUser retrieves a user by ID
@@ -37,12 +41,7 @@ func (s *Service) GetUser(id int) (*User, error) {
 		return nil, errors.New(ErrInvalidUserID)
 	}
 
-	user, exists := s.users[id]
-	if !exists {
-		return nil, errors.New(ErrUserNotFound)
-	}
-
-	return user, nil
+	return s.repo.Get(id)
 }
 
 // CreateUser creates a new user
@@ -56,28 +55,23 @@ func (s *Service) CreateUser(name, email string) (*User, error) {
 	}
 
 	user := &User{
-		ID:    s.nextID,
 		Name:  name,
 		Email: email,
 	}
-
-	s.users[s.nextID] = user
-	s.nextID++
+	if err := s.repo.Create(user); err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
 
-// UpdateUser updates an existing user
-func (s *Service) UpdateUser(id int, name, email string) (*User, error) {
+// UpdateUser updates an existing user. A non-zero version must match the
+// stored one.
+func (s *Service) UpdateUser(id int, name, email string, version int) (*User, error) {
 	if id <= 0 {
 		return nil, errors.New(ErrInvalidUserID)
 	}
 
-	user, exists := s.users[id]
-	if !exists {
-		return nil, errors.New(ErrUserNotFound)
-	}
-
 	if name == "" {
 		return nil, errors.New("name is required")
 	}
@@ -86,31 +80,29 @@ func (s *Service) UpdateUser(id int, name, email string) (*User, error) {
 		return nil, errors.New("email is required")
 	}
 
-	user.Name = name
-	user.Email = email
+	user := &User{
+		ID:    id,
+		Name:  name,
+		Email: email,
+	}
+	if err := s.repo.Update(user, version); err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
 
-// DeleteUser deletes a user by ID
-func (s *Service) DeleteUser(id int) error {
+// DeleteUser deletes a user by ID. A non-zero version must match the stored
+// one.
+func (s *Service) DeleteUser(id int, version int) error {
 	if id <= 0 {
 		return errors.New(ErrInvalidUserID)
 	}
 
-	if _, exists := s.users[id]; !exists {
-		return errors.New(ErrUserNotFound)
-	}
-
-	delete(s.users, id)
-	return nil
+	return s.repo.Delete(id, version)
 }
 
 // GetAllUsers returns all users
 func (s *Service) GetAllUsers() []*User {
-	users := make([]*User, 0, len(s.users))
-	for _, user := range s.users {
-		users = append(users, user)
-	}
-	return users
+	return s.repo.List()
 }
@@ -63,7 +63,7 @@ func TestService_UpdateUser(t *testing.T) {
 	}
 
 	// Update the user
-	updatedUser, err := service.UpdateUser(user.ID, "John Smith", "johnsmith@example.com")
+	updatedUser, err := service.UpdateUser(user.ID, "John Smith", "johnsmith@example.com", 0)
 	if err != nil {
 		t.Fatalf("Failed to update user: %v", err)
 	}
@@ -87,7 +87,7 @@ func TestService_DeleteUser(t *testing.T) {
 	}
 
 	// Delete the user
-	err = service.DeleteUser(user.ID)
+	err = service.DeleteUser(user.ID, 0)
 	if err != nil {
 		t.Fatalf("Failed to delete user: %v", err)
 	}
@@ -98,3 +98,41 @@ func TestService_DeleteUser(t *testing.T) {
 		t.Error("Expected error when getting deleted user")
 	}
 }
+
+func TestService_VersionChecks(t *testing.T) {
+	service := NewService()
+
+	user, err := service.CreateUser("John Doe", "john@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if user.Version != 1 {
+		t.Fatalf("Expected version 1, got %d", user.Version)
+	}
+
+	updated, err := service.UpdateUser(user.ID, "John Smith", "john@example.com", 1)
+	if err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2, got %d", updated.Version)
+	}
+
+	// A writer still holding version 1 must not clobber the update
+	_, err = service.UpdateUser(user.ID, "Johnny", "john@example.com", 1)
+	if err == nil || err.Error() != ErrVersionMismatch {
+		t.Errorf("Expected %q, got %v", ErrVersionMismatch, err)
+	}
+	if err := service.DeleteUser(user.ID, 1); err == nil || err.Error() != ErrVersionMismatch {
+		t.Errorf("Expected %q, got %v", ErrVersionMismatch, err)
+	}
+
+	current, _ := service.GetUser(user.ID)
+	if current.Name != "John Smith" {
+		t.Errorf("Expected name 'John Smith', got '%s'", current.Name)
+	}
+
+	if err := service.DeleteUser(user.ID, 2); err != nil {
+		t.Errorf("Failed to delete user: %v", err)
+	}
+}
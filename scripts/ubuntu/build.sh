@@ -5,4 +5,4 @@ PROJECT_ROOT="$(dirname "$(dirname "$SCRIPT_DIR")")"
 cd "$PROJECT_ROOT"
 echo "Building Go binary..."
 mkdir -p bin
-go build -o bin/app .
+go build -o bin/app ./cmd/api
@@ -9,4 +9,4 @@ PROJECT_ROOT="$(dirname "$(dirname "$SCRIPT_DIR")")"
 cd "$PROJECT_ROOT"
 
 echo "Running application..."
-go run main.go
+go run ./cmd/api
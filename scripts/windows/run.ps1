@@ -6,4 +6,4 @@ $ProjectRoot = Split-Path -Parent (Split-Path -Parent $ScriptDir)
 Set-Location $ProjectRoot
 
 Write-Output "Running application..."
-go run main.go
+go run ./cmd/api
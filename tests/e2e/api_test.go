@@ -1,23 +1,23 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
-	"github.com/example/go-template/internal/api"
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
 	"github.com/example/go-template/internal/di"
 	"github.com/example/go-template/internal/domain"
-	"github.com/labstack/echo/v4"
+	"github.com/example/go-template/internal/server"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestServer() *echo.Echo {
-	e := echo.New()
-	providers := di.NewProviders()
-	api.RegisterRoutes(e, providers)
-	return e
+func setupTestServer() http.Handler {
+	return server.New(di.NewProviders(config.Default())).Router()
 }
 
 func TestPublicEndpoint(t *testing.T) {
@@ -66,10 +66,10 @@ func TestPrivateEndpointWithoutAuth(t *testing.T) {
 
 func TestPrivateEndpointWithAuth(t *testing.T) {
 	e := setupTestServer()
-	providers := di.NewProviders()
+	providers := di.NewProviders(config.Default())
 
 	// Get token
-	token, _ := providers.AuthService.IssueToken("test-user")
+	token, _ := providers.AuthService.IssueToken(context.Background(), "test-user")
 
 	// Call private endpoint with token
 	req := httptest.NewRequest(http.MethodGet, "/v1/private", nil)
@@ -100,3 +100,124 @@ func TestCustomerEndpoint(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, response)
 }
+
+func TestRoutesEndpoint(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/_routes", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data []common.RouteInfo `json:"data"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	byName := make(map[string]common.RouteInfo)
+	for _, route := range response.Data {
+		byName[route.Name] = route
+	}
+	assert.Equal(t, "/v1/auth/login", byName["auth.login"].Path)
+	assert.Equal(t, common.AuthNone, byName["auth.login"].Auth)
+	assert.Equal(t, common.AuthJWT, byName["private.get"].Auth)
+	assert.Contains(t, byName["private.get"].Middleware, "jwt")
+	assert.Equal(t, "/users/{id}", byName["users.get"].Path)
+	assert.Contains(t, byName["calculator.add"].Middleware, "logger")
+}
+
+func TestUnknownRouteReturnsJSONError(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/does-not-exist", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "req-123", rec.Header().Get("X-Request-ID"))
+	assert.JSONEq(t, `{"error":"route not found","request_id":"req-123"}`, rec.Body.String())
+}
+
+func TestRequestIDIsGeneratedAndEchoedInErrors(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/private", nil)
+	req.Header.Set("X-Request-ID", "not a valid id")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	requestID := rec.Header().Get("X-Request-ID")
+	assert.Len(t, requestID, 32)
+
+	var response common.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, requestID, response.RequestID)
+}
+
+func TestCreateUserSetsLocation(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/users/1", rec.Header().Get("Location"))
+}
+
+func TestCreateCustomer(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/customer", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var customer domain.CustomerResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &customer))
+	assert.Equal(t, "3", customer.ID)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/customer", strings.NewReader(`{"name":"Ada"}`))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	e := setupTestServer()
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+		httptest.NewRequest(http.MethodGet, "/v1/auth/login", nil),
+		httptest.NewRequest(http.MethodGet, "/v1/private", nil),
+		httptest.NewRequest(http.MethodPost, "/v1/customer", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`)),
+		httptest.NewRequest(http.MethodGet, "/does-not-exist", nil),
+	} {
+		if r.URL.Path == "/v1/private" {
+			r.Header.Set("Authorization", "Bearer invalid")
+		}
+		e.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+
+	// Routes are labelled by name, not by raw path
+	assert.Contains(t, body, `http_requests_total{code="404",route="users.get"} 2`)
+	assert.Contains(t, body, `http_requests_total{code="404",route="route.not_found"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{route="users.get"} 2`)
+	assert.Contains(t, body, `http_requests_in_flight{route="system.metrics"} 1`)
+	assert.NotContains(t, body, "/users/1")
+
+	assert.Contains(t, body, "auth_tokens_issued_total 1")
+	assert.Contains(t, body, "auth_token_rejections_total 1")
+	assert.Contains(t, body, "customers_created_total 1")
+	assert.Contains(t, body, "go_goroutines")
+}
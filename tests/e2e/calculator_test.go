@@ -0,0 +1,504 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculator/evaluate", strings.NewReader(`{"expression":"(2 + 3) * sqrt(16) / 4 ^ 2"}`))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			Result float64 `json:"result"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1.25, resp.Data.Result)
+}
+
+func TestEvaluateExpressionReportsErrorPosition(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculator/evaluate", strings.NewReader(`{"expression":"1 + (2 * 3"}`))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp struct {
+		Error     string `json:"error"`
+		Position  int    `json:"position"`
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 11, resp.Position)
+	assert.Contains(t, resp.Error, `")"`)
+	assert.NotEmpty(t, resp.RequestID)
+}
+
+func TestEvaluateExpressionRejectsOversizedBodies(t *testing.T) {
+	e := setupTestServer()
+
+	body := `{"expression":"1` + strings.Repeat(" + 1", 1<<20) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/calculator/evaluate", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "expression too long")
+}
+
+func TestDecimalPrecision(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"float by default", http.MethodGet, "/add/0.1/0.2", "", `{"data":{"result":0.30000000000000004}}`},
+		{"exact sum", http.MethodGet, "/add/0.1/0.2?precision=decimal", "", `{"data":{"result":"0.3","scale":28,"rounding":"half_even"}}`},
+		{"scale and rounding", http.MethodGet, "/divide/1/3?precision=decimal&scale=5&rounding=up", "", `{"data":{"result":"0.33334","scale":5,"rounding":"up"}}`},
+		{"large operands", http.MethodGet, "/multiply/12345678901234567890/98765432109876543210?precision=decimal", "", `{"data":{"result":"1219326311370217952237463801111263526900","scale":28,"rounding":"half_even"}}`},
+		{"expression", http.MethodPost, "/calculator/evaluate?precision=decimal&scale=10", `{"expression":"sqrt(2) * 0.1"}`, `{"data":{"result":"0.1414213562","scale":10,"rounding":"half_even"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestDecimalPrecisionRejectsInvalidParameters(t *testing.T) {
+	e := setupTestServer()
+
+	for _, path := range []string{
+		"/add/1/2?precision=double",
+		"/add/1/2?precision=decimal&scale=-1",
+		"/add/1/2?precision=decimal&rounding=nearest",
+		"/add/0x10/2?precision=decimal",
+		"/divide/1/0?precision=decimal",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+}
+
+func TestMathFunctions(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/pow/2/10", `{"data":{"result":1024}}`},
+		{"/mod/-7/3", `{"data":{"result":-1}}`},
+		{"/nthroot/27/3", `{"data":{"result":3}}`},
+		{"/sqrt/16", `{"data":{"result":4}}`},
+		{"/abs/-2.5", `{"data":{"result":2.5}}`},
+		{"/log/1000", `{"data":{"result":3}}`},
+		{"/ln/1", `{"data":{"result":0}}`},
+		{"/exp/0", `{"data":{"result":1}}`},
+		{"/factorial/5", `{"data":{"result":120}}`},
+		{"/sin/0", `{"data":{"result":0}}`},
+		{"/cos/0", `{"data":{"result":1}}`},
+		{"/tan/0", `{"data":{"result":0}}`},
+		{"/asin/0", `{"data":{"result":0}}`},
+		{"/acos/1", `{"data":{"result":0}}`},
+		{"/atan/0", `{"data":{"result":0}}`},
+		{"/factorial/25?precision=decimal", `{"data":{"result":"15511210043330985984000000","scale":28,"rounding":"half_even"}}`},
+		{"/sqrt/2?precision=decimal&scale=10", `{"data":{"result":"1.4142135624","scale":10,"rounding":"half_even"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestMathFunctionErrorsAreNeverEncodedAsNaN(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/sqrt/-1", "sqrt of a negative number"},
+		{"/log/0", "log of a number that is not positive"},
+		{"/factorial/171", "factorial of 171 overflows"},
+		{"/factorial/1e9", "factorial of 1e+09 overflows"},
+		{"/factorial/2.5", "not a whole number"},
+		{"/pow/-8/0.5", "pow is undefined for these operands"},
+		{"/exp/1000", "result of exp overflows"},
+		{"/multiply/1e308/10", "result of multiply overflows"},
+		{"/add/NaN/1", "a must be a finite number"},
+		{"/sqrt/Inf", "x must be a finite number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+			var resp struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Contains(t, resp.Error, tt.want)
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	e := setupTestServer()
+
+	var upload bytes.Buffer
+	form := multipart.NewWriter(&upload)
+	file, err := form.CreateFormFile("file", "series.csv")
+	require.NoError(t, err)
+	_, _ = file.Write([]byte("price\n2\n4\n4\n4\n5\n5\n7\n9\n"))
+	require.NoError(t, form.Close())
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json", "application/json", "[2, 4, 4, 4, 5, 5, 7, 9]"},
+		{"csv", "text/csv", "2,4,4,4\n5,5,7,9\n"},
+		{"ndjson", "application/x-ndjson", "2\n4\n4\n4\n5\n5\n7\n9\n"},
+		{"upload", form.FormDataContentType(), upload.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/calculator/stats?percentiles=25,75&bins=2", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, `{"data":{
+				"count":8,"sum":40,"mean":5,"median":4.5,"mode":[4],
+				"variance":4,"stddev":2,"sample_variance":4.571428571428571,"sample_stddev":2.138089935299395,
+				"min":2,"max":9,"percentiles":{"p25":4,"p75":5.5},
+				"histogram":[{"lower":2,"upper":5.5,"count":6},{"lower":5.5,"upper":9,"count":2}],
+				"approximate":false}}`, rec.Body.String())
+		})
+	}
+}
+
+func TestStatsRejectsInvalidInput(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"empty series", "/calculator/stats", "application/json", "[]", http.StatusBadRequest},
+		{"not a number", "/calculator/stats", "application/json", `[1, "x"]`, http.StatusBadRequest},
+		{"bad csv", "/calculator/stats", "text/csv", "1\nx\n", http.StatusBadRequest},
+		{"bad percentile", "/calculator/stats?percentiles=150", "application/json", "[1]", http.StatusBadRequest},
+		{"bad bins", "/calculator/stats?bins=0", "application/json", "[1]", http.StatusBadRequest},
+		{"unsupported format", "/calculator/stats", "application/xml", "<values/>", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"float", "/calculator/batch", `{"data":[{"result":3},{"error":"cannot divide by zero"},{"result":4},{"error":"unknown operation \"cube\""}]}`},
+		{"decimal", "/calculator/batch?precision=decimal&scale=2", `{"data":[{"result":"3"},{"error":"cannot divide by zero"},{"result":"4"},{"error":"unknown operation \"cube\""}]}`},
+	}
+
+	body := `[
+		{"op":"add","operands":[1,2]},
+		{"op":"divide","operands":[1,0]},
+		{"op":"sqrt","operands":[16]},
+		{"op":"cube","operands":[2]}
+	]`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestBatchRejectsOversizedBatches(t *testing.T) {
+	e := setupTestServer()
+
+	body := "[" + strings.Repeat(`{"op":"add","operands":[1,2]},`, 1000) + `{"op":"add","operands":[1,2]}]`
+	req := httptest.NewRequest(http.MethodPost, "/calculator/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+
+	body = `[{"op":"add","operands":[1,` + strings.Repeat(" ", 1<<22) + `2]}]`
+	req = httptest.NewRequest(http.MethodPost, "/calculator/batch", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/calculator/batch", strings.NewReader(`{"op":"add"}`))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
+
+func TestCalculatorIgnoresInvalidTokens(t *testing.T) {
+	e := setupTestServer()
+
+	for _, header := range []string{"Bearer invalid", "Basic dXNlcjpwYXNz"} {
+		req := httptest.NewRequest(http.MethodGet, "/add/1/2", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, header)
+		assert.JSONEq(t, `{"data":{"result":3}}`, rec.Body.String(), header)
+	}
+
+	// The history still requires a valid token
+	req := httptest.NewRequest(http.MethodGet, "/calculator/history", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestCalculationHistory(t *testing.T) {
+	e := setupTestServer()
+	token, err := di.NewProviders(config.Default()).AuthService.IssueToken(context.Background(), "history-user")
+	require.NoError(t, err)
+
+	call := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodPost, "/calculator/evaluate", `{"expression":"x = 2 + 3"}`, token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = call(http.MethodGet, "/multiply/4/0.5", "", token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = call(http.MethodPost, "/calculator/evaluate", `{"expression":"ans * x"}`, token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"data":{"result":10}}`, rec.Body.String())
+
+	// Anonymous calculations are neither recorded nor see the variables
+	rec = call(http.MethodPost, "/calculator/evaluate", `{"expression":"ans * x"}`, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	rec = call(http.MethodGet, "/add/1/1", "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = call(http.MethodGet, "/calculator/history?limit=2", "", token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Data struct {
+			Entries []struct {
+				Operation string          `json:"operation"`
+				Inputs    []string        `json:"inputs"`
+				Result    json.RawMessage `json:"result"`
+				Variable  string          `json:"variable"`
+				CreatedAt string          `json:"created_at"`
+			} `json:"entries"`
+			Total int `json:"total"`
+			Limit int `json:"limit"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Data.Total)
+	assert.Equal(t, 2, resp.Data.Limit)
+	require.Len(t, resp.Data.Entries, 2)
+	assert.Equal(t, []string{"ans * x"}, resp.Data.Entries[0].Inputs)
+	assert.Equal(t, "10", string(resp.Data.Entries[0].Result))
+	assert.Equal(t, "multiply", resp.Data.Entries[1].Operation)
+	assert.Equal(t, []string{"4", "0.5"}, resp.Data.Entries[1].Inputs)
+	assert.Equal(t, "2", string(resp.Data.Entries[1].Result))
+	assert.NotEmpty(t, resp.Data.Entries[1].CreatedAt)
+
+	rec = call(http.MethodGet, "/calculator/history?offset=2", "", token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"variable":"x"`)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/calculator/history?limit=500", "", token).Code)
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/calculator/history", "", "").Code)
+
+	rec = call(http.MethodDelete, "/calculator/history", "", token)
+	assert.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	rec = call(http.MethodGet, "/calculator/history", "", token)
+	assert.JSONEq(t, `{"data":{"entries":[],"total":0,"offset":0,"limit":20}}`, rec.Body.String())
+	rec = call(http.MethodPost, "/calculator/evaluate", `{"expression":"x"}`, token)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
+
+func TestMatrix(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		path string
+		body string
+		want string
+	}{
+		{"/calculator/matrix/add", `{"a":[[1,2],[3,4]],"b":[[10,20],[30,40]]}`, `{"data":{"result":[[11,22],[33,44]]}}`},
+		{"/calculator/matrix/multiply", `{"a":[[1,2,3]],"b":[[1],[2],[3]]}`, `{"data":{"result":[[14]]}}`},
+		{"/calculator/matrix/transpose", `{"a":[[1,2,3]]}`, `{"data":{"result":[[1],[2],[3]]}}`},
+		{"/calculator/matrix/determinant", `{"a":[[4,7],[2,6]]}`, `{"data":{"result":10}}`},
+		{"/calculator/matrix/inverse", `{"a":[[2,0],[0,4]]}`, `{"data":{"result":[[0.5,0],[0,0.25]]}}`},
+		{"/calculator/matrix/solve", `{"a":[[2,0],[0,4]],"b":[2,8]}`, `{"data":{"result":[1,2]}}`},
+		{"/calculator/matrix/dot", `{"u":[1,2,3],"v":[4,5,6]}`, `{"data":{"result":32}}`},
+		{"/calculator/matrix/cross", `{"u":[1,0,0],"v":[0,1,0]}`, `{"data":{"result":[0,0,1]}}`},
+		{"/calculator/matrix/norm", `{"u":[3,4]}`, `{"data":{"result":5}}`},
+		{"/calculator/matrix/norm?ord=inf", `{"a":[[1,-2],[-3,4]]}`, `{"data":{"result":7}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestMatrixRejectsInvalidInput(t *testing.T) {
+	e := setupTestServer()
+
+	large := "[" + strings.TrimSuffix(strings.Repeat("1,", 101), ",") + "]"
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"dimension mismatch", "/calculator/matrix/add", `{"a":[[1,2]],"b":[[1],[2]]}`, http.StatusBadRequest, "cannot add a 1x2 matrix and a 2x1 matrix"},
+		{"singular", "/calculator/matrix/inverse", `{"a":[[1,2],[2,4]]}`, http.StatusBadRequest, "matrix is singular"},
+		{"not square", "/calculator/matrix/determinant", `{"a":[[1,2]]}`, http.StatusBadRequest, "a square matrix is required"},
+		{"ragged", "/calculator/matrix/transpose", `{"a":[[1,2],[3]]}`, http.StatusBadRequest, "2 columns in row 1 but 1 in row 2"},
+		{"missing operand", "/calculator/matrix/dot", `{"u":[1]}`, http.StatusBadRequest, "v must have at least one element"},
+		{"unknown norm", "/calculator/matrix/norm?ord=fro", `{"u":[1]}`, http.StatusBadRequest, "unknown vector norm"},
+		{"invalid JSON", "/calculator/matrix/add", `{"a":[["x"]]}`, http.StatusBadRequest, "invalid JSON payload"},
+		{"too large", "/calculator/matrix/dot", `{"u":` + large + `,"v":` + large + `}`, http.StatusRequestEntityTooLarge, "at most 100 are accepted"},
+		{"body too large", "/calculator/matrix/dot", `{"u":[` + strings.Repeat(" ", 1<<21) + `1]}`, http.StatusRequestEntityTooLarge, "matrix too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestComplexNumbers(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/calculator/complex/add/3+4i/1-2i", `{"data":{"result":{"re":4,"im":2}}}`},
+		{"/calculator/complex/multiply/3+4i/1-2i?format=string", `{"data":{"result":"11-2i"}}`},
+		{"/calculator/complex/divide/3+4i/1-2i?format=object", `{"data":{"result":{"re":-1,"im":2}}}`},
+		{"/calculator/complex/conjugate/3?format=string", `{"data":{"result":"3+0i"}}`},
+		{"/calculator/complex/modulus/3+4i", `{"data":{"result":5}}`},
+		{"/calculator/complex/argument/-1", `{"data":{"result":3.141592653589793}}`},
+		{"/calculator/complex/polar/0+2i", `{"data":{"r":2,"theta":1.5707963267948966}}`},
+		{"/calculator/complex/rect/2/0", `{"data":{"result":{"re":2,"im":0}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestComplexNumbersRejectInvalidInput(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		path  string
+		error string
+	}{
+		{"/calculator/complex/add/3+4j/1", "invalid complex number"},
+		{"/calculator/complex/divide/1+1i/0", "division by zero"},
+		{"/calculator/complex/add/1/2?format=polar", "unknown format"},
+		{"/calculator/complex/rect/-1/0", "negative modulus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.error)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func conditional(e http.Handler, method, path, header, etag, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if header != "" {
+		req.Header.Set(header, etag)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConditionalRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   string
+		resource string
+	}{
+		{"users", "/users", "/users/1"},
+		{"customers", "/v1/customer", "/v1/customer/3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := setupTestServer()
+
+			created := conditional(e, http.MethodPost, tt.create, "", "", `{"name":"Ada","email":"ada@example.com"}`)
+			require.Equal(t, http.StatusCreated, created.Code)
+			assert.Equal(t, `"1"`, created.Header().Get(common.HeaderETag))
+
+			get := conditional(e, http.MethodGet, tt.resource, "", "", "")
+			require.Equal(t, http.StatusOK, get.Code)
+			etag := get.Header().Get(common.HeaderETag)
+			assert.Equal(t, `"1"`, etag)
+			assert.Contains(t, get.Body.String(), `"version":1`)
+
+			cached := conditional(e, http.MethodGet, tt.resource, common.HeaderIfNoneMatch, etag, "")
+			assert.Equal(t, http.StatusNotModified, cached.Code)
+			assert.Empty(t, cached.Body.String())
+			assert.Equal(t, etag, cached.Header().Get(common.HeaderETag))
+
+			// The first writer wins; the second one still holds version 1
+			first := conditional(e, http.MethodPut, tt.resource, common.HeaderIfMatch, etag, `{"name":"Ada L.","email":"ada@example.com"}`)
+			require.Equal(t, http.StatusOK, first.Code)
+			assert.Equal(t, `"2"`, first.Header().Get(common.HeaderETag))
+
+			second := conditional(e, http.MethodPut, tt.resource, common.HeaderIfMatch, etag, `{"name":"Ada B.","email":"ada@example.com"}`)
+			assert.Equal(t, http.StatusPreconditionFailed, second.Code)
+
+			assert.Equal(t, http.StatusOK, conditional(e, http.MethodGet, tt.resource, common.HeaderIfNoneMatch, etag, "").Code)
+			assert.Contains(t, conditional(e, http.MethodGet, tt.resource, "", "", "").Body.String(), "Ada L.")
+
+			assert.Equal(t, http.StatusPreconditionFailed, conditional(e, http.MethodDelete, tt.resource, common.HeaderIfMatch, etag, "").Code)
+			assert.Equal(t, http.StatusPreconditionFailed, conditional(e, http.MethodDelete, tt.resource, common.HeaderIfMatch, `W/"2"`, "").Code)
+			assert.Equal(t, http.StatusNoContent, conditional(e, http.MethodDelete, tt.resource, common.HeaderIfMatch, `"2"`, "").Code)
+			assert.Equal(t, http.StatusNotFound, conditional(e, http.MethodGet, tt.resource, "", "", "").Code)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
+	"github.com/example/go-template/internal/health"
+	"github.com/example/go-template/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probe requests a health endpoint and decodes its report
+func probe(t *testing.T, e http.Handler, path string) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestHealthProbes(t *testing.T) {
+	providers := di.NewProviders(config.Default())
+	e := server.New(providers).Router()
+
+	code, _ := probe(t, e, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = probe(t, e, "/startupz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	providers.Health.MarkStarted()
+	code, report := probe(t, e, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, report.Checks)
+	assert.Equal(t, "customer_repository", report.Checks[0].Name)
+	assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+
+	// Readiness fails once graceful shutdown begins
+	providers.Health.MarkShuttingDown()
+	code, report = probe(t, e, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+
+	code, _ = probe(t, e, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+}
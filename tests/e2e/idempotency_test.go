@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/middleware"
+	"github.com/example/go-template/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentUserCreation(t *testing.T) {
+	e := setupTestServer()
+
+	create := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := create("retry-1", `{"name":"Ada","email":"ada@example.com"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	retry := create("retry-1", `{"name":"Ada","email":"ada@example.com"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/users/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, create("retry-1", `{"name":"Bob","email":"bob@example.com"}`).Code)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	var users struct{ Data []user.User }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &users))
+	assert.Len(t, users.Data, 1)
+}
+
+func TestIdempotentCustomerCreation(t *testing.T) {
+	e := setupTestServer()
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/customer", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "customer-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var customer struct{ ID string }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &customer))
+		ids = append(ids, customer.ID)
+	}
+	assert.Equal(t, ids[0], ids[1])
+}
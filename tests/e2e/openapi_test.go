@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
+	"github.com/example/go-template/internal/openapi"
+	"github.com/example/go-template/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fetchSpec requests /openapi.json from the given handler and decodes it
+func fetchSpec(t *testing.T, handler http.Handler) openapi.Document {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	return doc
+}
+
+// assertRoutesDocumented fails for every visible route missing from the spec
+func assertRoutesDocumented(t *testing.T, doc openapi.Document, routes []common.RouteInfo) {
+	t.Helper()
+
+	for _, route := range routes {
+		if route.Doc.Hidden {
+			continue
+		}
+		path, _ := openapi.Path(route.Path)
+		operations, ok := doc.Paths[path]
+		if !assert.Truef(t, ok, "route %s %s (%s) missing from spec", route.Method, route.Path, route.Name) {
+			continue
+		}
+		_, ok = operations[strings.ToLower(route.Method)]
+		assert.Truef(t, ok, "route %s %s (%s) missing from spec", route.Method, route.Path, route.Name)
+	}
+}
+
+func TestOpenAPICoversRegisteredRoutes(t *testing.T) {
+	srv := server.New(di.NewProviders(config.Default()))
+
+	doc := fetchSpec(t, srv.Router())
+	assertRoutesDocumented(t, doc, srv.Routes().Routes())
+
+	create := doc.Paths["/users"]["post"]
+	assert.NotNil(t, create.RequestBody)
+	assert.Contains(t, create.Responses, "201")
+	assert.Contains(t, doc.Components.Schemas, "user.User")
+
+	private := doc.Paths["/v1/private"]["get"]
+	assert.NotEmpty(t, private.Security)
+	assert.Contains(t, private.Responses, "401")
+}
+
+func TestSwaggerUIServesGeneratedSpec(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/index.html", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "openapi.json")
+}
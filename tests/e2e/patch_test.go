@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendPatch(e http.Handler, path, contentType, ifMatch, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if ifMatch != "" {
+		req.Header.Set(common.HeaderIfMatch, ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPatchResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   string
+		resource string
+		// decode extracts the resource from a response body
+		decode func(t *testing.T, body []byte) map[string]interface{}
+	}{
+		{"users", "/users", "/users/1", func(t *testing.T, body []byte) map[string]interface{} {
+			var resp struct{ Data map[string]interface{} }
+			require.NoError(t, json.Unmarshal(body, &resp))
+			return resp.Data
+		}},
+		{"customers", "/v1/customer", "/v1/customer/3", func(t *testing.T, body []byte) map[string]interface{} {
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &resp))
+			return resp
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := setupTestServer()
+			created := conditional(e, http.MethodPost, tt.create, "", "", `{"name":"Ada","email":"ada@example.com"}`)
+			require.Equal(t, http.StatusCreated, created.Code)
+
+			// A merge patch changes a single field
+			rec := sendPatch(e, tt.resource, patch.MediaTypeMergePatch, "", `{"email":"ada@lovelace.dev"}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			body := tt.decode(t, rec.Body.Bytes())
+			assert.Equal(t, "Ada", body["name"])
+			assert.Equal(t, "ada@lovelace.dev", body["email"])
+			assert.Equal(t, `"2"`, rec.Header().Get(common.HeaderETag))
+
+			// A JSON patch with a passing test applies atomically
+			rec = sendPatch(e, tt.resource, patch.MediaTypeJSONPatch, `"2"`,
+				`[{"op":"test","path":"/name","value":"Ada"},{"op":"replace","path":"/name","value":"Ada Lovelace"}]`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, "Ada Lovelace", tt.decode(t, rec.Body.Bytes())["name"])
+
+			// A failing test leaves the resource unchanged
+			rec = sendPatch(e, tt.resource, patch.MediaTypeJSONPatch, "",
+				`[{"op":"replace","path":"/email","value":"x@example.com"},{"op":"test","path":"/name","value":"Ada"}]`)
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Contains(t, rec.Body.String(), "operation 1 (test /name)")
+
+			errorCases := []struct {
+				name        string
+				contentType string
+				ifMatch     string
+				body        string
+				status      int
+			}{
+				{"invalid path", patch.MediaTypeJSONPatch, "", `[{"op":"remove","path":"/nickname"}]`, http.StatusUnprocessableEntity},
+				{"validation runs on the result", patch.MediaTypeMergePatch, "", `{"name":""}`, http.StatusUnprocessableEntity},
+				{"removing a required field", patch.MediaTypeJSONPatch, "", `[{"op":"remove","path":"/email"}]`, http.StatusUnprocessableEntity},
+				{"read-only field", patch.MediaTypeMergePatch, "", `{"version":10}`, http.StatusUnprocessableEntity},
+				{"unknown field", patch.MediaTypeMergePatch, "", `{"age":36}`, http.StatusUnprocessableEntity},
+				{"malformed patch", patch.MediaTypeJSONPatch, "", `{"op":"add"}`, http.StatusBadRequest},
+				{"unsupported media type", "application/json", "", `{"name":"Bob"}`, http.StatusUnsupportedMediaType},
+				{"stale version", patch.MediaTypeMergePatch, `"1"`, `{"name":"Bob"}`, http.StatusPreconditionFailed},
+			}
+			for _, ec := range errorCases {
+				rec := sendPatch(e, tt.resource, ec.contentType, ec.ifMatch, ec.body)
+				assert.Equal(t, ec.status, rec.Code, ec.name)
+			}
+
+			rec = conditional(e, http.MethodGet, tt.resource, "", "", "")
+			body = tt.decode(t, rec.Body.Bytes())
+			assert.Equal(t, "Ada Lovelace", body["name"])
+			assert.Equal(t, "ada@lovelace.dev", body["email"])
+			assert.EqualValues(t, 3, body["version"])
+
+			assert.Equal(t, http.StatusNotFound, sendPatch(e, tt.create+"/999", patch.MediaTypeMergePatch, "", `{}`).Code)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-template/internal/common"
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
+	"github.com/example/go-template/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func login(e http.Handler, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLoginIsRateLimitedPerIP(t *testing.T) {
+	e := setupTestServer()
+
+	for i := 0; i < 10; i++ {
+		rec := login(e, "203.0.113.7:1234", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "10;w=60", rec.Header().Get("RateLimit-Policy"))
+	}
+
+	// A forged X-Forwarded-For from an untrusted peer changes nothing
+	rec := login(e, "203.0.113.7:1234", "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.NotEmpty(t, rec.Header().Get("RateLimit-Reset"))
+
+	var body common.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "rate limit exceeded", body.Error)
+	assert.Equal(t, rec.Header().Get(common.HeaderRequestID), body.RequestID)
+
+	// Other clients keep their own budget
+	assert.Equal(t, http.StatusOK, login(e, "203.0.113.8:1234", "").Code)
+
+	// Routes without a rule are not limited
+	req := httptest.NewRequest(http.MethodGet, "/v1/public", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestCreationIsRateLimitedPerIP(t *testing.T) {
+	e := setupTestServer()
+
+	for _, path := range []string{"/users", "/v1/customer"} {
+		t.Run(path, func(t *testing.T) {
+			create := func(remoteAddr string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.RemoteAddr = remoteAddr
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				return rec
+			}
+
+			// The burst of 10 is spent by one anonymous client
+			for i := 0; i < 10; i++ {
+				require.Equal(t, http.StatusCreated, create("203.0.113.7:1234").Code)
+			}
+			assert.Equal(t, http.StatusTooManyRequests, create("203.0.113.7:1234").Code)
+			assert.Equal(t, http.StatusCreated, create("203.0.113.8:1234").Code)
+		})
+	}
+}
+
+func TestRateLimitTrustsConfiguredProxies(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/8"}
+	cfg.RateLimit.Rules = []string{"auth.login=1/1m"}
+	e := server.New(di.NewProviders(cfg)).Router()
+
+	assert.Equal(t, http.StatusOK, login(e, "10.0.0.2:1234", "198.51.100.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, login(e, "10.0.0.3:1234", "198.51.100.1").Code)
+	assert.Equal(t, http.StatusOK, login(e, "10.0.0.2:1234", "198.51.100.2").Code)
+}
+
+func TestRateLimitCanBeDisabled(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.Enabled = false
+	e := server.New(di.NewProviders(cfg)).Router()
+
+	for i := 0; i < 15; i++ {
+		require.Equal(t, http.StatusOK, login(e, "203.0.113.7:1234", "").Code)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculatorSession(t *testing.T) {
+	server := httptest.NewServer(setupTestServer())
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/calculator/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+
+	steps := []struct {
+		message string
+		want    string
+	}{
+		{`{"id":"a","expression":"x = 6"}`, `{"id":"a","result":6,"variable":"x"}`},
+		{"x * 7", `{"result":42}`},
+		{"ans / 0", `{"error":"division by zero","position":5}`},
+		{"y", `{"error":"unknown name \"y\"","position":1}`},
+	}
+	for _, step := range steps {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(step.message)))
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, step.want, string(data), step.message)
+	}
+
+	// Variables belong to the connection
+	other, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer other.Close()
+	require.NoError(t, other.WriteMessage(websocket.TextMessage, []byte("x")))
+	_, data, err := other.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `unknown name \"x\"`)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-template/internal/config"
+	"github.com/example/go-template/internal/di"
+	"github.com/example/go-template/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupTracedServer returns a server exporting spans in memory
+func setupTracedServer(t *testing.T) (http.Handler, *tracetest.InMemoryExporter) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Tracing.Exporter = config.TracingMemory
+	providers := di.NewProviders(cfg)
+	require.NoError(t, providers.Lifecycle.Start(context.Background()))
+	t.Cleanup(func() { providers.Lifecycle.Stop(context.Background()) })
+
+	exporter, ok := providers.SpanExporter.(*tracetest.InMemoryExporter)
+	require.True(t, ok)
+	return server.New(providers).Router(), exporter
+}
+
+// spanByName returns the exported span with the given name
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q", name)
+	return tracetest.SpanStub{}
+}
+
+// spanAttribute returns the value of the named attribute of a span
+func spanAttribute(span tracetest.SpanStub, key string) attribute.Value {
+	for _, a := range span.Attributes {
+		if string(a.Key) == key {
+			return a.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingSpanTree(t *testing.T) {
+	e, exporter := setupTracedServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/customer", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	serverSpan := spanByName(t, spans, "customers.list")
+	service := spanByName(t, spans, "CustomerService.ListCustomers")
+	repo := spanByName(t, spans, "CustomerRepository.ListCustomers")
+
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent.SpanID().String())
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), service.Parent.SpanID())
+	assert.Equal(t, service.SpanContext.SpanID(), repo.Parent.SpanID())
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	}
+
+	assert.Equal(t, "/v1/customer", spanAttribute(serverSpan, "http.route").AsString())
+	assert.Equal(t, int64(http.StatusOK), spanAttribute(serverSpan, "http.response.status_code").AsInt64())
+}
+
+func TestTracingRecordsRejectedTokens(t *testing.T) {
+	e, exporter := setupTracedServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/private", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	serverSpan := spanByName(t, spans, "private.get")
+	auth := spanByName(t, spans, "AuthService.ValidateToken")
+
+	assert.False(t, serverSpan.Parent.IsValid())
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), auth.Parent.SpanID())
+	assert.Equal(t, codes.Error, auth.Status.Code)
+	assert.Equal(t, codes.Unset, serverSpan.Status.Code, "4xx responses do not fail server spans")
+}
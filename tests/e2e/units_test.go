@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertUnits(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		query    string
+		result   float64
+		quantity string
+	}{
+		{"value=10&from=mi&to=km", 16.09344, "length"},
+		{"value=100&from=km/h&to=m/s", 27.7777777777778, "speed"},
+		{"value=100&from=degC&to=degF", 212, "temperature"},
+		{"value=2&from=GiB&to=MiB", 2048, "data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/convert?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var resp struct {
+				Data struct {
+					Result   float64 `json:"result"`
+					Quantity string  `json:"quantity"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.result, resp.Data.Result)
+			assert.Equal(t, tt.quantity, resp.Data.Quantity)
+		})
+	}
+}
+
+func TestConvertUnitsRejectsInvalidInput(t *testing.T) {
+	e := setupTestServer()
+
+	tests := []struct {
+		query string
+		error string
+	}{
+		{"from=m&to=km", "value must be a finite number"},
+		{"value=1&from=m", "from and to are required"},
+		{"value=1&from=furlong&to=m", "unknown unit"},
+		{"value=1&from=kg&to=m", "cannot convert kg (mass) to m (length)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/convert?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.error)
+		})
+	}
+}
+
+func TestListUnits(t *testing.T) {
+	e := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/units?quantity="+url.QueryEscape("temperature"), nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Data struct {
+			Units []struct {
+				Symbol   string `json:"symbol"`
+				Quantity string `json:"quantity"`
+			} `json:"units"`
+			Prefixes map[string][]struct {
+				Symbol string `json:"symbol"`
+			} `json:"prefixes"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	var symbols []string
+	for _, unit := range resp.Data.Units {
+		assert.Equal(t, "temperature", unit.Quantity)
+		symbols = append(symbols, unit.Symbol)
+	}
+	assert.Equal(t, []string{"K", "degC", "degF", "degR"}, symbols)
+	assert.Contains(t, resp.Data.Prefixes, "si")
+	assert.Contains(t, resp.Data.Prefixes, "binary")
+
+	req = httptest.NewRequest(http.MethodGet, "/units?quantity=luminosity", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown quantity")
+}
@@ -1,16 +1,18 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
+	"github.com/example/go-template/internal/config"
 	"github.com/example/go-template/internal/services"
 )
 
 const testUserSubject = "test-user"
 
 func TestIssueToken(t *testing.T) {
-	authService := services.NewAuthService()
-	token, err := authService.IssueToken(testUserSubject)
+	authService := services.NewAuthService(config.Default().Auth, nil)
+	token, err := authService.IssueToken(context.Background(), testUserSubject)
 
 	if err != nil {
 		t.Fatalf("IssueToken failed: %v", err)
@@ -22,10 +24,10 @@ func TestIssueToken(t *testing.T) {
 }
 
 func TestValidateToken(t *testing.T) {
-	authService := services.NewAuthService()
-	token, _ := authService.IssueToken(testUserSubject)
+	authService := services.NewAuthService(config.Default().Auth, nil)
+	token, _ := authService.IssueToken(context.Background(), testUserSubject)
 
-	claims, err := authService.ValidateToken(token)
+	claims, err := authService.ValidateToken(context.Background(), token)
 	if err != nil {
 		t.Fatalf("ValidateToken failed: %v", err)
 	}
@@ -36,8 +38,8 @@ func TestValidateToken(t *testing.T) {
 }
 
 func TestValidateInvalidToken(t *testing.T) {
-	authService := services.NewAuthService()
-	_, err := authService.ValidateToken("invalid.token.here")
+	authService := services.NewAuthService(config.Default().Auth, nil)
+	_, err := authService.ValidateToken(context.Background(), "invalid.token.here")
 
 	if err == nil {
 		t.Error("Expected validation to fail for invalid token")
@@ -45,14 +47,15 @@ func TestValidateInvalidToken(t *testing.T) {
 }
 
 func TestValidateTokenWithWrongSecret(t *testing.T) {
-	t.Setenv("JWT_SECRET", "your-super-secret-jwt-key-at-least-32-characters-long-for-hs256")
-	authService := services.NewAuthService()
-	token, _ := authService.IssueToken(testUserSubject)
+	cfg := config.Default().Auth
+	cfg.JWTSecret = "your-super-secret-jwt-key-at-least-32-characters-long-for-hs256"
+	authService := services.NewAuthService(cfg, nil)
+	token, _ := authService.IssueToken(context.Background(), testUserSubject)
 
-	t.Setenv("JWT_SECRET", "different-secret-key-at-least-32-characters-long-for-hs256")
-	authServiceWithDifferentSecret := services.NewAuthService()
+	cfg.JWTSecret = "different-secret-key-at-least-32-characters-long-for-hs256"
+	authServiceWithDifferentSecret := services.NewAuthService(cfg, nil)
 
-	_, err := authServiceWithDifferentSecret.ValidateToken(token)
+	_, err := authServiceWithDifferentSecret.ValidateToken(context.Background(), token)
 	if err == nil {
 		t.Error("Expected validation to fail with different secret")
 	}